@@ -26,6 +26,9 @@ Publish packs to Matrix rooms as MSC2545 state events.`,
 	rootCmd.AddCommand(cli.NewLoginCmd())
 	rootCmd.AddCommand(cli.NewTestCmd())
 	rootCmd.AddCommand(cli.NewBotCmd())
+	rootCmd.AddCommand(cli.NewImportCmd())
+	rootCmd.AddCommand(cli.NewMigrateCmd())
+	rootCmd.AddCommand(cli.NewSearchCmd())
 
 	// Execute
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"maunium.net/go/mautrix/id"
+)
+
+// Role is a user's access level, ordered so higher roles include lower ones
+type Role int
+
+// Access roles
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleCurator
+	RoleAdmin
+)
+
+// String returns the config name of the role
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return config.RoleViewer
+	case RoleCurator:
+		return config.RoleCurator
+	case RoleAdmin:
+		return config.RoleAdmin
+	default:
+		return "none"
+	}
+}
+
+// parseRole converts a config role name, treating unknown names as no access
+func parseRole(name string) Role {
+	switch name {
+	case config.RoleViewer:
+		return RoleViewer
+	case config.RoleCurator:
+		return RoleCurator
+	case config.RoleAdmin:
+		return RoleAdmin
+	default:
+		return RoleNone
+	}
+}
+
+// accessList resolves Matrix users to roles. The bot's own account is always admin,
+// then an exact Matrix ID rule wins over a homeserver domain rule.
+type accessList struct {
+	owner   id.UserID
+	users   map[id.UserID]Role
+	domains map[string]Role
+}
+
+// newAccessList builds the access list from config rules
+func newAccessList(owner id.UserID, cfg config.AccessConfig) *accessList {
+	a := &accessList{
+		owner:   owner,
+		users:   make(map[id.UserID]Role),
+		domains: make(map[string]Role),
+	}
+
+	for _, rule := range cfg.Users {
+		role := parseRole(rule.Role)
+		if strings.HasPrefix(rule.Match, "@") {
+			a.users[id.UserID(rule.Match)] = max(a.users[id.UserID(rule.Match)], role)
+		} else {
+			domain := strings.ToLower(strings.TrimPrefix(rule.Match, ":"))
+			a.domains[domain] = max(a.domains[domain], role)
+		}
+	}
+
+	return a
+}
+
+// Role returns the access level of a user
+func (a *accessList) Role(user id.UserID) Role {
+	if user == a.owner {
+		return RoleAdmin
+	}
+	if role, ok := a.users[user]; ok {
+		return role
+	}
+	return a.domains[strings.ToLower(user.Homeserver())]
+}
+
+// senders returns every user the bot acts for, or nil if whole homeservers are allowed
+func (a *accessList) senders() []id.UserID {
+	if len(a.domains) > 0 {
+		return nil
+	}
+
+	senders := []id.UserID{a.owner}
+	for user, role := range a.users {
+		if role > RoleNone && user != a.owner {
+			senders = append(senders, user)
+		}
+	}
+	slices.Sort(senders)
+
+	return senders
+}
+
+// commandRoles is the minimum role for each command. Pack and jobs subcommands are
+// keyed "pack <subcommand>" and "jobs <subcommand>"; anything not listed (help,
+// unknown commands) needs viewer.
+var commandRoles = map[string]Role{
+	"name":       RoleCurator,
+	"usage":      RoleCurator,
+	"tag":        RoleCurator,
+	"untag":      RoleCurator,
+	"alt":        RoleCurator,
+	"delete":     RoleAdmin,
+	"remove":     RoleAdmin,
+	"regenerate": RoleAdmin,
+
+	"pack create":  RoleCurator,
+	"pack add":     RoleCurator,
+	"pack remove":  RoleCurator,
+	"pack avatar":  RoleCurator,
+	"pack usage":   RoleCurator,
+	"pack title":   RoleCurator,
+	"pack move":    RoleCurator,
+	"pack publish": RoleCurator,
+	"pack import":  RoleCurator,
+	"pack fromtag": RoleCurator,
+	"jobs retry":   RoleCurator,
+	"jobs clear":   RoleCurator,
+
+	"pack delete":      RoleAdmin,
+	"pack rename":      RoleAdmin,
+	"pack unpublish":   RoleAdmin,
+	"pack subscribe":   RoleAdmin,
+	"pack unsubscribe": RoleAdmin,
+}
+
+// requiredRole returns the minimum role needed to run a command
+func requiredRole(args []string) Role {
+	if len(args) == 0 {
+		return RoleViewer
+	}
+
+	key := args[0]
+	if (key == "pack" || key == "jobs") && len(args) > 1 {
+		key = key + " " + args[1]
+	}
+
+	// Showing alt-text is viewing; setting or regenerating it is editing
+	if key == "alt" && len(args) <= 2 {
+		return RoleViewer
+	}
+
+	// Linting is viewing; fixing renames stickers
+	if key == "pack lint" && slices.Contains(args, "--fix") {
+		return RoleCurator
+	}
+
+	// Personal emotes and emote rooms live in the bot account's own account data
+	if key == "pack publish" && (slices.Contains(args, "--personal") || slices.Contains(args, "--subscribe")) {
+		return RoleAdmin
+	}
+
+	if role, ok := commandRoles[key]; ok {
+		return role
+	}
+	return RoleViewer
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"maunium.net/go/mautrix/id"
+)
+
+// TestAccessList_Role verifies owner, user and domain rules resolve to the right roles
+func TestAccessList_Role(t *testing.T) {
+	access := newAccessList("@bot:example.org", config.AccessConfig{Users: []config.AccessRule{
+		{Match: "@alice:example.org", Role: config.RoleAdmin},
+		{Match: "@bob:example.org", Role: config.RoleViewer},
+		{Match: "Example.org", Role: config.RoleCurator},
+		{Match: ":friends.net", Role: config.RoleViewer},
+	}})
+
+	tests := []struct {
+		user     id.UserID
+		expected Role
+	}{
+		{"@bot:example.org", RoleAdmin},
+		{"@alice:example.org", RoleAdmin},
+		{"@bob:example.org", RoleViewer}, // Exact rule wins over the domain rule
+		{"@carol:example.org", RoleCurator},
+		{"@dave:friends.net", RoleViewer},
+		{"@eve:elsewhere.com", RoleNone},
+	}
+
+	for _, tt := range tests {
+		if got := access.Role(tt.user); got != tt.expected {
+			t.Errorf("Role(%s) = %s, expected %s", tt.user, got, tt.expected)
+		}
+	}
+}
+
+// TestRequiredRole verifies commands map to the expected minimum role
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected Role
+	}{
+		{"", RoleViewer},
+		{"pack list", RoleViewer},
+		{"show abc", RoleViewer},
+		{"name abc happy", RoleCurator},
+		{"pack add cats abc", RoleCurator},
+		{"pack publish cats !room:example.org", RoleCurator},
+		{"pack publish cats --personal", RoleAdmin},
+		{"pack publish cats !room:example.org --subscribe", RoleAdmin},
+		{"pack lint cats", RoleViewer},
+		{"pack lint cats --fix", RoleCurator},
+		{"pack delete cats", RoleAdmin},
+		{"delete abc", RoleAdmin},
+		{"alt abc", RoleViewer},
+		{"alt abc A happy cat", RoleCurator},
+		{"alt abc --regenerate", RoleCurator},
+		{"regenerate outdated", RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		if got := requiredRole(strings.Fields(tt.command)); got != tt.expected {
+			t.Errorf("requiredRole(%q) = %s, expected %s", tt.command, got, tt.expected)
+		}
+	}
+}
+
+// TestExecuteCommand_RoleChecks verifies commands are refused for users without the role
+func TestExecuteCommand_RoleChecks(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	bot.access = newAccessList(bot.client.UserID, config.AccessConfig{Users: []config.AccessRule{
+		{Match: "@viewer:matrix.org", Role: config.RoleViewer},
+		{Match: "@curator:matrix.org", Role: config.RoleCurator},
+	}})
+
+	result := bot.executeCommand(context.Background(), "@viewer:matrix.org", "!sticker pack create cats")
+	if !strings.Contains(result, "needs the curator role") {
+		t.Errorf("Expected viewer to be refused, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), "@viewer:matrix.org", "!sticker pack list")
+	if strings.Contains(result, "❌") {
+		t.Errorf("Expected viewer to list packs, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), "@curator:matrix.org", "!sticker pack create cats")
+	if !strings.Contains(result, "✅") {
+		t.Fatalf("Expected curator to create pack, got: %s", result)
+	}
+	pack, err := bot.store.GetPack("cats")
+	if err != nil {
+		t.Fatalf("Failed to get pack: %v", err)
+	}
+	if pack.Attribution != "@curator:matrix.org" {
+		t.Errorf("Expected pack attributed to its creator, got %s", pack.Attribution)
+	}
+
+	result = bot.executeCommand(context.Background(), "@curator:matrix.org", "!sticker pack delete cats")
+	if !strings.Contains(result, "needs the admin role") {
+		t.Errorf("Expected curator to be refused pack delete, got: %s", result)
+	}
+}
@@ -0,0 +1,275 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/llm"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// altTextUsage explains the alt command
+const altTextUsage = "❌ Usage: !sticker alt <sticker-id> [text | --lang <code> <text> | --regenerate [hint]]\n\n" +
+	"With no text, shows the sticker's alt-text. Text you write is kept when alt-text is regenerated in bulk."
+
+// handleAltCommand handles !sticker alt <sticker-id> [...], sent as eventID in roomID
+func (b *Bot) handleAltCommand(sender id.UserID, roomID id.RoomID, eventID id.EventID, args []string) string {
+	if len(args) == 0 {
+		return altTextUsage
+	}
+
+	return b.withSticker(sender, args[0], func(stickerID string) string {
+		rest := args[1:]
+		switch {
+		case len(rest) == 0:
+			return b.stickerAltShow(stickerID)
+		case rest[0] == "--regenerate":
+			return b.stickerAltRegenerate(sender, roomID, eventID, stickerID, strings.Join(rest[1:], " "))
+		case rest[0] == "--lang":
+			if len(rest) < 3 {
+				return altTextUsage
+			}
+			return b.stickerAltSet(stickerID, rest[1], strings.Join(rest[2:], " "))
+		default:
+			return b.stickerAltSet(stickerID, "", strings.Join(rest, " "))
+		}
+	})
+}
+
+// stickerAltShow shows a sticker's alt-text in each language and who wrote it
+func (b *Bot) stickerAltShow(stickerID string) string {
+	sticker, err := b.store.GetSticker(stickerID)
+	if err != nil {
+		return stickerError(stickerID, err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Alt-text for `%s` (:%s:):\n\n", sticker.ID, sticker.Name))
+
+	if len(sticker.AltTexts) == 0 {
+		result.WriteString(fmt.Sprintf("- %s\n", sticker.AltTextIn("")))
+	}
+	for _, language := range slices.Sorted(maps.Keys(sticker.AltTexts)) {
+		result.WriteString(fmt.Sprintf("- **%s:** %s\n", language, sticker.AltTexts[language]))
+	}
+
+	result.WriteString("\n" + altTextAuthor(sticker))
+	return result.String()
+}
+
+// altTextAuthor describes who wrote a sticker's alt-text
+func altTextAuthor(sticker *storage.Sticker) string {
+	switch {
+	case sticker.HumanAltText():
+		return "Written by hand"
+	case sticker.AltTextModel != "":
+		return fmt.Sprintf("Written by %s", sticker.AltTextModel)
+	case sticker.GeneratedAltText == "" || sticker.GeneratedAltText == sticker.OriginalBody:
+		return "The description it was sent with (no alt-text generated)"
+	default:
+		return "Written by a model before models were recorded"
+	}
+}
+
+// stickerAltSet sets a sticker's alt-text by hand, in the main language if language
+// is empty. Hand-written alt-text is never replaced by bulk regeneration, so setting
+// the main language clears the others rather than keep translations of the old text.
+func (b *Bot) stickerAltSet(stickerID string, language string, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "❌ Alt-text cannot be empty"
+	}
+
+	languages := b.config.AltText.LanguageCodes()
+	if language == "" {
+		language = languages[0]
+	}
+	if !slices.Contains(languages, language) {
+		return fmt.Sprintf("❌ Unknown language: %s (configured: %s)", language, strings.Join(languages, ", "))
+	}
+
+	sticker, err := b.store.GetSticker(stickerID)
+	if err != nil {
+		return stickerError(stickerID, err)
+	}
+
+	altText := storage.AltText{
+		Text:      sticker.GeneratedAltText,
+		Languages: maps.Clone(sticker.AltTexts),
+		By:        storage.AltTextByHuman,
+	}
+	if altText.Languages == nil {
+		altText.Languages = make(map[string]string)
+	}
+	// The other languages describe the text being replaced, and would be kept for good
+	// once the sticker counts as written by hand
+	var cleared []string
+	if language == languages[0] {
+		altText.Text = text
+		for _, other := range slices.Sorted(maps.Keys(altText.Languages)) {
+			if other != language {
+				delete(altText.Languages, other)
+				cleared = append(cleared, other)
+			}
+		}
+	}
+	altText.Languages[language] = text
+
+	if err := b.store.UpdateAltText(stickerID, altText); err != nil {
+		return fmt.Sprintf("❌ Error setting alt-text: %v", err)
+	}
+
+	result := fmt.Sprintf("✅ Set %s alt-text for `%s`: %s", language, stickerID, text)
+	if len(cleared) > 0 {
+		result += fmt.Sprintf("\n\n⚠️ Cleared the %s alt-text written for the old text, so %s is used until you set it with `!sticker alt %s --lang <code> <text>`",
+			strings.Join(cleared, ", "), language, stickerID)
+	}
+	return result + b.refreshPersonal(sticker.InPacks...)
+}
+
+// stickerAltRegenerate queues a job to describe a sticker again, replacing its
+// alt-text even if it was written by hand. The hint is passed on to the model, and
+// the command's message is answered once the job is done.
+func (b *Bot) stickerAltRegenerate(sender id.UserID, roomID id.RoomID, eventID id.EventID, stickerID string, hint string) string {
+	if b.llmClient.Model() == "" {
+		return "❌ No alt-text provider is configured (alt_text.provider is none)"
+	}
+
+	if _, err := b.store.GetSticker(stickerID); err != nil {
+		return stickerError(stickerID, err)
+	}
+
+	job := storage.Job{
+		ID:           jobID(strings.Join([]string{"regenerate", stickerID, eventID.String(), hint}, " ")),
+		Kind:         storage.JobRegenerate,
+		RoomID:       roomID.String(),
+		RequestedBy:  sender.String(),
+		StickerID:    stickerID,
+		Hint:         hint,
+		ReplaceHuman: true,
+		CommandEvent: eventID.String(),
+	}
+	if err := b.jobs.enqueue(job); err != nil {
+		return fmt.Sprintf("❌ Error queueing alt-text regeneration: %v", err)
+	}
+
+	if eventID == "" {
+		return fmt.Sprintf("✅ Queued alt-text regeneration for `%s`\n\nCheck on it with `!sticker jobs`", stickerID)
+	}
+	return fmt.Sprintf("✅ Regenerating alt-text for `%s` - the new alt-text will follow once it's written", stickerID)
+}
+
+// describeSticker downloads a sticker's rehosted image and describes it again with
+// the prompt for its packs plus an optional hint
+func (b *Bot) describeSticker(ctx context.Context, sticker *storage.Sticker, hint string) (*imageDescription, error) {
+	image, err := b.fetchImage(ctx, id.ContentURIString(sticker.LocalMXC), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := llm.WithHint(b.config.AltText.PackPrompt(sticker.InPacks...), hint)
+	description, err := b.describeImage(ctx, image, sticker.OriginalBody, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if description.model == "" {
+		return nil, permanent(fmt.Errorf("the model gave no alt-text"))
+	}
+
+	return description, nil
+}
+
+// handleRegenerateCommand handles !sticker regenerate pack <pack> and
+// !sticker regenerate outdated
+func (b *Bot) handleRegenerateCommand(sender id.UserID, args []string) string {
+	const usage = "❌ Usage: !sticker regenerate pack <pack> | !sticker regenerate outdated\n\n" +
+		"Regenerates machine-written alt-text for a pack's stickers, or for stickers described by another model. " +
+		"Alt-text written by hand, and stickers that only have the description they were sent with, are kept."
+
+	if len(args) == 0 {
+		return usage
+	}
+	if b.llmClient.Model() == "" {
+		return "❌ No alt-text provider is configured (alt_text.provider is none)"
+	}
+
+	switch {
+	case args[0] == "pack" && len(args) == 2:
+		packID := sanitizePackName(args[1])
+		stickers, err := b.store.PackStickers(packID)
+		if err != nil {
+			return fmt.Sprintf("❌ Error loading pack: %v", err)
+		}
+		return b.regenerateStickers(sender, stickers, "pack "+packID)
+	case args[0] == "outdated" && len(args) == 1:
+		stickers, err := b.store.ListStickers()
+		if err != nil {
+			return fmt.Sprintf("❌ Error loading collection: %v", err)
+		}
+		model := b.llmClient.Model()
+		outdated := slices.DeleteFunc(stickers, func(sticker storage.Sticker) bool {
+			return sticker.AltTextModel == model
+		})
+		return b.regenerateStickers(sender, outdated, "stickers not described by "+model)
+	default:
+		return usage
+	}
+}
+
+// hasOwnAltText reports whether a sticker has alt-text of its own, rather than just
+// the description it was sent with
+func hasOwnAltText(sticker *storage.Sticker) bool {
+	return sticker.AltTextBy != "" || sticker.AltTextModel != "" ||
+		(sticker.GeneratedAltText != "" && sticker.GeneratedAltText != sticker.OriginalBody)
+}
+
+// regenerateStickers queues a regeneration job for each sticker with machine-written
+// alt-text, summarising what was queued. Stickers written by hand or with only their
+// original description are skipped. Each vision call runs in the background with the
+// collection jobs' retries, rather than holding up the command.
+func (b *Bot) regenerateStickers(sender id.UserID, stickers []storage.Sticker, what string) string {
+	var queued, human int
+	var failed []string
+
+	for _, sticker := range stickers {
+		if !hasOwnAltText(&sticker) {
+			continue
+		}
+		if sticker.HumanAltText() {
+			human++
+			continue
+		}
+		job := storage.Job{
+			ID:          jobID("regenerate " + sticker.ID),
+			Kind:        storage.JobRegenerate,
+			RequestedBy: sender.String(),
+			StickerID:   sticker.ID,
+		}
+		if err := b.jobs.enqueue(job); err != nil {
+			failed = append(failed, fmt.Sprintf("`%s`: %v", sticker.ID, err))
+			continue
+		}
+		queued++
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("✅ Queued alt-text regeneration for %d sticker(s) in %s", queued, what))
+	if human > 0 {
+		result.WriteString(fmt.Sprintf(" (%d written by hand, kept)", human))
+	}
+	if queued > 0 {
+		result.WriteString("\n\nCheck on them with `!sticker jobs`")
+	}
+	if len(failed) > 0 {
+		result.WriteString(fmt.Sprintf("\n\n⚠️ %d sticker(s) couldn't be queued:\n", len(failed)))
+		for _, failure := range failed {
+			result.WriteString(fmt.Sprintf("- %s\n", failure))
+		}
+	}
+
+	return result.String()
+}
@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// altTextOptions sets up a bot whose homeserver serves a PNG for every media download
+// and whose alt-text generator describes it in English and German. The collection
+// holds a sticker described by an older model, one described by hand and one
+// described by the current model.
+func altTextOptions(t *testing.T) serverBotOptions {
+	return serverBotOptions{
+		image: testPNG(t, 8),
+		configure: func(cfg *config.Config) {
+			cfg.AltText = config.AltTextConfig{Languages: []string{"en", "de"}}
+		},
+		generator: &fakeGenerator{
+			reply:        func(string) string { return "A new cat" },
+			translations: map[string]string{"de": "Eine neue Katze"},
+		},
+		stickers: []storage.Sticker{
+			{ID: "sha256:cat1", Name: "cat1", LocalMXC: "mxc://matrix.org/cat1", GeneratedAltText: "An old cat",
+				AltTextBy: storage.AltTextByModel, AltTextModel: "old-model", InPacks: []string{}},
+			{ID: "sha256:cat2", Name: "cat2", LocalMXC: "mxc://matrix.org/cat2", GeneratedAltText: "My cat Tom",
+				AltTextBy: storage.AltTextByHuman, InPacks: []string{}},
+			{ID: "sha256:cat3", Name: "cat3", LocalMXC: "mxc://matrix.org/cat3", GeneratedAltText: "A current cat",
+				AltTextBy: storage.AltTextByModel, AltTextModel: "fake-model", InPacks: []string{}},
+		},
+	}
+}
+
+// TestExecuteCommand_AltSet verifies alt-text written by hand is saved and marked
+func TestExecuteCommand_AltSet(t *testing.T) {
+	bot, _ := setupServerBot(t, altTextOptions(t))
+	ctx := context.Background()
+	altText := storage.AltText{Text: "An old cat", Languages: map[string]string{"en": "An old cat", "de": "Eine alte Katze"},
+		By: storage.AltTextByModel, Model: "old-model"}
+	if err := bot.store.UpdateAltText("sha256:cat1", altText); err != nil {
+		t.Fatalf("Failed to set alt-text: %v", err)
+	}
+
+	// The machine translation of the replaced text goes with it
+	result := bot.executeCommand(ctx, bot.client.UserID, "!sticker alt :cat1: A sleepy ginger cat")
+	if !strings.HasPrefix(result, "✅") || !strings.Contains(result, "Cleared the de alt-text") {
+		t.Fatalf("Expected alt-text to be set and de cleared, got: %s", result)
+	}
+	if sticker, _ := bot.store.GetSticker("sha256:cat1"); len(sticker.AltTexts) != 1 || sticker.AltTextIn("de") != "A sleepy ginger cat" {
+		t.Errorf("Expected only en alt-text, got %v", sticker.AltTexts)
+	}
+	result = bot.executeCommand(ctx, bot.client.UserID, "!sticker alt :cat1: --lang de Eine müde Katze")
+	if !strings.HasPrefix(result, "✅") {
+		t.Fatalf("Expected German alt-text to be set, got: %s", result)
+	}
+
+	sticker, _ := bot.store.GetSticker("sha256:cat1")
+	if sticker.GeneratedAltText != "A sleepy ginger cat" || sticker.AltTexts["en"] != "A sleepy ginger cat" || sticker.AltTexts["de"] != "Eine müde Katze" {
+		t.Errorf("Unexpected alt-text: %q %v", sticker.GeneratedAltText, sticker.AltTexts)
+	}
+	if !sticker.HumanAltText() || sticker.AltTextModel != "" {
+		t.Errorf("Expected alt-text marked as written by hand, got %q %q", sticker.AltTextBy, sticker.AltTextModel)
+	}
+
+	result = bot.executeCommand(ctx, bot.client.UserID, "!sticker alt :cat1:")
+	if !strings.Contains(result, "**de:** Eine müde Katze") || !strings.Contains(result, "Written by hand") {
+		t.Errorf("Expected both languages and author, got: %s", result)
+	}
+
+	result = bot.executeCommand(ctx, bot.client.UserID, "!sticker alt :cat1: --lang fr Un chat")
+	if !strings.Contains(result, "Unknown language: fr") {
+		t.Errorf("Expected unknown language error, got: %s", result)
+	}
+}
+
+// TestExecuteCommand_AltRegenerate verifies one sticker is queued to be described
+// again with the user's hint, replacing even hand-written alt-text
+func TestExecuteCommand_AltRegenerate(t *testing.T) {
+	opts := altTextOptions(t)
+	bot, _ := setupServerBot(t, opts)
+	generator := opts.generator
+	ctx := context.Background()
+
+	result := bot.executeCommandFrom(ctx, bot.client.UserID, "!room:matrix.org", "$command", "!sticker alt :cat2: --regenerate it's Tom from next door")
+	if !strings.HasPrefix(result, "✅ Regenerating") {
+		t.Fatalf("Expected regeneration to be queued, got: %s", result)
+	}
+	if len(generator.prompts) != 0 {
+		t.Fatalf("Expected no model call from the command itself, got %q", generator.prompts)
+	}
+	jobs, _ := bot.jobs.list()
+	if len(jobs) != 1 || jobs[0].Kind != storage.JobRegenerate || jobs[0].CommandEvent != "$command" || jobs[0].RoomID != "!room:matrix.org" {
+		t.Fatalf("Expected a regeneration job answering the command, got %+v", jobs)
+	}
+
+	if remaining, err := bot.RunJobs(ctx); err != nil || len(remaining) != 0 {
+		t.Fatalf("Expected the job to finish, got %+v, %v", remaining, err)
+	}
+	if len(generator.prompts) != 1 || !strings.Contains(generator.prompts[0], "Tom from next door") {
+		t.Errorf("Expected the hint in a single prompt, got %q", generator.prompts)
+	}
+
+	sticker, _ := bot.store.GetSticker("sha256:cat2")
+	if sticker.GeneratedAltText != "A new cat" || sticker.AltTexts["de"] != "Eine neue Katze" {
+		t.Errorf("Unexpected alt-text: %q %v", sticker.GeneratedAltText, sticker.AltTexts)
+	}
+	if sticker.AltTextBy != storage.AltTextByModel || sticker.AltTextModel != "fake-model" {
+		t.Errorf("Expected alt-text marked as machine-written, got %q %q", sticker.AltTextBy, sticker.AltTextModel)
+	}
+}
+
+// TestExecuteCommand_Regenerate verifies bulk regeneration is queued, skipping
+// hand-written alt-text and stickers with only their original description
+func TestExecuteCommand_Regenerate(t *testing.T) {
+	bot, _ := setupServerBot(t, altTextOptions(t))
+	ctx := context.Background()
+
+	original := storage.Sticker{ID: "sha256:cat4", Name: "cat4", LocalMXC: "mxc://matrix.org/cat4",
+		OriginalBody: "cat.gif", GeneratedAltText: "cat.gif", InPacks: []string{}}
+	if err := bot.store.AddSticker(original); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+
+	result := bot.executeCommand(ctx, bot.client.UserID, "!sticker regenerate outdated")
+	if !strings.Contains(result, "Queued alt-text regeneration for 1 sticker(s)") || !strings.Contains(result, "1 written by hand, kept") {
+		t.Fatalf("Expected one queued and one kept, got: %s", result)
+	}
+	if sticker, _ := bot.store.GetSticker("sha256:cat1"); sticker.GeneratedAltText != "An old cat" {
+		t.Errorf("Expected alt-text unchanged until the job runs, got %q", sticker.GeneratedAltText)
+	}
+	if remaining, err := bot.RunJobs(ctx); err != nil || len(remaining) != 0 {
+		t.Fatalf("Expected every job to finish, got %+v, %v", remaining, err)
+	}
+
+	for id, expected := range map[string]string{
+		"sha256:cat1": "A new cat",
+		"sha256:cat2": "My cat Tom",
+		"sha256:cat3": "A current cat",
+		"sha256:cat4": "cat.gif",
+	} {
+		sticker, _ := bot.store.GetSticker(id)
+		if sticker.GeneratedAltText != expected {
+			t.Errorf("Expected %s alt-text %q, got %q", id, expected, sticker.GeneratedAltText)
+		}
+	}
+	if sticker, _ := bot.store.GetSticker("sha256:cat1"); sticker.AltTextModel != "fake-model" || sticker.AltTexts["de"] != "Eine neue Katze" {
+		t.Errorf("Expected regenerated alt-text from fake-model in every language, got %q %v", sticker.AltTextModel, sticker.AltTexts)
+	}
+
+	bot.executeCommand(ctx, bot.client.UserID, "!sticker pack create cats")
+	bot.executeCommand(ctx, bot.client.UserID, "!sticker pack add cats :cat2:")
+	bot.executeCommand(ctx, bot.client.UserID, "!sticker pack add cats :cat3:")
+	result = bot.executeCommand(ctx, bot.client.UserID, "!sticker regenerate pack cats")
+	if !strings.Contains(result, "Queued alt-text regeneration for 1 sticker(s) in pack cats") {
+		t.Errorf("Expected one sticker queued in the pack, got: %s", result)
+	}
+
+	// Alt-text written by hand while the job waits is kept too
+	bot.executeCommand(ctx, bot.client.UserID, "!sticker alt :cat3: Tom's brother")
+	if _, err := bot.RunJobs(ctx); err != nil {
+		t.Fatalf("Failed to run jobs: %v", err)
+	}
+	for id, expected := range map[string]string{
+		"sha256:cat2": "My cat Tom",
+		"sha256:cat3": "Tom's brother",
+	} {
+		if sticker, _ := bot.store.GetSticker(id); sticker.GeneratedAltText != expected {
+			t.Errorf("Expected hand-written alt-text %q for %s, got %q", expected, id, sticker.GeneratedAltText)
+		}
+	}
+}
+
+// TestExecuteCommand_RegeneratePack verifies regenerating a pack queues only its
+// machine-written stickers, keeping hand-written alt-text and original descriptions
+func TestExecuteCommand_RegeneratePack(t *testing.T) {
+	bot, _ := setupServerBot(t, altTextOptions(t))
+	ctx := context.Background()
+
+	original := storage.Sticker{ID: "sha256:cat4", Name: "cat4", LocalMXC: "mxc://matrix.org/cat4",
+		OriginalBody: "cat.gif", GeneratedAltText: "cat.gif", InPacks: []string{}}
+	if err := bot.store.AddSticker(original); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+	if err := bot.store.CreatePack("cats", "Cats", ""); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	if err := bot.store.AddToPack("cats", []string{"sha256:cat1", "sha256:cat2", "sha256:cat3", "sha256:cat4"}); err != nil {
+		t.Fatalf("Failed to add to pack: %v", err)
+	}
+
+	result := bot.executeCommand(ctx, bot.client.UserID, "!sticker regenerate pack cats")
+	if !strings.Contains(result, "Queued alt-text regeneration for 2 sticker(s) in pack cats") || !strings.Contains(result, "1 written by hand, kept") {
+		t.Fatalf("Expected two queued and one kept, got: %s", result)
+	}
+	if remaining, err := bot.RunJobs(ctx); err != nil || len(remaining) != 0 {
+		t.Fatalf("Expected every job to finish, got %+v, %v", remaining, err)
+	}
+
+	for id, expected := range map[string]string{
+		"sha256:cat1": "A new cat",
+		"sha256:cat2": "My cat Tom",
+		"sha256:cat3": "A new cat",
+		"sha256:cat4": "cat.gif",
+	} {
+		if sticker, _ := bot.store.GetSticker(id); sticker.GeneratedAltText != expected {
+			t.Errorf("Expected %s alt-text %q, got %q", id, expected, sticker.GeneratedAltText)
+		}
+	}
+}
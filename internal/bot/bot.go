@@ -3,6 +3,7 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -11,68 +12,62 @@ import (
 	"github.com/liminalpurple/matrix-stickerbook/internal/config"
 	"github.com/liminalpurple/matrix-stickerbook/internal/llm"
 	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
 	"maunium.net/go/mautrix"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 )
 
-// simpleStore implements a minimal mautrix.SyncStore that only tracks next_batch
-type simpleStore struct {
-	mu        sync.RWMutex
-	nextBatch string
-}
-
-func (s *simpleStore) SaveFilterID(ctx context.Context, userID id.UserID, filterID string) error {
-	return nil
-}
-func (s *simpleStore) LoadFilterID(ctx context.Context, userID id.UserID) (string, error) {
-	return "", nil
-}
-func (s *simpleStore) SaveNextBatch(ctx context.Context, userID id.UserID, nextBatchToken string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.nextBatch = nextBatchToken
-	return nil
-}
-func (s *simpleStore) LoadNextBatch(ctx context.Context, userID id.UserID) (string, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.nextBatch, nil
-}
-
 // Bot watches Matrix rooms for reaction commands and collects stickers
 type Bot struct {
 	client     *matrix.Client
-	llmClient  *llm.Client
+	llmClient  llm.AltTextGenerator
+	store      storage.Store
 	storageDir string
 	syncer     *mautrix.DefaultSyncer
 	ctx        context.Context
 	cancel     context.CancelFunc
 	config     *config.Config
-	nextBatch  string
+	access     *accessList
+	syncStore  *syncStore
+	journal    *storage.EventJournal
+	jobs       *jobQueue
+	collecting imageLocks // Images a job is collecting right now
+
+	// Sticker IDs from each user's last numbered listing, for picking by position
+	listings   map[id.UserID][]string
+	listingsMu sync.Mutex
 }
 
 // NewBot creates a new bot instance
-func NewBot(matrixClient *matrix.Client, llmClient *llm.Client, cfg *config.Config) *Bot {
+func NewBot(matrixClient *matrix.Client, llmClient llm.AltTextGenerator, store storage.Store, cfg *config.Config) *Bot {
 	ctx, cancel := context.WithCancel(context.Background())
+	access := newAccessList(matrixClient.UserID, cfg.Access)
 
-	// Create store with initial next_batch
-	store := &simpleStore{
-		nextBatch: cfg.Matrix.NextBatch,
-	}
+	// Only sync the events we act on, from the users allowed to use the bot
+	syncer := matrixClient.Syncer.(*mautrix.DefaultSyncer)
+	syncer.FilterJSON = syncFilter(access.senders(), matrixClient.EncryptionEnabled())
 
-	// Set store on client so it uses our next_batch
-	matrixClient.Store = store
+	// Keep the sync position in the data directory, picking up a token saved in
+	// config.yaml by older versions
+	syncStore := newSyncStore(cfg.Storage.DataDir, cfg.Matrix.NextBatch, syncer.FilterJSON)
+	matrixClient.Store = syncStore
+	matrixClient.Syncer = &processedSyncer{DefaultSyncer: syncer, store: syncStore}
 
 	bot := &Bot{
 		client:     matrixClient,
 		llmClient:  llmClient,
+		store:      store,
 		storageDir: cfg.Storage.DataDir,
-		syncer:     matrixClient.Syncer.(*mautrix.DefaultSyncer),
+		syncer:     syncer,
 		ctx:        ctx,
 		cancel:     cancel,
 		config:     cfg,
-		nextBatch:  cfg.Matrix.NextBatch,
+		access:     access,
+		syncStore:  syncStore,
+		journal:    storage.NewEventJournal(cfg.Storage.DataDir, time.Duration(cfg.Storage.JournalRetentionDays)*24*time.Hour),
+		jobs:       newJobQueue(cfg.Storage.DataDir, cfg.Jobs.MaxAttempts),
+		listings:   make(map[id.UserID][]string),
 	}
 
 	// Register event handlers
@@ -87,80 +82,29 @@ func (b *Bot) Run() error {
 	log.Println("Starting bot sync loop...")
 
 	// Log resume point if we have one
-	if b.nextBatch != "" {
-		truncated := b.nextBatch
-		if len(truncated) > 20 {
-			truncated = truncated[:20] + "..."
-		}
-		log.Printf("Resuming from next_batch: %s", truncated)
+	nextBatch, err := b.syncStore.LoadNextBatch(b.ctx, b.client.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load sync position: %w", err)
+	}
+	if nextBatch != "" {
+		log.Printf("Resuming from next_batch: %s", nextBatch[:min(len(nextBatch), 20)])
 	} else {
 		log.Println("No previous sync token, starting from current state")
 	}
 
-	// Start hourly ticker to save next_batch
-	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
-
-	// Also check every 10 seconds for first sync completion
-	firstSyncCheck := time.NewTicker(10 * time.Second)
-	defer firstSyncCheck.Stop()
-
-	// Start sync loop in goroutine
-	syncErr := make(chan error, 1)
-	go func() {
-		log.Println("Sync goroutine started, waiting for events...")
-		if err := b.client.SyncWithContext(b.ctx); err != nil {
-			if err != context.Canceled {
-				syncErr <- err
-			}
-		}
-		log.Println("Sync goroutine exited")
-	}()
-
-	// Handle periodic saves and shutdown
-	savedFirst := false
-	for {
-		select {
-		case <-firstSyncCheck.C:
-			// Check if we have a next_batch from first sync
-			if !savedFirst {
-				if nb, err := b.client.Store.LoadNextBatch(context.Background(), b.client.UserID); err == nil && nb != "" && nb != b.nextBatch {
-					b.nextBatch = nb
-					log.Printf("First sync completed, next_batch: %s", nb[:min(len(nb), 20)])
-					if err := b.saveNextBatch(); err != nil {
-						log.Printf("Warning: failed to save next_batch after first sync: %v", err)
-					} else {
-						log.Println("✅ Saved initial next_batch to config")
-						savedFirst = true
-						firstSyncCheck.Stop()
-					}
-				}
-			}
-
-		case <-ticker.C:
-			// Save next_batch every hour
-			if err := b.saveNextBatch(); err != nil {
-				log.Printf("Warning: failed to save next_batch: %v", err)
-			} else {
-				log.Println("Saved next_batch checkpoint")
-			}
-
-		case err := <-syncErr:
-			return fmt.Errorf("sync error: %w", err)
-
-		case <-b.ctx.Done():
-			log.Println("Bot sync loop stopped")
-			return nil
-		}
+	// Collect stickers in the background, picking up jobs left from the last run
+	if err := b.jobs.open(); err != nil {
+		return fmt.Errorf("failed to open job queue: %w", err)
 	}
-}
+	b.startJobWorkers(b.ctx, b.config.Jobs.Workers)
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+	// Each processed sync saves its position, so this just runs until stopped
+	if err := b.client.SyncWithContext(b.ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("sync error: %w", err)
 	}
-	return b
+
+	log.Println("Bot sync loop stopped")
+	return nil
 }
 
 // Stop gracefully shuts down the bot
@@ -168,29 +112,13 @@ func (b *Bot) Stop() {
 	log.Println("Stopping bot...")
 	b.cancel()
 	b.client.StopSync()
-
-	// Save final next_batch on graceful shutdown (already updated by OnSync callback)
-	if err := b.saveNextBatch(); err != nil {
-		log.Printf("Warning: failed to save next_batch on shutdown: %v", err)
-	} else {
-		log.Println("Saved final next_batch on shutdown")
-	}
-}
-
-// saveNextBatch persists the current next_batch token to config
-func (b *Bot) saveNextBatch() error {
-	// Read latest next_batch from store (updated by sync)
-	if nb, err := b.client.Store.LoadNextBatch(context.Background(), b.client.UserID); err == nil {
-		b.nextBatch = nb
-	}
-	b.config.Matrix.NextBatch = b.nextBatch
-	return config.Save(b.config)
+	b.jobs.close()
 }
 
 // handleReaction is called for every m.reaction event
 func (b *Bot) handleReaction(ctx context.Context, evt *event.Event) {
-	// Only process reactions from our user
-	if evt.Sender != b.client.UserID {
+	// Only process reactions from users allowed to collect
+	if b.access.Role(evt.Sender) < RoleCurator {
 		return
 	}
 
@@ -201,3 +129,33 @@ func (b *Bot) handleReaction(ctx context.Context, evt *event.Event) {
 		log.Printf("Error processing reaction: %v", err)
 	}
 }
+
+// alreadyProcessed reports whether an event was handled before, e.g. replayed after a restart
+func (b *Bot) alreadyProcessed(evt *event.Event) bool {
+	entry, err := b.journal.Get(evt.ID.String())
+	if err != nil {
+		// Better to risk handling an event twice than to drop it
+		log.Printf("Warning: failed to check event journal: %v", err)
+		return false
+	}
+	if entry == nil {
+		return false
+	}
+
+	log.Printf("Skipping %s %s, already handled at %s: %s",
+		entry.Kind, evt.ID, entry.ProcessedAt.Format(time.RFC3339), entry.Outcome)
+	return true
+}
+
+// recordProcessed adds a handled event and its outcome to the journal
+func (b *Bot) recordProcessed(evt *event.Event, kind string, outcome string) {
+	err := b.journal.Record(storage.ProcessedEvent{
+		EventID: evt.ID.String(),
+		RoomID:  evt.RoomID.String(),
+		Kind:    kind,
+		Outcome: outcome,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record %s %s in event journal: %v", kind, evt.ID, err)
+	}
+}
@@ -1,12 +1,23 @@
 package bot
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/liminalpurple/matrix-stickerbook/internal/config"
 	"github.com/liminalpurple/matrix-stickerbook/internal/llm"
 	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
 	"maunium.net/go/mautrix/event"
 )
 
@@ -52,6 +63,129 @@ func setupTestEnv(t *testing.T) func() {
 	}
 }
 
+// testPNG encodes a blank size×size PNG image
+func testPNG(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size, size))); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// notFound answers a fake homeserver request the test doesn't handle
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Not found"}`))
+}
+
+// serveImage answers a media download from a fake homeserver with a PNG, reporting
+// whether r was one
+func serveImage(w http.ResponseWriter, r *http.Request, data []byte) bool {
+	if !strings.Contains(r.URL.Path, "/download/") {
+		return false
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(data)
+	return true
+}
+
+// sentEvent is an event the bot sent to the fake homeserver
+type sentEvent struct {
+	eventType string
+	content   map[string]any
+}
+
+// serverBotOptions sets up the fake homeserver and collection for setupServerBot.
+// Every field is optional.
+type serverBotOptions struct {
+	handler   http.HandlerFunc         // Answers whatever the other options don't
+	events    map[string]string        // Event JSON served for /event/{id}
+	image     []byte                   // Served for every media download
+	configure func(cfg *config.Config) // Adjusts the config before the bot is created
+	generator *fakeGenerator           // Writes alt-text, "A cat" if nil
+	stickers  []storage.Sticker        // Added to the collection
+}
+
+// fakeHomeserver records the events a bot sends to it
+type fakeHomeserver struct {
+	mu     sync.Mutex
+	events []sentEvent
+}
+
+// sent returns the events the bot has sent so far
+func (h *fakeHomeserver) sent() []sentEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.events
+}
+
+// serve records a sent event, or answers r from opts, or M_NOT_FOUND
+func (h *fakeHomeserver) serve(w http.ResponseWriter, r *http.Request, opts serverBotOptions) {
+	// PUT /_matrix/client/v3/rooms/{room}/send/{type}/{txn}
+	parts := strings.Split(r.URL.Path, "/")
+	if r.Method == http.MethodPut && len(parts) > 2 && parts[len(parts)-3] == "send" {
+		var content map[string]any
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &content)
+
+		h.mu.Lock()
+		h.events = append(h.events, sentEvent{eventType: parts[len(parts)-2], content: content})
+		h.mu.Unlock()
+
+		_, _ = w.Write([]byte(`{"event_id":"$feedback"}`))
+		return
+	}
+	if len(parts) > 1 && parts[len(parts)-2] == "event" {
+		if evt, ok := opts.events[parts[len(parts)-1]]; ok {
+			_, _ = w.Write([]byte(evt))
+			return
+		}
+	}
+	if opts.image != nil && serveImage(w, r, opts.image) {
+		return
+	}
+	if opts.handler != nil {
+		opts.handler(w, r)
+		return
+	}
+	notFound(w)
+}
+
+// setupServerBot creates a bot with a fresh data directory and a fake homeserver set
+// up by opts, returning the bot and the homeserver
+func setupServerBot(t *testing.T, opts serverBotOptions) (*Bot, *fakeHomeserver) {
+	t.Helper()
+	t.Cleanup(setupTestEnv(t))
+	dataDir := t.TempDir()
+
+	homeserver := &fakeHomeserver{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		homeserver.serve(w, r, opts)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testConfig(dataDir)
+	if opts.configure != nil {
+		opts.configure(cfg)
+	}
+	generator := opts.generator
+	if generator == nil {
+		generator = &fakeGenerator{reply: func(string) string { return "A cat" }}
+	}
+	matrixClient, _ := matrix.NewClient(server.URL, "@test:matrix.org", "test-token")
+	bot := NewBot(matrixClient, generator, storage.NewJSONStore(dataDir), cfg)
+	t.Cleanup(bot.Stop)
+
+	for _, sticker := range opts.stickers {
+		if err := bot.store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+
+	return bot, homeserver
+}
+
 // TestNewBot verifies bot creation
 func TestNewBot(t *testing.T) {
 	defer setupTestEnv(t)()
@@ -63,7 +197,7 @@ func TestNewBot(t *testing.T) {
 
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
 
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 
 	if bot == nil {
 		t.Fatal("Expected bot to be created")
@@ -97,31 +231,53 @@ func TestNewBot(t *testing.T) {
 	bot.Stop()
 }
 
-// TestValidCommands verifies command recognition
-func TestValidCommands(t *testing.T) {
+// TestCollectPack verifies collect reactions, pack suffixes and shortcuts are recognised
+func TestCollectPack(t *testing.T) {
 	defer setupTestEnv(t)()
 
+	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
+	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
+	cfg := testConfig(getTestStorageDir())
+	cfg.Collection.Shortcuts = []config.ReactionShortcut{{Key: "🐱", Pack: "Cats"}}
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), cfg)
+	defer bot.Stop()
+
 	tests := []struct {
-		command string
-		valid   bool
+		reaction string
+		pack     string
+		valid    bool
 	}{
-		{"!yoink", true},
-		{"!nom", true},
-		{"!grab", true},
-		{"!invalid", false},
-		{"yoink", false},
-		{"", false},
-		{"👍", false},
+		{"!yoink", "", true},
+		{"!nom", "", true},
+		{"!grab", "", true},
+		{"!yoink:cats", "cats", true},
+		{"!grab:Big Cats", "big-cats", true},
+		{"!yoink:unsorted", "", true},
+		{"🐱", "cats", true},
+		{"!yoink:", "", false},
+		{"!invalid", "", false},
+		{"yoink", "", false},
+		{"", "", false},
+		{"👍", "", false},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.command, func(t *testing.T) {
-			result := validCommands[tt.command]
-			if result != tt.valid {
-				t.Errorf("Command %q: expected valid=%v, got %v", tt.command, tt.valid, result)
+		t.Run(tt.reaction, func(t *testing.T) {
+			pack, valid := bot.collectPack(tt.reaction)
+			if valid != tt.valid || pack != tt.pack {
+				t.Errorf("Reaction %q: expected (%q, %v), got (%q, %v)", tt.reaction, tt.pack, tt.valid, pack, valid)
 			}
 		})
 	}
+
+	// Configured reactions replace the defaults
+	bot.config.Collection.Reactions = []string{"📥"}
+	if _, valid := bot.collectPack("!yoink"); valid {
+		t.Error("Expected !yoink to be ignored once other reactions are configured")
+	}
+	if pack, valid := bot.collectPack("📥:cats"); !valid || pack != "cats" {
+		t.Errorf("Expected 📥:cats to collect into cats, got (%q, %v)", pack, valid)
+	}
 }
 
 // TestBotStop verifies graceful shutdown
@@ -133,7 +289,7 @@ func TestBotStop(t *testing.T) {
 	}
 
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 
 	// Context should be active
 	select {
@@ -160,7 +316,7 @@ func TestExtractImageData_Sticker(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	// Create a mock sticker event
@@ -174,7 +330,7 @@ func TestExtractImageData_Sticker(t *testing.T) {
 		},
 	}
 
-	mxcURI, body, err := bot.extractImageData(evt)
+	mxcURI, _, body, err := bot.extractImageData(evt)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -193,7 +349,7 @@ func TestExtractImageData_StickerRawContent(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	// Create a sticker event with raw content (not parsed)
@@ -207,7 +363,7 @@ func TestExtractImageData_StickerRawContent(t *testing.T) {
 		},
 	}
 
-	mxcURI, body, err := bot.extractImageData(evt)
+	mxcURI, _, body, err := bot.extractImageData(evt)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -226,7 +382,7 @@ func TestExtractImageData_ImageMessage(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	// Create a mock image message event
@@ -241,7 +397,7 @@ func TestExtractImageData_ImageMessage(t *testing.T) {
 		},
 	}
 
-	mxcURI, body, err := bot.extractImageData(evt)
+	mxcURI, _, body, err := bot.extractImageData(evt)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -260,7 +416,7 @@ func TestExtractImageData_ImageMessageRawContent(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	// Create an image message event with raw content (not parsed)
@@ -275,7 +431,7 @@ func TestExtractImageData_ImageMessageRawContent(t *testing.T) {
 		},
 	}
 
-	mxcURI, body, err := bot.extractImageData(evt)
+	mxcURI, _, body, err := bot.extractImageData(evt)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -294,7 +450,7 @@ func TestExtractImageData_TextMessage(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	// Create a text message event (not an image)
@@ -308,7 +464,7 @@ func TestExtractImageData_TextMessage(t *testing.T) {
 		},
 	}
 
-	_, _, err := bot.extractImageData(evt)
+	_, _, _, err := bot.extractImageData(evt)
 	if err == nil {
 		t.Error("Expected error when extracting from text message")
 	}
@@ -324,7 +480,7 @@ func TestExtractImageData_UnsupportedEventType(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	tests := []struct {
@@ -345,7 +501,7 @@ func TestExtractImageData_UnsupportedEventType(t *testing.T) {
 				},
 			}
 
-			_, _, err := bot.extractImageData(evt)
+			_, _, _, err := bot.extractImageData(evt)
 			if err == nil {
 				t.Errorf("Expected error for event type %s", tt.eventType.Type)
 			}
@@ -358,7 +514,7 @@ func TestExtractImageData_InvalidContent(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	// Create sticker event with wrong content type
@@ -369,7 +525,7 @@ func TestExtractImageData_InvalidContent(t *testing.T) {
 		},
 	}
 
-	_, _, err := bot.extractImageData(evt)
+	_, _, _, err := bot.extractImageData(evt)
 	if err == nil {
 		t.Error("Expected error when content is not MessageEventContent")
 	}
@@ -380,7 +536,7 @@ func TestExtractImageData_VideoMessage(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	evt := &event.Event{
@@ -394,7 +550,7 @@ func TestExtractImageData_VideoMessage(t *testing.T) {
 		},
 	}
 
-	_, _, err := bot.extractImageData(evt)
+	_, _, _, err := bot.extractImageData(evt)
 	if err == nil {
 		t.Error("Expected error when message is video, not image")
 	}
@@ -405,7 +561,7 @@ func TestExtractImageData_EmptyMXC(t *testing.T) {
 	defer setupTestEnv(t)()
 	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
 	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
-	bot := NewBot(matrixClient, llmClient, testConfig(getTestStorageDir()))
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
 	defer bot.Stop()
 
 	evt := &event.Event{
@@ -418,7 +574,7 @@ func TestExtractImageData_EmptyMXC(t *testing.T) {
 		},
 	}
 
-	mxcURI, body, err := bot.extractImageData(evt)
+	mxcURI, _, body, err := bot.extractImageData(evt)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -432,3 +588,189 @@ func TestExtractImageData_EmptyMXC(t *testing.T) {
 		t.Errorf("Expected body 'Sticker without MXC', got %s", body)
 	}
 }
+
+// TestExtractImageData_EncryptedImage verifies encrypted images return their file info
+func TestExtractImageData_EncryptedImage(t *testing.T) {
+	defer setupTestEnv(t)()
+	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
+	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(getTestStorageDir()), testConfig(getTestStorageDir()))
+	defer bot.Stop()
+
+	evt := &event.Event{
+		Type: event.EventMessage,
+		Content: event.Content{
+			Parsed: &event.MessageEventContent{
+				MsgType: event.MsgImage,
+				Body:    "Secret cat",
+				File:    &event.EncryptedFileInfo{URL: "mxc://matrix.org/encrypted123"},
+			},
+		},
+	}
+
+	mxcURI, file, body, err := bot.extractImageData(evt)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if file == nil {
+		t.Fatal("Expected encrypted file info")
+	}
+	if string(mxcURI) != "mxc://matrix.org/encrypted123" {
+		t.Errorf("Expected MXC URI from the file info, got %s", mxcURI)
+	}
+	if body != "Secret cat" {
+		t.Errorf("Expected body 'Secret cat', got %s", body)
+	}
+
+	// Unparsed content carries the file info as a raw map
+	evt = &event.Event{
+		Type: event.EventMessage,
+		Content: event.Content{
+			Raw: map[string]interface{}{
+				"msgtype": "m.image",
+				"body":    "Raw secret",
+				"file": map[string]interface{}{
+					"url": "mxc://matrix.org/rawencrypted",
+					"v":   "v2",
+				},
+			},
+		},
+	}
+
+	mxcURI, file, _, err = bot.extractImageData(evt)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if file == nil || string(mxcURI) != "mxc://matrix.org/rawencrypted" {
+		t.Errorf("Expected raw encrypted file info, got %v (%s)", file, mxcURI)
+	}
+}
+
+// TestProcessReaction_SkipsJournaled verifies a replayed reaction isn't collected again
+func TestProcessReaction_SkipsJournaled(t *testing.T) {
+	defer setupTestEnv(t)()
+	tmpDir, err := os.MkdirTemp("", "stickerbook-journal-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
+	llmClient := llm.NewClient("test-api-key", "claude-3-haiku-20240307", 100)
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(tmpDir), testConfig(tmpDir))
+	defer bot.Stop()
+
+	evt := &event.Event{
+		ID:     "$reaction1",
+		RoomID: "!room:matrix.org",
+		Sender: "@test:matrix.org",
+		Type:   event.EventReaction,
+		Content: event.Content{
+			Parsed: &event.ReactionEventContent{
+				RelatesTo: event.RelatesTo{Type: event.RelAnnotation, EventID: "$image1", Key: "!yoink"},
+			},
+		},
+	}
+
+	if err := bot.journal.Record(storage.ProcessedEvent{EventID: "$reaction1", Kind: storage.EventKindReaction, Outcome: "collected"}); err != nil {
+		t.Fatalf("Failed to record reaction: %v", err)
+	}
+
+	// The parent event would have to be fetched from matrix.org, so a nil error
+	// means the journaled reaction was skipped before any network access
+	if err := bot.processReaction(context.Background(), evt); err != nil {
+		t.Errorf("Expected journaled reaction to be skipped, got: %v", err)
+	}
+}
+
+// fakeGenerator is an alt-text generator that records its prompts and answers with reply
+type fakeGenerator struct {
+	prompts      []string
+	reply        func(prompt string) string
+	shortcode    string            // Suggested with every reply
+	translations map[string]string // Returned for each language the prompt asks for a translation into
+}
+
+// Describe records the prompt and returns reply's alt-text with some suggested tags,
+// the shortcode and the translations asked for
+func (g *fakeGenerator) Describe(ctx context.Context, imageData []byte, mimeType string, prompt string) (*llm.Description, error) {
+	g.prompts = append(g.prompts, prompt)
+	description := &llm.Description{AltText: g.reply(prompt), Tags: []string{"Cat", "not a tag", "cat"}, Shortcode: g.shortcode}
+	for language, translation := range g.translations {
+		if strings.Contains(prompt, "Translation "+language+":") {
+			if description.Translations == nil {
+				description.Translations = make(map[string]string)
+			}
+			description.Translations[language] = translation
+		}
+	}
+	return description, nil
+}
+
+// Model names the fake model
+func (g *fakeGenerator) Model() string {
+	return "fake-model"
+}
+
+// Name describes the generator
+func (g *fakeGenerator) Name() string {
+	return "fake"
+}
+
+// TestDescribeImage_Languages verifies a sticker is described once with its pack's
+// prompt, with the other languages as translations in the same reply
+func TestDescribeImage_Languages(t *testing.T) {
+	cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	generator := &fakeGenerator{
+		reply:        func(string) string { return "A cat" },
+		translations: map[string]string{"de": "Eine Katze"},
+	}
+	cfg := testConfig(getTestStorageDir())
+	cfg.AltText = config.AltTextConfig{
+		Languages: []string{"en", "de"},
+		Packs:     []config.PackAltText{{Pack: "emotes", Prompt: "Two words only."}},
+	}
+	matrixClient, _ := matrix.NewClient("https://matrix.org", "@test:matrix.org", "test-token")
+	bot := NewBot(matrixClient, generator, storage.NewJSONStore(getTestStorageDir()), cfg)
+
+	image := &fetchedImage{data: []byte("image"), info: &matrix.ImageInfo{MimeType: "image/png"}}
+	description, err := bot.describeImage(context.Background(), image, "original", cfg.AltText.PackPrompt("emotes"))
+	if err != nil {
+		t.Fatalf("describeImage failed: %v", err)
+	}
+
+	if description.altText != "A cat" || description.altTexts["en"] != "A cat" || description.altTexts["de"] != "Eine Katze" {
+		t.Errorf("Unexpected alt-text: %+v", description)
+	}
+	if description.model != "fake-model" {
+		t.Errorf("Expected the model to be recorded, got %q", description.model)
+	}
+	if len(description.tags) != 1 || description.tags[0] != "cat" {
+		t.Errorf("Expected tags [cat], got %v", description.tags)
+	}
+	if len(generator.prompts) != 1 || !strings.HasPrefix(generator.prompts[0], "Two words only.") {
+		t.Errorf("Expected the pack prompt in a single request, got %q", generator.prompts)
+	}
+
+	// A missing translation is left out, falling back to the main language
+	generator.translations = nil
+	description, err = bot.describeImage(context.Background(), image, "original", "")
+	if err != nil {
+		t.Fatalf("describeImage failed: %v", err)
+	}
+	if len(description.altTexts) != 1 || description.altTexts["en"] != "A cat" {
+		t.Errorf("Expected only the main language, got %v", description.altTexts)
+	}
+
+	// Without alt-text (as with the none provider) the original description is kept
+	generator.reply = func(string) string { return "" }
+	description, err = bot.describeImage(context.Background(), image, "original", "")
+	if err != nil {
+		t.Fatalf("describeImage failed: %v", err)
+	}
+	if description.altText != "original" || description.altTexts != nil || description.model != "" {
+		t.Errorf("Expected original description and no alt-texts, got %+v", description)
+	}
+}
@@ -2,13 +2,17 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
 	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
@@ -16,8 +20,8 @@ import (
 
 // handleMessage processes text messages looking for !sticker commands
 func (b *Bot) handleMessage(ctx context.Context, evt *event.Event) {
-	// Only process messages from our user
-	if evt.Sender != b.client.UserID {
+	// Only process messages from our user or allowed users
+	if b.access.Role(evt.Sender) == RoleNone {
 		return
 	}
 
@@ -37,20 +41,61 @@ func (b *Bot) handleMessage(ctx context.Context, evt *event.Event) {
 		return
 	}
 
-	// Check if message starts with !sticker (with or without space)
+	// Check if message starts with !sticker (with or without space), ignoring the
+	// quote of the replied-to message that older clients put in front
+	replyTo := content.RelatesTo.GetReplyTo()
 	body := strings.TrimSpace(content.Body)
+	if replyTo != "" {
+		body = event.TrimReplyFallbackText(body)
+	}
 	if !strings.HasPrefix(body, "!sticker") {
 		return
 	}
 
+	// Commands replayed after a restart already have their result
+	if b.alreadyProcessed(evt) {
+		return
+	}
+
 	log.Printf("Processing command: %s", body)
 
+	// A command replying to an image acts on that image's sticker
+	command := body
+	var result string
+	if replyTo != "" {
+		var queued bool
+		var err error
+		command, queued, err = b.replyCommand(ctx, evt.Sender, evt.RoomID, replyTo, evt.ID, body)
+		switch {
+		case err != nil:
+			result = fmt.Sprintf("❌ %v", err)
+		case queued:
+			result = fmt.Sprintf("✅ Collecting this image first - `%s` will run once it's in the collection", command)
+		}
+	}
+
 	// Parse and execute command
-	result := b.executeCommand(ctx, body)
+	if result == "" {
+		result = b.executeCommandFrom(ctx, evt.Sender, evt.RoomID, evt.ID, command)
+	}
+	defer b.recordProcessed(evt, storage.EventKindCommand, firstLine(result))
 
-	// Edit the original message with the result
-	if err := b.editMessage(ctx, evt.RoomID, evt.ID, body, result); err != nil {
-		log.Printf("Error editing message: %v", err)
+	// An encrypted command needs an encrypted result, so make sure we know the room is encrypted
+	if evt.Mautrix.WasEncrypted {
+		if err := b.client.LoadEncryptedRoom(ctx, evt.RoomID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Edit our own message with the result; other users' messages can only be replied to
+	if evt.Sender == b.client.UserID {
+		if err := b.editMessage(ctx, evt.RoomID, evt.ID, body, result); err != nil {
+			log.Printf("Error editing message: %v", err)
+		}
+		return
+	}
+	if _, err := b.replyMessage(ctx, evt.RoomID, evt.ID, result); err != nil {
+		log.Printf("Error replying to message: %v", err)
 	}
 }
 
@@ -60,23 +105,69 @@ func (b *Bot) showHelp() string {
 		"- !sticker pack list - List all packs with sticker counts\n" +
 		"- !sticker pack create <name> - Create a new pack\n" +
 		"- !sticker pack show <pack> - Show stickers in a pack\n" +
+		"- !sticker pack delete <pack> - Delete a pack (stickers are kept)\n" +
+		"- !sticker pack rename <pack> <new-name> - Rename a pack\n" +
+		"- !sticker pack title <pack> <display name> - Set the pack's display name\n" +
+		"- !sticker pack move <pack> <sticker-id> <position> - Reorder a sticker\n" +
 		"- !sticker pack add <pack> <sticker-id> - Add sticker to pack\n" +
 		"- !sticker pack remove <pack> <sticker-id> - Remove sticker from pack\n" +
 		"- !sticker pack avatar <pack> <mxc-uri> - Set pack icon\n" +
 		"- !sticker pack usage <pack> <type> - Set default usage (sticker/emoticon/both/reset)\n" +
-		"- !sticker pack publish <pack> [room-id] - Publish to room (or all saved)\n\n" +
+		"- !sticker pack publish <pack> [room-id] - Publish to room (or all saved)\n" +
+		"- !sticker pack publish <pack> --personal - Publish as the bot account's personal emotes\n" +
+		"- !sticker pack publish <pack> [room-id] --subscribe - Publish and add it to the bot account's emote rooms\n" +
+		"- !sticker pack unpublish <pack> [room-id|--personal] - Remove from a room (or everywhere)\n" +
+		"- !sticker pack subscribe <pack> - Add the pack's rooms to the bot account's emote rooms\n" +
+		"- !sticker pack unsubscribe <pack> - Remove the pack's rooms from the bot account's emote rooms\n" +
+		"- !sticker pack import <room-id> [state-key] - Import a room's sticker pack\n" +
+		"- !sticker pack fromtag <pack> <tags> - Add every sticker with these tags (prefix - to exclude)\n" +
+		"- !sticker pack lint <pack> [--fix] - Find shortcode clashes before publishing (--fix numbers them)\n\n" +
 		"Listing:\n\n" +
 		"- !sticker list unsorted - Show stickers not in any pack\n" +
-		"- !sticker show <sticker-id> - Show sticker with metadata and image\n\n" +
+		"- !sticker list tag <tags> - Show stickers with all these tags (prefix - to exclude)\n" +
+		"- !sticker tags - List tags with sticker counts\n" +
+		"- !sticker show <sticker-id> - Show sticker with metadata and image\n" +
+		"- !sticker search <query> - Search stickers (filters: pack:, tag:, usage:, mime:, before:, after:)\n\n" +
 		"Management:\n\n" +
-		"- !sticker name <sticker-id> <shortcode> - Set emoji shortcode (e.g., happy_cat)\n" +
+		"- !sticker name <sticker-id> [shortcode] - Set emoji shortcode (e.g., happy_cat), or accept the suggested one\n" +
 		"- !sticker usage <sticker-id> <type> - Set usage (sticker/emoticon/both/reset)\n" +
-		"- !sticker delete <sticker-id> - Delete sticker from collection\n\n" +
-		"**React to any sticker with `!yoink`, `!nom`, or `!grab` to collect it!**"
+		"- !sticker tag <sticker-id> <tags> - Tag a sticker (e.g., cat happy)\n" +
+		"- !sticker untag <sticker-id> <tags> - Remove tags from a sticker\n" +
+		"- !sticker alt <sticker-id> [text] - Show alt-text, or set it by hand (--lang <code> for another language)\n" +
+		"- !sticker alt <sticker-id> --regenerate [hint] - Describe the sticker again, with an optional hint\n" +
+		"- !sticker regenerate pack <pack> - Regenerate a pack's machine-written alt-text\n" +
+		"- !sticker regenerate outdated - Regenerate alt-text written by other models\n" +
+		"- !sticker delete <sticker-id> - Delete sticker from collection\n" +
+		"- !sticker jobs - Show queued and failed collections\n" +
+		"- !sticker jobs retry [job-id] - Retry failed collections\n" +
+		"- !sticker jobs clear - Discard failed collections\n\n" +
+		"Listings show 20 at a time: add --page N or --limit N for more, or --gallery for thumbnails.\n\n" +
+		"A <sticker-id> can be the first few characters of the ID, a :shortcode:, or a number from your last listing. " +
+		"Reply to an image and leave out the <sticker-id> to use that image.\n\n" +
+		fmt.Sprintf("**React to any sticker with %s to collect it, or `%s:<pack>` to collect it into a pack!**",
+			quoteReactions(b.collectReactions()), b.collectReactions()[0])
+}
+
+// quoteReactions lists reaction keys for the help text, e.g. `!yoink`, `!nom`, or `!grab`
+func quoteReactions(reactions []string) string {
+	quoted := make([]string, len(reactions))
+	for i, reaction := range reactions {
+		quoted[i] = "`" + reaction + "`"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
 }
 
 // executeCommand parses and executes a !sticker command
-func (b *Bot) executeCommand(ctx context.Context, body string) string {
+func (b *Bot) executeCommand(ctx context.Context, sender id.UserID, body string) string {
+	return b.executeCommandFrom(ctx, sender, "", "", body)
+}
+
+// executeCommandFrom executes a !sticker command sent as eventID in roomID, which
+// work it queues answers once it's done. Without an event, it's only listed in jobs.
+func (b *Bot) executeCommandFrom(ctx context.Context, sender id.UserID, roomID id.RoomID, eventID id.EventID, body string) string {
 	// Remove "!sticker" prefix (handle both "!sticker" and "!sticker ...")
 	body = strings.TrimSpace(body)
 
@@ -91,77 +182,231 @@ func (b *Bot) executeCommand(ctx context.Context, body string) string {
 		return b.showHelp()
 	}
 
+	// Check the sender's role allows this command
+	if role, required := b.access.Role(sender), requiredRole(args); role < required {
+		return fmt.Sprintf("❌ This command needs the %s role (you have: %s)", required, role)
+	}
+
 	switch args[0] {
 	case "pack":
-		return b.handlePackCommand(args[1:])
+		return b.handlePackCommand(sender, args[1:])
 	case "list":
-		return b.handleListCommand(args[1:])
+		return b.handleListCommand(sender, args[1:])
+	case "search":
+		return b.stickerSearch(sender, args[1:])
 	case "show":
 		if len(args) < 2 {
 			return "❌ Usage: !sticker show <sticker-id>"
 		}
-		return b.stickerShow(args[1])
+		return b.withSticker(sender, args[1], b.stickerShow)
 	case "delete", "remove":
 		if len(args) < 2 {
 			return "❌ Usage: !sticker delete <sticker-id>"
 		}
-		return b.stickerDelete(args[1])
+		return b.withSticker(sender, args[1], b.stickerDelete)
 	case "usage":
 		if len(args) < 3 {
 			return "❌ Usage: !sticker usage <sticker-id> <sticker|emoticon|emoji|both|reset>\n\nSets how this sticker can be used. Use 'reset' to clear override and inherit from pack."
 		}
-		return b.stickerUsage(args[1], args[2])
-	case "name":
+		return b.withSticker(sender, args[1], func(stickerID string) string {
+			return b.stickerUsage(stickerID, args[2])
+		})
+	case "tag", "untag":
 		if len(args) < 3 {
-			return "❌ Usage: !sticker name <sticker-id> <shortcode>\n\nSets the emoji shortcode name (e.g., 'happy_cat' becomes :happy_cat:). Defaults to SHA256 hash."
+			return fmt.Sprintf("❌ Usage: !sticker %s <sticker-id> <tags>\n\nTags are single words (letters, numbers, _ and -), e.g. `!sticker tag happy_cat cat happy`.", args[0])
+		}
+		return b.withSticker(sender, args[1], func(stickerID string) string {
+			if args[0] == "untag" {
+				return b.stickerUntag(stickerID, args[2:])
+			}
+			return b.stickerTag(stickerID, args[2:])
+		})
+	case "tags":
+		p, _, err := parsePage(args[1:])
+		if err != nil {
+			return fmt.Sprintf("❌ %v", err)
+		}
+		return b.listTags(p)
+	case "jobs":
+		return b.handleJobsCommand(args[1:])
+	case "alt":
+		return b.handleAltCommand(sender, roomID, eventID, args[1:])
+	case "regenerate":
+		return b.handleRegenerateCommand(sender, args[1:])
+	case "name":
+		if len(args) < 2 {
+			return "❌ Usage: !sticker name <sticker-id> [shortcode]\n\nSets the emoji shortcode name (e.g., 'happy_cat' becomes :happy_cat:). Defaults to SHA256 hash. " +
+				"Without a shortcode, accepts the one suggested when the sticker was collected."
 		}
-		return b.stickerName(args[1], args[2])
+		return b.withSticker(sender, args[1], func(stickerID string) string {
+			if len(args) == 2 {
+				return b.stickerAcceptName(stickerID)
+			}
+			return b.stickerName(stickerID, args[2])
+		})
 	default:
 		return fmt.Sprintf("❌ Unknown command: %s\n\n%s", args[0], b.showHelp())
 	}
 }
 
+// handleJobsCommand handles !sticker jobs [retry [job-id]|clear]
+func (b *Bot) handleJobsCommand(args []string) string {
+	if len(args) == 0 {
+		return b.listJobs()
+	}
+
+	switch args[0] {
+	case "retry":
+		jobID := ""
+		if len(args) > 1 {
+			jobID = args[1]
+		}
+		return b.retryJobs(jobID)
+	case "clear":
+		return b.clearJobs()
+	default:
+		return "❌ Usage: !sticker jobs [retry [job-id]|clear]"
+	}
+}
+
 // handlePackCommand handles !sticker pack <subcommand>
-func (b *Bot) handlePackCommand(args []string) string {
+func (b *Bot) handlePackCommand(sender id.UserID, args []string) string {
 	if len(args) == 0 {
-		return "❌ No pack subcommand specified. Try: pack list, pack create, pack add, pack remove, pack show, pack avatar, pack publish"
+		return "❌ No pack subcommand specified. Try: pack list, pack create, pack delete, pack rename, pack title, pack move, pack add, pack remove, pack show, pack avatar, pack publish, pack unpublish, pack subscribe, pack unsubscribe, pack import, pack fromtag, pack lint"
 	}
 
 	switch args[0] {
 	case "list":
-		return b.packList()
+		p, _, err := parsePage(args[1:])
+		if err != nil {
+			return fmt.Sprintf("❌ %v", err)
+		}
+		return b.packList(p)
 	case "create":
 		if len(args) < 2 {
 			return "❌ Usage: !sticker pack create <name>"
 		}
 		// Join all remaining args as pack name
 		packName := strings.Join(args[1:], " ")
-		return b.packCreate(packName)
+		return b.packCreate(packName, sender)
+	case "delete":
+		const usage = "❌ Usage: !sticker pack delete <pack-name> [--unpublish|--force]\n\nDeletes the pack; its stickers stay in your collection."
+		if len(args) < 2 || len(args) > 3 {
+			return usage
+		}
+		flag := ""
+		if len(args) == 3 {
+			flag = args[2]
+		}
+		// A mistyped flag mustn't delete a published pack without unpublishing it
+		if flag != "" && flag != "--unpublish" && flag != "--force" {
+			return fmt.Sprintf("❌ Unknown flag: %s\n\n", flag) + strings.TrimPrefix(usage, "❌ ")
+		}
+		return b.packDelete(args[1], flag)
+	case "rename":
+		if len(args) < 3 {
+			return "❌ Usage: !sticker pack rename <pack-name> <new-name>\n\nExample: !sticker pack rename favourites faves"
+		}
+		return b.packRename(args[1], strings.Join(args[2:], " "))
+	case "title":
+		if len(args) < 3 {
+			return "❌ Usage: !sticker pack title <pack-name> <display name>\n\nExample: !sticker pack title favourites My Favourite Stickers"
+		}
+		return b.packTitle(args[1], strings.Join(args[2:], " "))
+	case "move":
+		if len(args) < 4 {
+			return "❌ Usage: !sticker pack move <pack-name> <sticker-id> <position>\n\nPosition 1 is first in the sticker picker. Use `!sticker pack show <pack>` to see the current order."
+		}
+		return b.withSticker(sender, args[2], func(stickerID string) string {
+			return b.packMove(args[1], stickerID, args[3])
+		})
 	case "add":
 		if len(args) < 3 {
 			return "❌ Usage: !sticker pack add <pack-name> <sticker-id>\n\nExample: !sticker pack add favourites abc123...\n\nUse `!sticker pack list` to see available packs, or create one with `!sticker pack create <name>`"
 		}
-		return b.packAdd(args[1], args[2])
+		return b.withSticker(sender, args[2], func(stickerID string) string {
+			return b.packAdd(args[1], stickerID)
+		})
 	case "remove":
 		if len(args) < 3 {
 			return "❌ Usage: !sticker pack remove <pack-name> <sticker-id>\n\nExample: !sticker pack remove favourites abc123..."
 		}
-		return b.packRemove(args[1], args[2])
+		return b.withSticker(sender, args[2], func(stickerID string) string {
+			return b.packRemove(args[1], stickerID)
+		})
 	case "show":
+		p, args, err := parsePage(args)
+		if err != nil {
+			return fmt.Sprintf("❌ %v", err)
+		}
 		if len(args) < 2 {
-			return "❌ Usage: !sticker pack show <pack>"
+			return "❌ Usage: !sticker pack show <pack> [--page N] [--limit N] [--gallery]"
+		}
+		return b.packShow(sender, args[1], p)
+	case "fromtag":
+		if len(args) < 3 {
+			return "❌ Usage: !sticker pack fromtag <pack-name> <tags>\n\nAdds every sticker with all the tags to the pack, creating it if needed. Prefix a tag with - to leave out stickers that have it.\n\nExample: !sticker pack fromtag cats cat -sad"
+		}
+		return b.packFromTags(sender, args[1], strings.Join(args[2:], " "))
+	case "lint":
+		if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "--fix") {
+			return "❌ Usage: !sticker pack lint <pack-name> [--fix]\n\nLists stickers in the pack that share a shortcode (only one of them would be published), have an invalid shortcode, or have none yet. " +
+				"--fix numbers the shared shortcodes, e.g. cat_2."
 		}
-		return b.packShow(args[1])
+		return b.packLint(args[1], len(args) == 3)
 	case "publish":
 		if len(args) < 2 {
-			return "❌ Usage: !sticker pack publish <pack-name> [room-id]\n\nPublish to a specific room: !sticker pack publish favourites !roomid:matrix.org\nRe-publish to all saved rooms: !sticker pack publish favourites"
+			return "❌ Usage: !sticker pack publish <pack-name> [room-id|--personal] [--subscribe]\n\nPublish to a specific room: !sticker pack publish favourites !roomid:matrix.org\nPublish as the bot account's personal emotes: !sticker pack publish favourites --personal\nRe-publish to all saved rooms: !sticker pack publish favourites\nAdd --subscribe to also list the pack's rooms in the bot account's emote rooms"
 		}
 		// Optional room ID - if not provided, republish to all saved rooms
 		roomID := ""
+		personal, subscribe := false, false
+		for _, arg := range args[2:] {
+			switch arg {
+			case "--personal":
+				personal = true
+			case "--subscribe":
+				subscribe = true
+			default:
+				roomID = arg
+			}
+		}
+		if personal {
+			return b.packPublishPersonal(args[1])
+		}
+		result := b.packPublish(args[1], roomID)
+		if subscribe && !strings.HasPrefix(result, "❌") {
+			result += "\n\n" + b.packSubscribe(args[1])
+		}
+		return result
+	case "unpublish":
+		if len(args) < 2 {
+			return "❌ Usage: !sticker pack unpublish <pack-name> [room-id|--personal]\n\nRemove from a specific room: !sticker pack unpublish favourites !roomid:matrix.org\nRemove from the bot account's personal emotes: !sticker pack unpublish favourites --personal\nRemove from everywhere it was published: !sticker pack unpublish favourites"
+		}
+		target := ""
 		if len(args) >= 3 {
-			roomID = args[2]
+			target = args[2]
+		}
+		return b.packUnpublish(args[1], target)
+	case "subscribe":
+		if len(args) < 2 {
+			return "❌ Usage: !sticker pack subscribe <pack-name>\n\nAdds the rooms the pack is published to into your im.ponies.emote_rooms, so clients offer it in every room."
+		}
+		return b.packSubscribe(args[1])
+	case "unsubscribe":
+		if len(args) < 2 {
+			return "❌ Usage: !sticker pack unsubscribe <pack-name>"
+		}
+		return b.packUnsubscribe(args[1])
+	case "import":
+		if len(args) < 2 {
+			return "❌ Usage: !sticker pack import <room-id> [state-key]\n\nImports the room's im.ponies.room_emotes pack into your collection as a new pack.\nExample: !sticker pack import !roomid:matrix.org"
 		}
-		return b.packPublish(args[1], roomID)
+		stateKey := ""
+		if len(args) >= 3 {
+			stateKey = args[2]
+		}
+		return b.packImport(sender, args[1], stateKey)
 	case "avatar":
 		if len(args) < 3 {
 			return "❌ Usage: !sticker pack avatar <pack-name> <mxc-uri>\n\nExample: !sticker pack avatar favourites mxc://matrix.org/abc123..."
@@ -178,47 +423,57 @@ func (b *Bot) handlePackCommand(args []string) string {
 }
 
 // handleListCommand handles !sticker list <subcommand>
-func (b *Bot) handleListCommand(args []string) string {
+func (b *Bot) handleListCommand(sender id.UserID, args []string) string {
+	p, args, err := parsePage(args)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
 	if len(args) == 0 {
-		return "❌ No list subcommand specified. Try: list unsorted"
+		return "❌ No list subcommand specified. Try: list unsorted, list tag <tags>"
 	}
 
 	switch args[0] {
 	case "unsorted":
-		return b.listUnsorted()
+		return b.listUnsorted(sender, p)
+	case "tag":
+		if len(args) < 2 {
+			return "❌ Usage: !sticker list tag <tags>\n\nExample: !sticker list tag cat happy -sad"
+		}
+		return b.listTagged(sender, strings.Join(args[1:], " "), p)
 	default:
 		return fmt.Sprintf("❌ Unknown list subcommand: %s", args[0])
 	}
 }
 
 // packList lists all packs with sticker counts
-func (b *Bot) packList() string {
-	packs, err := storage.ListPacks(b.storageDir)
+func (b *Bot) packList(p page) string {
+	packs, err := b.store.ListPacks()
 	if err != nil {
 		return fmt.Sprintf("❌ Error loading packs: %v", err)
 	}
 
 	// Count unsorted stickers
-	collection, err := storage.LoadCollection(b.storageDir)
+	unsorted, err := b.store.ListUnsorted()
 	if err != nil {
 		return fmt.Sprintf("❌ Error loading collection: %v", err)
 	}
+	unsortedCount := len(unsorted)
 
-	unsortedCount := 0
-	for _, sticker := range collection.Stickers {
-		if len(sticker.InPacks) == 0 {
-			unsortedCount++
-		}
+	// Always show "unsorted" meta-pack (even if 0)
+	lines := []string{fmt.Sprintf("- unsorted (%d)\n", unsortedCount)}
+	for _, pack := range packs {
+		lines = append(lines, fmt.Sprintf("- %s (%d)\n", pack.Name, len(pack.StickerIDs)))
+	}
+	if err := p.check(len(lines)); err != nil {
+		return fmt.Sprintf("❌ %v", err)
 	}
 
 	var result strings.Builder
-
-	// Always show "unsorted" meta-pack (even if 0)
-	result.WriteString(fmt.Sprintf("- unsorted (%d)\n", unsortedCount))
-
-	for _, pack := range packs {
-		result.WriteString(fmt.Sprintf("- %s (%d)\n", pack.Name, len(pack.StickerIDs)))
+	start, end := p.bounds(len(lines))
+	for _, line := range lines[start:end] {
+		result.WriteString(line)
 	}
+	result.WriteString(p.footer(len(lines), "!sticker pack list"))
 
 	// Add helpful message if no packs created yet
 	if len(packs) == 0 {
@@ -228,13 +483,13 @@ func (b *Bot) packList() string {
 	return result.String()
 }
 
-// packCreate creates a new pack
-func (b *Bot) packCreate(name string) string {
+// packCreate creates a new pack, attributed to the user who created it
+func (b *Bot) packCreate(name string, creator id.UserID) string {
 	// Keep original name for display
 	displayName := name
 
 	// Sanitize pack name for ID (lowercase, no spaces)
-	packID := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	packID := sanitizePackName(name)
 
 	// Forbid "unsorted" as it's a virtual pack
 	if packID == "unsorted" {
@@ -242,101 +497,205 @@ func (b *Bot) packCreate(name string) string {
 	}
 
 	// Create pack with display name and attribution
-	if err := storage.CreatePackWithAttribution(b.storageDir, packID, displayName, string(b.client.UserID)); err != nil {
+	if err := b.store.CreatePack(packID, displayName, string(creator)); err != nil {
 		return fmt.Sprintf("❌ Error creating pack: %v", err)
 	}
 
 	return fmt.Sprintf("✅ Created pack: %s", displayName)
 }
 
-// packAdd adds a sticker to a pack
-func (b *Bot) packAdd(packName, stickerID string) string {
-	if err := storage.AddToPack(b.storageDir, packName, []string{stickerID}); err != nil {
-		return fmt.Sprintf("❌ Error adding to pack: %v", err)
+// sanitizePackName turns a user-supplied name into a pack ID (lowercase, no spaces)
+func sanitizePackName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+}
+
+// packDelete deletes a pack. A pack that is still published is only deleted with
+// --unpublish (clean up the rooms first) or --force (leave the state events behind).
+func (b *Bot) packDelete(packName string, flag string) string {
+	pack, err := b.store.GetPack(packName)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading pack: %v", err)
+	}
+
+	published := len(pack.PublishedRooms) > 0 || pack.PublishedPersonal
+	var result strings.Builder
+	switch {
+	case flag == "--unpublish" && published:
+		unpublished := b.unpublishAll(packName)
+		if !strings.HasPrefix(unpublished, "✅") {
+			return unpublished + "\n\nPack was not deleted. Use --force to delete it anyway."
+		}
+		result.WriteString(unpublished + "\n")
+	case flag == "--force":
+	case published:
+		return fmt.Sprintf("⚠️ Pack '%s' is still published to %d room(s)%s\n\n"+
+			"- `!sticker pack delete %s --unpublish` - remove it from those rooms first (recommended)\n"+
+			"- `!sticker pack delete %s --force` - delete it and leave the published packs behind",
+			packName, len(pack.PublishedRooms), personalSuffix(pack.PublishedPersonal), packName, packName)
+	}
+
+	if err := b.store.DeletePack(packName); err != nil {
+		return fmt.Sprintf("❌ Error deleting pack: %v", err)
 	}
 
-	return fmt.Sprintf("✅ Added sticker to pack: %s", packName)
+	result.WriteString(fmt.Sprintf("✅ Deleted pack: %s (its stickers stay in your collection)", packName))
+	return result.String()
 }
 
-// packRemove removes a sticker from a pack
-func (b *Bot) packRemove(packName, stickerID string) string {
-	if err := storage.RemoveFromPack(b.storageDir, packName, []string{stickerID}); err != nil {
-		return fmt.Sprintf("❌ Error removing from pack: %v", err)
+// personalSuffix describes personal emotes publication for status messages
+func personalSuffix(personal bool) string {
+	if personal {
+		return " and the bot account's personal emotes"
 	}
+	return ""
+}
 
-	return fmt.Sprintf("✅ Removed sticker from pack: %s", packName)
+// packRename changes a pack's name. Rooms it was published to keep their
+// original state key, so republishing updates the pack there in place.
+func (b *Bot) packRename(oldName, newName string) string {
+	newID := sanitizePackName(newName)
+	if newID == "unsorted" {
+		return "❌ Cannot rename a pack to 'unsorted' - this is a reserved name for stickers not in any pack"
+	}
+
+	if err := b.store.RenamePack(oldName, newID); err != nil {
+		return fmt.Sprintf("❌ Error renaming pack: %v", err)
+	}
+
+	result := fmt.Sprintf("✅ Renamed pack %s to %s", oldName, newID)
+
+	pack, err := b.store.GetPack(newID)
+	if err == nil && len(pack.PublishedRooms) > 0 {
+		result += fmt.Sprintf("\n\nIt's still published under its original state key in %d room(s); "+
+			"`!sticker pack publish %s` updates those rooms in place.", len(pack.PublishedRooms), newID)
+	}
+	if settings := b.packSettings(oldName); len(settings) > 0 {
+		result += fmt.Sprintf("\n\n⚠️ config.yaml still names pack %s in %s - change it to %s there and restart the bot",
+			oldName, strings.Join(settings, " and "), newID)
+	}
+
+	return result
 }
 
-// packShow shows stickers in a pack
-func (b *Bot) packShow(packName string) string {
-	pack, err := storage.GetPack(b.storageDir, packName)
-	if err != nil {
-		return fmt.Sprintf("❌ Error loading pack: %v", err)
+// packSettings lists the config settings that name a pack: its alt_text.packs override
+// and any collection.shortcuts that collect into it
+func (b *Bot) packSettings(packName string) []string {
+	var settings []string
+	if slices.ContainsFunc(b.config.AltText.Packs, func(p config.PackAltText) bool { return p.Pack == packName }) {
+		settings = append(settings, "alt_text.packs")
 	}
 
-	if len(pack.StickerIDs) == 0 {
-		return "Pack is empty"
+	var keys []string
+	for _, shortcut := range b.config.Collection.Shortcuts {
+		if sanitizePackName(shortcut.Pack) == packName {
+			keys = append(keys, "`"+shortcut.Key+"`")
+		}
 	}
+	if len(keys) > 0 {
+		settings = append(settings, fmt.Sprintf("collection.shortcuts (%s)", strings.Join(keys, ", ")))
+	}
+	return settings
+}
 
-	var result strings.Builder
+// packTitle sets the display name of a pack
+func (b *Bot) packTitle(packName, displayName string) string {
+	if err := b.store.SetPackDisplayName(packName, displayName); err != nil {
+		return fmt.Sprintf("❌ Error setting pack title: %v", err)
+	}
 
-	// Load stickers to show their alt-text
-	collection, err := storage.LoadCollection(b.storageDir)
+	return fmt.Sprintf("✅ Set title of pack %s to: %s", packName, displayName) + b.refreshPersonal(packName)
+}
+
+// packMove moves a sticker to a new position in a pack
+func (b *Bot) packMove(packName, stickerID, positionStr string) string {
+	position, err := strconv.Atoi(positionStr)
 	if err != nil {
-		return fmt.Sprintf("❌ Error loading collection: %v", err)
+		return fmt.Sprintf("❌ Invalid position: %s (use a number, e.g. 1 for first)", positionStr)
 	}
 
-	for i, stickerID := range pack.StickerIDs {
-		// Find the sticker in collection
-		var altText, name string
-		for _, sticker := range collection.Stickers {
-			if sticker.ID == stickerID {
-				altText = sticker.GeneratedAltText
-				name = sticker.Name
-				break
-			}
-		}
+	if err := b.store.MovePackSticker(packName, stickerID, position); err != nil {
+		return fmt.Sprintf("❌ Error moving sticker: %v", err)
+	}
 
-		if altText == "" {
-			altText = "(no alt-text)"
-		}
+	return fmt.Sprintf("✅ Moved sticker to position %d in pack: %s", position, packName) + b.refreshPersonal(packName)
+}
 
-		// Use code formatting for ID, proper markdown ordered list
-		result.WriteString(fmt.Sprintf("%d. `%s` (:%s:) - %s\n", i+1, stickerID, name, altText))
+// packAdd adds a sticker to a pack
+func (b *Bot) packAdd(packName, stickerID string) string {
+	if err := b.store.AddToPack(packName, []string{stickerID}); err != nil {
+		return fmt.Sprintf("❌ Error adding to pack: %v", err) + b.renameHint(err)
 	}
 
-	return result.String()
+	return fmt.Sprintf("✅ Added sticker to pack: %s", packName) + b.refreshPersonal(packName)
+}
+
+// packRemove removes a sticker from a pack
+func (b *Bot) packRemove(packName, stickerID string) string {
+	if err := b.store.RemoveFromPack(packName, []string{stickerID}); err != nil {
+		return fmt.Sprintf("❌ Error removing from pack: %v", err)
+	}
+
+	return fmt.Sprintf("✅ Removed sticker from pack: %s", packName) + b.refreshPersonal(packName)
+}
+
+// packShow shows a page of the stickers in a pack, remembering the listing so the
+// user can pick stickers from it by number
+func (b *Bot) packShow(sender id.UserID, packName string, p page) string {
+	// Load stickers in pack order to show their alt-text
+	stickers, err := b.store.PackStickers(packName)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading pack: %v", err)
+	}
+
+	if len(stickers) == 0 {
+		return "Pack is empty"
+	}
+
+	return b.showStickers(sender, stickers, p, "!sticker pack show "+packName, stickerLine)
 }
 
 // packPublish publishes a pack to a Matrix room (or all previously published rooms if roomID is empty)
 func (b *Bot) packPublish(packName, roomID string) string {
 	// If no room ID provided, republish to all saved rooms
 	if roomID == "" {
-		pack, err := storage.GetPack(b.storageDir, packName)
+		pack, err := b.store.GetPack(packName)
 		if err != nil {
 			return fmt.Sprintf("❌ Error loading pack: %v", err)
 		}
 
-		if len(pack.PublishedRooms) == 0 {
+		if len(pack.PublishedRooms) == 0 && !pack.PublishedPersonal {
 			return "❌ Pack has not been published to any rooms yet\n\nUse: !sticker pack publish <pack> <room-id> to publish to a specific room"
 		}
 
 		// Publish to all saved rooms
 		successCount := 0
 		var errors []string
+		hint := ""
 		for savedRoomID := range pack.PublishedRooms {
-			if err := b.client.PublishPack(b.ctx, b.storageDir, packName, id.RoomID(savedRoomID)); err != nil {
+			if err := b.client.PublishPack(b.ctx, b.store, packName, id.RoomID(savedRoomID), b.config.AltText.PackLanguage(packName)); err != nil {
 				errors = append(errors, fmt.Sprintf("%s: %v", savedRoomID, err))
+				hint = lintHint(err)
 			} else {
 				successCount++
 			}
 		}
 
+		// And to personal emotes, if that's where it lives
+		personal := ""
+		if pack.PublishedPersonal {
+			if err := b.client.PublishPersonalPack(b.ctx, b.store, packName, b.config.AltText.PackLanguage(packName)); err != nil {
+				errors = append(errors, fmt.Sprintf("personal emotes: %v", err))
+				hint = lintHint(err)
+			} else {
+				personal = " and the bot account's personal emotes"
+			}
+		}
+
 		if len(errors) > 0 {
-			return fmt.Sprintf("⚠️ Published to %d/%d rooms\n\nErrors:\n%s", successCount, len(pack.PublishedRooms), strings.Join(errors, "\n"))
+			return fmt.Sprintf("⚠️ Published to %d/%d rooms%s\n\nErrors:\n%s", successCount, len(pack.PublishedRooms), personal, strings.Join(errors, "\n")) + hint
 		}
 
-		return fmt.Sprintf("✅ Published pack '%s' to %d room(s)", packName, successCount)
+		return fmt.Sprintf("✅ Published pack '%s' to %d room(s)%s", packName, successCount, personal)
 	}
 
 	// Validate room ID format
@@ -345,13 +704,158 @@ func (b *Bot) packPublish(packName, roomID string) string {
 	}
 
 	// Publish to specific room
-	if err := b.client.PublishPack(b.ctx, b.storageDir, packName, id.RoomID(roomID)); err != nil {
-		return fmt.Sprintf("❌ Error publishing pack: %v", err)
+	if err := b.client.PublishPack(b.ctx, b.store, packName, id.RoomID(roomID), b.config.AltText.PackLanguage(packName)); err != nil {
+		return fmt.Sprintf("❌ Error publishing pack: %v", err) + lintHint(err)
 	}
 
 	return fmt.Sprintf("✅ Published pack '%s' to room %s", packName, roomID)
 }
 
+// packPublishPersonal publishes a pack as the bot account's personal emotes (account data)
+func (b *Bot) packPublishPersonal(packName string) string {
+	if err := b.client.PublishPersonalPack(b.ctx, b.store, packName, b.config.AltText.PackLanguage(packName)); err != nil {
+		return fmt.Sprintf("❌ Error publishing pack: %v", err) + lintHint(err)
+	}
+
+	return fmt.Sprintf("✅ Published pack '%s' as the bot account's personal emotes\n\nIt will be republished automatically when the pack changes.", packName)
+}
+
+// packUnpublish removes a pack from a room, from personal emotes, or (with no
+// target) from everywhere it was published
+func (b *Bot) packUnpublish(packName, target string) string {
+	switch {
+	case target == "":
+		return b.unpublishAll(packName)
+	case target == "--personal":
+		if err := b.client.UnpublishPersonalPack(b.ctx, b.store, packName); err != nil {
+			return fmt.Sprintf("❌ Error unpublishing pack: %v", err)
+		}
+		return fmt.Sprintf("✅ Removed pack '%s' from the bot account's personal emotes", packName)
+	case !strings.HasPrefix(target, "!"):
+		return "❌ Invalid room ID - must start with !\n\nExample: !roomid:matrix.org"
+	}
+
+	if err := b.client.UnpublishPack(b.ctx, b.store, packName, id.RoomID(target)); err != nil {
+		return fmt.Sprintf("❌ Error unpublishing pack: %v", err)
+	}
+
+	return fmt.Sprintf("✅ Unpublished pack '%s' from room %s", packName, target)
+}
+
+// unpublishAll removes a pack from every room it was published to and from
+// personal emotes, reporting any rooms that couldn't be cleaned up
+func (b *Bot) unpublishAll(packName string) string {
+	pack, err := b.store.GetPack(packName)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading pack: %v", err)
+	}
+
+	if len(pack.PublishedRooms) == 0 && !pack.PublishedPersonal {
+		return fmt.Sprintf("✅ Pack '%s' isn't published anywhere", packName)
+	}
+
+	successCount := 0
+	var errors []string
+	for roomID := range pack.PublishedRooms {
+		if err := b.client.UnpublishPack(b.ctx, b.store, packName, id.RoomID(roomID)); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", roomID, err))
+		} else {
+			successCount++
+		}
+	}
+
+	personal := ""
+	if pack.PublishedPersonal {
+		if err := b.client.UnpublishPersonalPack(b.ctx, b.store, packName); err != nil {
+			errors = append(errors, fmt.Sprintf("personal emotes: %v", err))
+		} else {
+			personal = " and the bot account's personal emotes"
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Sprintf("⚠️ Unpublished from %d/%d rooms%s\n\nErrors:\n%s", successCount, len(pack.PublishedRooms), personal, strings.Join(errors, "\n"))
+	}
+
+	return fmt.Sprintf("✅ Unpublished pack '%s' from %d room(s)%s", packName, successCount, personal)
+}
+
+// packSubscribe adds the pack's published rooms to the bot account's emote rooms
+func (b *Bot) packSubscribe(packName string) string {
+	added, err := b.client.SubscribePack(b.ctx, b.store, packName)
+	if err != nil {
+		return fmt.Sprintf("❌ Error subscribing to pack: %v", err)
+	}
+
+	if added == 0 {
+		return fmt.Sprintf("✅ Already subscribed to pack '%s' in all its rooms", packName)
+	}
+
+	return fmt.Sprintf("✅ Subscribed to pack '%s' in %d room(s) - it's now in the bot account's emote rooms", packName, added)
+}
+
+// packUnsubscribe removes the pack's published rooms from the bot account's emote rooms
+func (b *Bot) packUnsubscribe(packName string) string {
+	removed, err := b.client.UnsubscribePack(b.ctx, b.store, packName)
+	if err != nil {
+		return fmt.Sprintf("❌ Error unsubscribing from pack: %v", err)
+	}
+
+	if removed == 0 {
+		return fmt.Sprintf("✅ Pack '%s' wasn't subscribed", packName)
+	}
+
+	return fmt.Sprintf("✅ Unsubscribed from pack '%s' in %d room(s) of the bot account's emote rooms", packName, removed)
+}
+
+// refreshPersonal republishes the personal emotes if the personal pack is one of
+// packNames, so account data follows local edits. It returns a note to append to
+// the command result, or an empty string if nothing needed updating.
+func (b *Bot) refreshPersonal(packNames ...string) string {
+	packs, err := b.store.ListPacks()
+	if err != nil {
+		return fmt.Sprintf("\n\n⚠️ Couldn't check personal emotes: %v", err)
+	}
+
+	for _, pack := range packs {
+		if !pack.PublishedPersonal || !slices.Contains(packNames, pack.Name) {
+			continue
+		}
+		if err := b.client.PublishPersonalPack(b.ctx, b.store, pack.Name, b.config.AltText.PackLanguage(pack.Name)); err != nil {
+			return fmt.Sprintf("\n\n⚠️ Failed to update personal emotes: %v", err) + lintHint(err)
+		}
+		return "\n\n🔄 Updated the bot account's personal emotes"
+	}
+
+	return ""
+}
+
+// stickerPackNames returns the packs a sticker is in, for refreshPersonal
+func (b *Bot) stickerPackNames(stickerID string) []string {
+	sticker, err := b.store.GetSticker(stickerID)
+	if err != nil {
+		return nil
+	}
+	return sticker.InPacks
+}
+
+// packImport imports a room's MSC2545 pack into the collection as a new pack
+func (b *Bot) packImport(importer id.UserID, roomID, stateKey string) string {
+	// Validate room ID format
+	if !strings.HasPrefix(roomID, "!") {
+		return "❌ Invalid room ID - must start with !\n\nExample: !roomid:matrix.org"
+	}
+
+	result, err := b.ImportPack(b.ctx, importer, id.RoomID(roomID), stateKey)
+	if err != nil {
+		return fmt.Sprintf("❌ Error importing pack: %v", err)
+	}
+
+	return fmt.Sprintf("✅ Importing pack '%s' as %s: queued %d image(s) for collection\n\n"+
+		"They're added to the pack as they're collected - check on them with `!sticker jobs`",
+		result.DisplayName, result.PackName, result.Queued)
+}
+
 // packAvatar sets the avatar for a pack
 func (b *Bot) packAvatar(packName, avatarURL string) string {
 	// Validate MXC URI format
@@ -360,31 +864,27 @@ func (b *Bot) packAvatar(packName, avatarURL string) string {
 	}
 
 	// Set the avatar
-	if err := storage.SetPackAvatar(b.storageDir, packName, avatarURL); err != nil {
+	if err := b.store.SetPackAvatar(packName, avatarURL); err != nil {
 		return fmt.Sprintf("❌ Error setting pack avatar: %v", err)
 	}
 
-	return fmt.Sprintf("✅ Set avatar for pack: %s", packName)
+	return fmt.Sprintf("✅ Set avatar for pack: %s", packName) + b.refreshPersonal(packName)
+}
+
+// stickerError replies to a failed GetSticker, telling a missing sticker apart from
+// an error loading the collection
+func stickerError(stickerID string, err error) string {
+	if errors.Is(err, storage.ErrStickerNotFound) {
+		return fmt.Sprintf("❌ Sticker not found: %s", stickerID)
+	}
+	return fmt.Sprintf("❌ Error loading sticker: %v", err)
 }
 
 // stickerShow displays a sticker with metadata and image
 func (b *Bot) stickerShow(stickerID string) string {
-	collection, err := storage.LoadCollection(b.storageDir)
+	sticker, err := b.store.GetSticker(stickerID)
 	if err != nil {
-		return fmt.Sprintf("❌ Error loading collection: %v", err)
-	}
-
-	// Find the sticker
-	var sticker *storage.Sticker
-	for i := range collection.Stickers {
-		if collection.Stickers[i].ID == stickerID {
-			sticker = &collection.Stickers[i]
-			break
-		}
-	}
-
-	if sticker == nil {
-		return fmt.Sprintf("❌ Sticker not found: %s", stickerID)
+		return stickerError(stickerID, err)
 	}
 
 	// Build metadata as markdown list
@@ -402,7 +902,17 @@ func (b *Bot) stickerShow(stickerID string) string {
 	// Metadata as list
 	result.WriteString(fmt.Sprintf("- **ID:** `%s`\n", sticker.ID))
 	result.WriteString(fmt.Sprintf("- **Name:** `:%s:`\n", sticker.Name))
+	if sticker.SuggestedName != "" {
+		result.WriteString(fmt.Sprintf("- **Suggested name:** `:%s:` (accept with `!sticker name %s`)\n", sticker.SuggestedName, sticker.ID))
+	}
 	result.WriteString(fmt.Sprintf("- **Alt-text:** %s\n", altText))
+
+	// Alt-text in the other configured languages
+	for _, language := range b.config.AltText.LanguageCodes()[1:] {
+		if translated := sticker.AltTexts[language]; translated != "" {
+			result.WriteString(fmt.Sprintf("- **Alt-text (%s):** %s\n", language, translated))
+		}
+	}
 	result.WriteString(fmt.Sprintf("- **Size:** %dx%d, %s\n", sticker.Width, sticker.Height, sticker.MimeType))
 
 	// Packs
@@ -412,6 +922,11 @@ func (b *Bot) stickerShow(stickerID string) string {
 		result.WriteString("- **Packs:** (unsorted)\n")
 	}
 
+	// Tags
+	if len(sticker.Tags) > 0 {
+		result.WriteString(fmt.Sprintf("- **Tags:** %s\n", strings.Join(sticker.Tags, ", ")))
+	}
+
 	// Blank line before image
 	result.WriteString("\n")
 
@@ -423,44 +938,29 @@ func (b *Bot) stickerShow(stickerID string) string {
 
 // stickerDelete deletes a sticker from the collection
 func (b *Bot) stickerDelete(stickerID string) string {
-	if err := storage.DeleteSticker(b.storageDir, stickerID); err != nil {
+	// Remember its packs before they forget the sticker
+	packNames := b.stickerPackNames(stickerID)
+
+	if err := b.store.DeleteSticker(stickerID); err != nil {
 		return fmt.Sprintf("❌ Error deleting sticker: %v", err)
 	}
 
-	return fmt.Sprintf("✅ Deleted sticker: %s", stickerID)
+	return fmt.Sprintf("✅ Deleted sticker: %s", stickerID) + b.refreshPersonal(packNames...)
 }
 
-// listUnsorted lists stickers not in any pack
-func (b *Bot) listUnsorted() string {
-	collection, err := storage.LoadCollection(b.storageDir)
+// listUnsorted lists a page of the stickers not in any pack, remembering the listing
+// so the user can pick stickers from it by number
+func (b *Bot) listUnsorted(sender id.UserID, p page) string {
+	unsorted, err := b.store.ListUnsorted()
 	if err != nil {
 		return fmt.Sprintf("❌ Error loading collection: %v", err)
 	}
 
-	var unsorted []storage.Sticker
-	for _, sticker := range collection.Stickers {
-		if len(sticker.InPacks) == 0 {
-			unsorted = append(unsorted, sticker)
-		}
-	}
-
 	if len(unsorted) == 0 {
 		return "All stickers are organized into packs!"
 	}
 
-	var result strings.Builder
-
-	for i, sticker := range unsorted {
-		altText := sticker.GeneratedAltText
-		if altText == "" {
-			altText = "(no alt-text)"
-		}
-
-		// Use code formatting for ID, proper markdown ordered list
-		result.WriteString(fmt.Sprintf("%d. `%s` (:%s:) - %s\n", i+1, sticker.ID, sticker.Name, altText))
-	}
-
-	return result.String()
+	return b.showStickers(sender, unsorted, p, "!sticker list unsorted", stickerLine)
 }
 
 // editMessage edits a message to show the command result
@@ -470,6 +970,7 @@ func (b *Bot) editMessage(ctx context.Context, roomID id.RoomID, eventID id.Even
 
 	// Convert markdown to HTML for formatted_body
 	formattedBody := markdownToHTML(newBody)
+	newBody = plainBody(newBody)
 
 	// Create edit content
 	content := &event.MessageEventContent{
@@ -493,6 +994,26 @@ func (b *Bot) editMessage(ctx context.Context, roomID id.RoomID, eventID id.Even
 	return err
 }
 
+// replyMessage replies to another user's command with the result. Notices are
+// ignored by handleMessage, so the bot never reacts to its own replies.
+func (b *Bot) replyMessage(ctx context.Context, roomID id.RoomID, eventID id.EventID, result string) (id.EventID, error) {
+	content := &event.MessageEventContent{
+		MsgType:       event.MsgNotice,
+		Body:          plainBody(result),
+		Format:        event.FormatHTML,
+		FormattedBody: markdownToHTML(result),
+		RelatesTo: &event.RelatesTo{
+			InReplyTo: &event.InReplyTo{EventID: eventID},
+		},
+	}
+
+	resp, err := b.client.SendMessageEvent(ctx, roomID, event.EventMessage, content)
+	if err != nil {
+		return "", err
+	}
+	return resp.EventID, nil
+}
+
 // stickerUsage sets the usage types for a specific sticker
 func (b *Bot) stickerUsage(stickerID, usageStr string) string {
 	usage, err := storage.ParseUsage(usageStr)
@@ -500,15 +1021,17 @@ func (b *Bot) stickerUsage(stickerID, usageStr string) string {
 		return fmt.Sprintf("❌ %v", err)
 	}
 
-	if err := storage.SetStickerUsage(b.storageDir, stickerID, usage); err != nil {
+	if err := b.store.SetStickerUsage(stickerID, usage); err != nil {
 		return fmt.Sprintf("❌ Error setting sticker usage: %v", err)
 	}
 
+	refreshed := b.refreshPersonal(b.stickerPackNames(stickerID)...)
+
 	if usage == nil {
-		return fmt.Sprintf("✅ Reset usage for sticker %s (will inherit from pack)", stickerID)
+		return fmt.Sprintf("✅ Reset usage for sticker %s (will inherit from pack)", stickerID) + refreshed
 	}
 
-	return fmt.Sprintf("✅ Set sticker %s usage to: %s", stickerID, storage.FormatUsage(usage))
+	return fmt.Sprintf("✅ Set sticker %s usage to: %s", stickerID, storage.FormatUsage(usage)) + refreshed
 }
 
 // stickerName sets the shortcode name for a specific sticker
@@ -518,11 +1041,24 @@ func (b *Bot) stickerName(stickerID, name string) string {
 		return fmt.Sprintf("❌ Invalid shortcode: %v", err)
 	}
 
-	if err := storage.SetStickerName(b.storageDir, stickerID, name); err != nil {
-		return fmt.Sprintf("❌ Error setting sticker name: %v", err)
+	if err := b.store.SetStickerName(stickerID, name); err != nil {
+		return fmt.Sprintf("❌ Error setting sticker name: %v", err) + b.renameHint(err)
+	}
+
+	return fmt.Sprintf("✅ Set sticker shortcode to: :%s:", name) + b.refreshPersonal(b.stickerPackNames(stickerID)...)
+}
+
+// stickerAcceptName names a sticker with its suggested shortcode
+func (b *Bot) stickerAcceptName(stickerID string) string {
+	sticker, err := b.store.GetSticker(stickerID)
+	if err != nil {
+		return stickerError(stickerID, err)
+	}
+	if sticker.SuggestedName == "" {
+		return fmt.Sprintf("❌ No shortcode suggested for `%s` - use `!sticker name %s <shortcode>`", stickerID, stickerID)
 	}
 
-	return fmt.Sprintf("✅ Set sticker shortcode to: :%s:", name)
+	return b.stickerName(stickerID, sticker.SuggestedName)
 }
 
 // packUsage sets the default usage for all stickers in a pack
@@ -532,15 +1068,17 @@ func (b *Bot) packUsage(packName, usageStr string) string {
 		return fmt.Sprintf("❌ %v", err)
 	}
 
-	if err := storage.SetPackUsage(b.storageDir, packName, usage); err != nil {
+	if err := b.store.SetPackUsage(packName, usage); err != nil {
 		return fmt.Sprintf("❌ Error setting pack usage: %v", err)
 	}
 
+	refreshed := b.refreshPersonal(packName)
+
 	if usage == nil {
-		return fmt.Sprintf("✅ Reset usage for pack %s (will use default: both)", packName)
+		return fmt.Sprintf("✅ Reset usage for pack %s (will use default: both)", packName) + refreshed
 	}
 
-	return fmt.Sprintf("✅ Set pack %s default usage to: %s", packName, storage.FormatUsage(usage))
+	return fmt.Sprintf("✅ Set pack %s default usage to: %s", packName, storage.FormatUsage(usage)) + refreshed
 }
 
 // markdownToHTML converts markdown to HTML for Matrix formatted_body
@@ -560,3 +1098,13 @@ func markdownToHTML(text string) string {
 	// Render to HTML and return as string
 	return string(markdown.Render(doc, renderer))
 }
+
+// firstLine returns the first non-empty line of a command result, as a short summary
+func firstLine(text string) string {
+	for line := range strings.SplitSeq(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -2,6 +2,8 @@ package bot
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"testing"
@@ -49,7 +51,7 @@ func setupTestBot(t *testing.T) (*Bot, string) {
 		},
 	}
 
-	bot := NewBot(matrixClient, llmClient, cfg)
+	bot := NewBot(matrixClient, llmClient, storage.NewJSONStore(tmpDir), cfg)
 
 	return bot, tmpDir
 }
@@ -61,7 +63,7 @@ func TestExecuteCommand_PackList(t *testing.T) {
 	defer bot.Stop()
 
 	// Initially no packs - should show unsorted (0)
-	result := bot.executeCommand(context.Background(), "!sticker pack list")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack list")
 	if !strings.Contains(result, "unsorted (0)") {
 		t.Errorf("Expected 'unsorted (0)', got: %s", result)
 	}
@@ -75,7 +77,7 @@ func TestExecuteCommand_PackList(t *testing.T) {
 	}
 
 	// Should now show the pack and unsorted
-	result = bot.executeCommand(context.Background(), "!sticker pack list")
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack list")
 	if !strings.Contains(result, "test-pack") {
 		t.Errorf("Expected pack to be listed, got: %s", result)
 	}
@@ -90,7 +92,7 @@ func TestExecuteCommand_PackCreate(t *testing.T) {
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 	defer bot.Stop()
 
-	result := bot.executeCommand(context.Background(), "!sticker pack create favourites")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack create favourites")
 	if !strings.Contains(result, "✅") || !strings.Contains(result, "favourites") {
 		t.Errorf("Expected success message, got: %s", result)
 	}
@@ -111,7 +113,7 @@ func TestExecuteCommand_PackCreateWithSpaces(t *testing.T) {
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 	defer bot.Stop()
 
-	result := bot.executeCommand(context.Background(), "!sticker pack create Funny Memes")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack create Funny Memes")
 	if !strings.Contains(result, "✅") {
 		t.Errorf("Expected success, got: %s", result)
 	}
@@ -129,7 +131,7 @@ func TestExecuteCommand_PackCreateUnsorted(t *testing.T) {
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 	defer bot.Stop()
 
-	result := bot.executeCommand(context.Background(), "!sticker pack create unsorted")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack create unsorted")
 	if !strings.Contains(result, "❌") || !strings.Contains(result, "reserved") {
 		t.Errorf("Expected error about reserved name, got: %s", result)
 	}
@@ -162,7 +164,7 @@ func TestExecuteCommand_PackAdd(t *testing.T) {
 	}
 
 	// Add sticker to pack
-	result := bot.executeCommand(context.Background(), "!sticker pack add test-pack sha256:test123")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack add test-pack sha256:test123")
 	if !strings.Contains(result, "✅") {
 		t.Errorf("Expected success, got: %s", result)
 	}
@@ -198,7 +200,7 @@ func TestExecuteCommand_PackRemove(t *testing.T) {
 	}
 
 	// Remove sticker
-	result := bot.executeCommand(context.Background(), "!sticker pack remove test-pack sha256:test123")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack remove test-pack sha256:test123")
 	if !strings.Contains(result, "✅") {
 		t.Errorf("Expected success, got: %s", result)
 	}
@@ -234,12 +236,139 @@ func TestExecuteCommand_PackShow(t *testing.T) {
 		t.Fatalf("Failed to add to pack: %v", err)
 	}
 
-	result := bot.executeCommand(context.Background(), "!sticker pack show test-pack")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack show test-pack")
 	if !strings.Contains(result, "cute sticker") || !strings.Contains(result, "test123abc") {
 		t.Errorf("Expected sticker details, got: %s", result)
 	}
 }
 
+// TestExecuteCommand_PackRenameTitleMove verifies renaming, retitling and reordering a pack
+func TestExecuteCommand_PackRenameTitleMove(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	if err := storage.CreatePack(tmpDir, "test-pack", "Test Pack"); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	for _, id := range []string{"sha256:one", "sha256:two"} {
+		if err := storage.AddSticker(tmpDir, storage.Sticker{ID: id, CollectedAt: time.Now(), InPacks: []string{}}); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+	if err := storage.AddToPack(tmpDir, "test-pack", []string{"sha256:one", "sha256:two"}); err != nil {
+		t.Fatalf("Failed to add to pack: %v", err)
+	}
+
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack rename test-pack Cute Cats")
+	if !strings.Contains(result, "✅") {
+		t.Fatalf("Expected rename success, got: %s", result)
+	}
+	sticker, _ := storage.GetSticker(tmpDir, "sha256:one")
+	if len(sticker.InPacks) != 1 || sticker.InPacks[0] != "cute-cats" {
+		t.Errorf("Expected sticker to reference renamed pack, got %v", sticker.InPacks)
+	}
+
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack title cute-cats The Cutest Cats")
+	if !strings.Contains(result, "✅") {
+		t.Errorf("Expected title success, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack move cute-cats sha256:two 1")
+	if !strings.Contains(result, "✅") {
+		t.Errorf("Expected move success, got: %s", result)
+	}
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack move cute-cats sha256:two first")
+	if !strings.Contains(result, "Invalid position") {
+		t.Errorf("Expected invalid position error, got: %s", result)
+	}
+
+	pack, _ := storage.GetPack(tmpDir, "cute-cats")
+	if pack.DisplayName != "The Cutest Cats" {
+		t.Errorf("Expected display name to be set, got %s", pack.DisplayName)
+	}
+	if len(pack.StickerIDs) != 2 || pack.StickerIDs[0] != "sha256:two" {
+		t.Errorf("Expected sha256:two first, got %v", pack.StickerIDs)
+	}
+}
+
+// TestExecuteCommand_PackRenameWarnsAboutConfig verifies renaming a pack named in
+// config.yaml points out the settings still using the old name
+func TestExecuteCommand_PackRenameWarnsAboutConfig(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	bot.config.AltText.Packs = []config.PackAltText{{Pack: "cats", Prompt: "Describe the cat"}}
+	bot.config.Collection.Shortcuts = []config.ReactionShortcut{{Key: "🐱", Pack: "cats"}, {Key: "🐶", Pack: "dogs"}}
+	for _, name := range []string{"cats", "dogs"} {
+		if err := storage.CreatePack(tmpDir, name, name); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+	}
+
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack rename cats kittens")
+	if !strings.Contains(result, "✅") || !strings.Contains(result, "⚠️") ||
+		!strings.Contains(result, "alt_text.packs and collection.shortcuts (`🐱`)") {
+		t.Errorf("Expected a warning naming both settings, got: %s", result)
+	}
+
+	bot.config.Collection.Shortcuts = nil
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack rename dogs puppies")
+	if strings.Contains(result, "⚠️") {
+		t.Errorf("Expected no warning for a pack not named in the config, got: %s", result)
+	}
+}
+
+// TestExecuteCommand_PackDelete verifies deleting packs, and that published packs need a flag
+func TestExecuteCommand_PackDelete(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	for _, name := range []string{"draft", "published"} {
+		if err := storage.CreatePack(tmpDir, name, name); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+	}
+	if err := storage.UpdatePublished(tmpDir, "published", "!room:matrix.org", "published"); err != nil {
+		t.Fatalf("Failed to update published: %v", err)
+	}
+
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack delete draft")
+	if !strings.Contains(result, "✅") {
+		t.Errorf("Expected delete success, got: %s", result)
+	}
+	if _, err := storage.GetPack(tmpDir, "draft"); err == nil {
+		t.Error("Expected draft pack to be deleted")
+	}
+
+	// A published pack asks how to handle its rooms first
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack delete published")
+	if !strings.Contains(result, "--unpublish") {
+		t.Errorf("Expected unpublish offer, got: %s", result)
+	}
+	if _, err := storage.GetPack(tmpDir, "published"); err != nil {
+		t.Error("Expected published pack to be kept without a flag")
+	}
+
+	// A mistyped flag is refused rather than ignored
+	for _, command := range []string{"!sticker pack delete published --unpublsh", "!sticker pack delete published --force extra"} {
+		result = bot.executeCommand(context.Background(), bot.client.UserID, command)
+		if !strings.HasPrefix(result, "❌") || !strings.Contains(result, "Usage:") {
+			t.Errorf("%q: expected a usage error, got: %s", command, result)
+		}
+	}
+	if _, err := storage.GetPack(tmpDir, "published"); err != nil {
+		t.Error("Expected published pack to be kept with an unknown flag")
+	}
+
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker pack delete published --force")
+	if !strings.Contains(result, "✅") {
+		t.Errorf("Expected forced delete success, got: %s", result)
+	}
+}
+
 // TestExecuteCommand_ListUnsorted verifies listing unsorted stickers
 func TestExecuteCommand_ListUnsorted(t *testing.T) {
 	bot, tmpDir := setupTestBot(t)
@@ -247,7 +376,7 @@ func TestExecuteCommand_ListUnsorted(t *testing.T) {
 	defer bot.Stop()
 
 	// Initially no stickers
-	result := bot.executeCommand(context.Background(), "!sticker list unsorted")
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker list unsorted")
 	if !strings.Contains(result, "All stickers are organized") {
 		t.Errorf("Expected organized message, got: %s", result)
 	}
@@ -263,7 +392,7 @@ func TestExecuteCommand_ListUnsorted(t *testing.T) {
 		t.Fatalf("Failed to add sticker: %v", err)
 	}
 
-	result = bot.executeCommand(context.Background(), "!sticker list unsorted")
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker list unsorted")
 	if !strings.Contains(result, "Unsorted") || !strings.Contains(result, "Unsorted sticker") {
 		t.Errorf("Expected unsorted sticker to be listed, got: %s", result)
 	}
@@ -287,13 +416,22 @@ func TestExecuteCommand_InvalidCommands(t *testing.T) {
 		{"!sticker pack create", "Usage:", false},
 		{"!sticker pack add", "Usage:", false},
 		{"!sticker pack add packname", "Usage:", false},
+		{"!sticker pack import", "Usage:", false},
+		{"!sticker pack publish missing --personal", "pack not found", false},
+		{"!sticker pack unpublish", "Usage:", false},
+		{"!sticker pack unpublish missing", "pack not found", false},
+		{"!sticker pack unpublish missing notaroom", "Invalid room ID", false},
+		{"!sticker pack subscribe", "Usage:", false},
+		{"!sticker pack subscribe missing", "pack not found", false},
+		{"!sticker pack unsubscribe", "Usage:", false},
+		{"!sticker pack import notaroom", "Invalid room ID", false},
 		{"!sticker list", "No list subcommand", false},
 		{"!sticker list unknown", "Unknown list subcommand", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.command, func(t *testing.T) {
-			result := bot.executeCommand(context.Background(), tt.command)
+			result := bot.executeCommand(context.Background(), bot.client.UserID, tt.command)
 			if tt.isHelp {
 				if !strings.Contains(result, tt.expectError) {
 					t.Errorf("Expected help text with %q, got: %s", tt.expectError, result)
@@ -306,3 +444,16 @@ func TestExecuteCommand_InvalidCommands(t *testing.T) {
 		})
 	}
 }
+
+// TestStickerError verifies only a missing sticker is reported as not found
+func TestStickerError(t *testing.T) {
+	notFound := fmt.Errorf("%w: sha256:abc", storage.ErrStickerNotFound)
+	if got := stickerError("sha256:abc", notFound); got != "❌ Sticker not found: sha256:abc" {
+		t.Errorf("Expected not found, got %q", got)
+	}
+
+	got := stickerError("sha256:abc", errors.New("failed to load collection: disk on fire"))
+	if !strings.Contains(got, "Error loading sticker") || !strings.Contains(got, "disk on fire") {
+		t.Errorf("Expected the load error, got %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+// Reactions added to the image in reaction feedback mode
+const (
+	feedbackCollected = "✅"
+	feedbackFailed    = "❌"
+)
+
+// feedbackMode returns the configured collection feedback, defaulting to reactions
+func (b *Bot) feedbackMode() string {
+	if b.config.Collection.Feedback == "" {
+		return config.FeedbackReaction
+	}
+	return b.config.Collection.Feedback
+}
+
+// reportCollected tells the room a job's sticker is in the collection. The reaction
+// that asked for it is always redacted, keeping the timeline clean. A command waiting
+// on the sticker runs now, and its result is the only feedback.
+func (b *Bot) reportCollected(ctx context.Context, job *storage.Job, existed bool) {
+	roomID := id.RoomID(job.RoomID)
+
+	if job.ReactionID != "" {
+		if err := b.redactReaction(ctx, roomID, id.EventID(job.ReactionID)); err != nil {
+			log.Printf("Warning: failed to redact reaction: %v", err)
+		}
+	}
+
+	// A failure reported before a successful retry no longer applies
+	if job.FeedbackID != "" {
+		if _, err := b.client.RedactEvent(ctx, roomID, id.EventID(job.FeedbackID)); err != nil {
+			log.Printf("Warning: failed to redact earlier failure feedback: %v", err)
+		}
+	}
+
+	switch {
+	case job.Command != "":
+		b.runJobCommand(ctx, job)
+		return
+	case job.EventID == "":
+		return // Imported images have no event to react or reply to
+	}
+
+	switch b.feedbackMode() {
+	case config.FeedbackReaction:
+		if _, err := b.sendFeedbackReaction(ctx, job, feedbackCollected); err != nil {
+			log.Printf("Warning: failed to send collection feedback: %v", err)
+		}
+
+	case config.FeedbackVerbose:
+		sticker, err := b.store.GetSticker(job.StickerID)
+		if err != nil {
+			log.Printf("Warning: failed to load collected sticker %s: %v", job.StickerID, err)
+			return
+		}
+		if _, err := b.sendFeedbackReply(ctx, job, collectedMessage(sticker, existed)); err != nil {
+			log.Printf("Warning: failed to send collection feedback: %v", err)
+		}
+	}
+}
+
+// reportFailed tells the room a job has given up. The reaction that asked for the
+// sticker is left in place, so quiet mode still shows nothing was collected. A
+// command waiting on the job is always answered.
+func (b *Bot) reportFailed(ctx context.Context, job *storage.Job) {
+	var feedbackID id.EventID
+	var err error
+
+	switch {
+	case job.CommandEvent != "":
+		b.loadFeedbackRoom(ctx, job)
+		feedbackID, err = b.replyMessage(ctx, id.RoomID(job.RoomID), id.EventID(job.CommandEvent), failedMessage(job))
+	case job.EventID == "":
+		return // Imported images are listed by !sticker jobs instead
+	case b.feedbackMode() == config.FeedbackReaction:
+		feedbackID, err = b.sendFeedbackReaction(ctx, job, feedbackFailed)
+	case b.feedbackMode() == config.FeedbackVerbose:
+		feedbackID, err = b.sendFeedbackReply(ctx, job, failedMessage(job))
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: failed to send collection feedback: %v", err)
+		return
+	}
+
+	// Remember the feedback so it can be taken back if the job is retried
+	job.FeedbackID = feedbackID.String()
+	if err := b.jobs.update(job); err != nil {
+		log.Printf("Warning: failed to save job %s feedback: %v", job.ID, err)
+	}
+}
+
+// reportMissingPack tells the room a reaction asked for a pack that doesn't exist,
+// the same way a failed collection is reported
+func (b *Bot) reportMissingPack(ctx context.Context, evt *event.Event, imageID id.EventID, pack string) {
+	if evt.Mautrix.WasEncrypted {
+		if err := b.client.LoadEncryptedRoom(ctx, evt.RoomID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	var err error
+	switch b.feedbackMode() {
+	case config.FeedbackReaction:
+		_, err = b.client.SendReaction(ctx, evt.RoomID, imageID, feedbackFailed)
+	case config.FeedbackVerbose:
+		_, err = b.replyMessage(ctx, evt.RoomID, imageID, fmt.Sprintf(
+			"❌ Couldn't collect this image: pack not found: %s\n\nCreate it with `!sticker pack create %s` and react again", pack, pack))
+	}
+	if err != nil {
+		log.Printf("Warning: failed to send collection feedback: %v", err)
+	}
+}
+
+// reportRegenerated answers the command that asked for a sticker's alt-text to be
+// regenerated, if there was one
+func (b *Bot) reportRegenerated(ctx context.Context, job *storage.Job) {
+	if job.CommandEvent == "" {
+		return
+	}
+	message := fmt.Sprintf("✅ Regenerated alt-text for `%s`: %s", job.StickerID, job.AltText)
+	if _, err := b.replyMessage(ctx, id.RoomID(job.RoomID), id.EventID(job.CommandEvent), message); err != nil {
+		log.Printf("Warning: failed to send regeneration result: %v", err)
+	}
+}
+
+// runJobCommand runs the command that was waiting on a job's sticker, answering the
+// command's message with the result
+func (b *Bot) runJobCommand(ctx context.Context, job *storage.Job) {
+	log.Printf("Running command waiting on sticker %s: %s", job.StickerID, job.Command)
+	result := b.executeCommandFrom(ctx, id.UserID(job.RequestedBy), id.RoomID(job.RoomID), id.EventID(job.CommandEvent), job.Command)
+
+	b.loadFeedbackRoom(ctx, job)
+	if _, err := b.replyMessage(ctx, id.RoomID(job.RoomID), id.EventID(job.CommandEvent), result); err != nil {
+		log.Printf("Warning: failed to send command result: %v", err)
+	}
+}
+
+// sendFeedbackReaction reacts to the job's image
+func (b *Bot) sendFeedbackReaction(ctx context.Context, job *storage.Job, key string) (id.EventID, error) {
+	b.loadFeedbackRoom(ctx, job)
+	resp, err := b.client.SendReaction(ctx, id.RoomID(job.RoomID), id.EventID(job.EventID), key)
+	if err != nil {
+		return "", err
+	}
+	return resp.EventID, nil
+}
+
+// sendFeedbackReply replies to the job's image
+func (b *Bot) sendFeedbackReply(ctx context.Context, job *storage.Job, text string) (id.EventID, error) {
+	b.loadFeedbackRoom(ctx, job)
+	return b.replyMessage(ctx, id.RoomID(job.RoomID), id.EventID(job.EventID), text)
+}
+
+// loadFeedbackRoom makes sure feedback is encrypted in encrypted rooms. Images
+// there are always sent as encrypted files, so that's the only case to check.
+func (b *Bot) loadFeedbackRoom(ctx context.Context, job *storage.Job) {
+	if len(job.File) == 0 {
+		return
+	}
+	if err := b.client.LoadEncryptedRoom(ctx, id.RoomID(job.RoomID)); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// collectedMessage describes a collected sticker for verbose feedback
+func collectedMessage(sticker *storage.Sticker, existed bool) string {
+	status := "✅ Collected sticker"
+	if existed {
+		status = "✅ Already collected as sticker"
+	}
+
+	shortcode := fmt.Sprintf("none yet, set one with `!sticker name %s <shortcode>`", sticker.ID)
+	switch {
+	case sticker.Name != "" && sticker.Name != sticker.ID:
+		shortcode = fmt.Sprintf(":%s:", sticker.Name)
+	case sticker.SuggestedName != "":
+		shortcode = fmt.Sprintf("none yet, suggested :%s: - accept it with `!sticker name %s`", sticker.SuggestedName, sticker.ID)
+	}
+
+	message := fmt.Sprintf("%s `%s`\n\nShortcode: %s\n\nAlt-text: %s", status, sticker.ID, shortcode, sticker.GeneratedAltText)
+	if len(sticker.InPacks) > 0 {
+		message += fmt.Sprintf("\n\nPacks: %s", strings.Join(sticker.InPacks, ", "))
+	}
+	return message
+}
+
+// failedMessage describes a failed job for verbose feedback
+func failedMessage(job *storage.Job) string {
+	if job.Kind == storage.JobRegenerate {
+		return fmt.Sprintf("❌ Couldn't regenerate alt-text for `%s` (%s): %s\n\nUse `!sticker jobs retry %s` to try again",
+			job.StickerID, job.Stage, job.LastError, job.ID)
+	}
+	return fmt.Sprintf("❌ Couldn't collect this image (%s): %s\n\nUse `!sticker jobs retry %s` to try again",
+		job.Stage, job.LastError, job.ID)
+}
@@ -0,0 +1,205 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/event"
+)
+
+// feedbackMode configures a bot to give collection feedback in mode
+func feedbackMode(mode string) func(cfg *config.Config) {
+	return func(cfg *config.Config) { cfg.Collection.Feedback = mode }
+}
+
+// runFailingJob queues and runs a job for an event the homeserver can't find
+func runFailingJob(t *testing.T, bot *Bot) storage.Job {
+	t.Helper()
+
+	if err := bot.jobs.enqueue(storage.Job{ID: "job1", RoomID: "!room:matrix.org", EventID: "$missing", ReactionID: "$reaction"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	job, _, _ := bot.jobs.next()
+	if job == nil {
+		t.Fatal("Expected to claim the job")
+	}
+	bot.runJob(context.Background(), job)
+
+	jobs, _ := bot.jobs.list()
+	if len(jobs) != 1 {
+		t.Fatalf("Expected the failed job to stay queued, got %+v", jobs)
+	}
+	return jobs[0]
+}
+
+// TestReportFailed_Reaction verifies a failed collection reacts ❌ to the image
+func TestReportFailed_Reaction(t *testing.T) {
+	bot, homeserver := setupServerBot(t, serverBotOptions{configure: feedbackMode(config.FeedbackReaction)})
+	job := runFailingJob(t, bot)
+
+	events := homeserver.sent()
+	if len(events) != 1 || events[0].eventType != "m.reaction" {
+		t.Fatalf("Expected one reaction, got %+v", events)
+	}
+	relates, _ := events[0].content["m.relates_to"].(map[string]any)
+	if relates["event_id"] != "$missing" || relates["key"] != feedbackFailed {
+		t.Errorf("Expected ❌ on the image, got %+v", relates)
+	}
+	if job.FeedbackID != "$feedback" {
+		t.Errorf("Expected feedback event to be remembered, got %q", job.FeedbackID)
+	}
+}
+
+// TestReportFailed_Verbose verifies a failed collection replies to the image with the error
+func TestReportFailed_Verbose(t *testing.T) {
+	bot, homeserver := setupServerBot(t, serverBotOptions{configure: feedbackMode(config.FeedbackVerbose)})
+	runFailingJob(t, bot)
+
+	events := homeserver.sent()
+	if len(events) != 1 || events[0].eventType != "m.room.message" {
+		t.Fatalf("Expected one reply, got %+v", events)
+	}
+	body, _ := events[0].content["body"].(string)
+	if !strings.Contains(body, "M_NOT_FOUND") || !strings.Contains(body, "!sticker jobs retry job1") {
+		t.Errorf("Expected error and retry hint in reply, got: %s", body)
+	}
+}
+
+// TestReportFailed_Quiet verifies quiet mode sends nothing on failure
+func TestReportFailed_Quiet(t *testing.T) {
+	bot, homeserver := setupServerBot(t, serverBotOptions{configure: feedbackMode(config.FeedbackQuiet)})
+	runFailingJob(t, bot)
+
+	if events := homeserver.sent(); len(events) != 0 {
+		t.Errorf("Expected no feedback, got %+v", events)
+	}
+}
+
+// TestReportFailed_Regenerate verifies a failed regeneration answers the command that asked for it
+func TestReportFailed_Regenerate(t *testing.T) {
+	bot, homeserver := setupServerBot(t, serverBotOptions{configure: feedbackMode(config.FeedbackQuiet)})
+
+	job := storage.Job{ID: "job1", Kind: storage.JobRegenerate, RoomID: "!room:matrix.org", StickerID: "sha256:gone", CommandEvent: "$command"}
+	if err := bot.jobs.enqueue(job); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if _, err := bot.RunJobs(context.Background()); err != nil {
+		t.Fatalf("Failed to run jobs: %v", err)
+	}
+
+	events := homeserver.sent()
+	if len(events) != 1 || events[0].eventType != "m.room.message" {
+		t.Fatalf("Expected one reply, got %+v", events)
+	}
+	relates, _ := events[0].content["m.relates_to"].(map[string]any)
+	inReplyTo, _ := relates["m.in_reply_to"].(map[string]any)
+	body, _ := events[0].content["body"].(string)
+	if inReplyTo["event_id"] != "$command" || !strings.Contains(body, "Couldn't regenerate alt-text for `sha256:gone`") {
+		t.Errorf("Expected the failure in reply to the command, got %+v", events[0].content)
+	}
+}
+
+// reactToCollect processes a reaction to $image with key
+func reactToCollect(t *testing.T, bot *Bot, key string) {
+	t.Helper()
+
+	evt := &event.Event{
+		ID:     "$reaction",
+		RoomID: "!room:matrix.org",
+		Sender: "@test:matrix.org",
+		Type:   event.EventReaction,
+		Content: event.Content{
+			Parsed: &event.ReactionEventContent{
+				RelatesTo: event.RelatesTo{Type: event.RelAnnotation, EventID: "$image", Key: key},
+			},
+		},
+	}
+	if err := bot.processReaction(context.Background(), evt); err != nil {
+		t.Fatalf("Failed to process reaction: %v", err)
+	}
+}
+
+// TestProcessReaction_MissingPack verifies a reaction naming a missing pack is
+// reported straight away instead of being queued to fail
+func TestProcessReaction_MissingPack(t *testing.T) {
+	for _, mode := range []string{config.FeedbackQuiet, config.FeedbackReaction, config.FeedbackVerbose} {
+		t.Run(mode, func(t *testing.T) {
+			bot, homeserver := setupServerBot(t, serverBotOptions{configure: feedbackMode(mode)})
+			reactToCollect(t, bot, "!yoink:cats")
+
+			if jobs, _ := bot.jobs.list(); len(jobs) != 0 {
+				t.Errorf("Expected nothing queued, got %+v", jobs)
+			}
+			if entry, err := bot.journal.Get("$reaction"); err != nil || entry == nil {
+				t.Errorf("Expected the reaction to be journaled, got %v, %v", entry, err)
+			}
+
+			events := homeserver.sent()
+			switch mode {
+			case config.FeedbackQuiet:
+				if len(events) != 0 {
+					t.Errorf("Expected no feedback, got %+v", events)
+				}
+			case config.FeedbackReaction:
+				if len(events) != 1 || events[0].eventType != "m.reaction" {
+					t.Fatalf("Expected one reaction, got %+v", events)
+				}
+				relates, _ := events[0].content["m.relates_to"].(map[string]any)
+				if relates["event_id"] != "$image" || relates["key"] != feedbackFailed {
+					t.Errorf("Expected ❌ on the image, got %+v", relates)
+				}
+			case config.FeedbackVerbose:
+				if len(events) != 1 || events[0].eventType != "m.room.message" {
+					t.Fatalf("Expected one reply, got %+v", events)
+				}
+				if body, _ := events[0].content["body"].(string); !strings.Contains(body, "pack not found: cats") {
+					t.Errorf("Expected the missing pack in the reply, got: %s", body)
+				}
+			}
+		})
+	}
+}
+
+// TestProcessReaction_ExistingPack verifies a reaction naming a pack that exists is queued
+func TestProcessReaction_ExistingPack(t *testing.T) {
+	bot, homeserver := setupServerBot(t, serverBotOptions{configure: feedbackMode(config.FeedbackVerbose)})
+	if err := bot.store.CreatePack("cats", "Cats", ""); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	reactToCollect(t, bot, "!yoink:cats")
+
+	if jobs, _ := bot.jobs.list(); len(jobs) != 1 || jobs[0].Pack != "cats" {
+		t.Errorf("Expected one job for pack cats, got %+v", jobs)
+	}
+	if events := homeserver.sent(); len(events) != 0 {
+		t.Errorf("Expected no feedback yet, got %+v", events)
+	}
+}
+
+// TestCollectedMessage verifies the verbose success reply
+func TestCollectedMessage(t *testing.T) {
+	sticker := &storage.Sticker{ID: "abc123", Name: "abc123", GeneratedAltText: "A cat waving"}
+
+	msg := collectedMessage(sticker, false)
+	if !strings.Contains(msg, "✅ Collected sticker `abc123`") || !strings.Contains(msg, "A cat waving") {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+	if !strings.Contains(msg, "!sticker name abc123") {
+		t.Errorf("Expected hint to set a shortcode, got: %s", msg)
+	}
+
+	sticker.SuggestedName = "cat_wave"
+	msg = collectedMessage(sticker, false)
+	if !strings.Contains(msg, "suggested :cat_wave:") || !strings.Contains(msg, "`!sticker name abc123`") {
+		t.Errorf("Expected the suggested shortcode, got: %s", msg)
+	}
+
+	sticker.Name = "wave"
+	msg = collectedMessage(sticker, true)
+	if !strings.Contains(msg, "Already collected") || !strings.Contains(msg, ":wave:") {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+}
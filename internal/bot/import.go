@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// ImportResult summarises a pack import
+type ImportResult struct {
+	PackName    string // Local pack the stickers are imported into
+	DisplayName string // Pack display name
+	Queued      int    // Images queued for collection
+}
+
+// ImportPack copies an MSC2545 pack from a room's state into the collection. A local
+// pack is created straight away with the original pack metadata, and every image is
+// queued as a collection job, going through the same download/rehost/alt-text
+// pipeline and retries as a collected sticker. Each job files its sticker into the
+// pack under its original shortcode, so the pack fills in as the jobs finish, in
+// the order the room's pack lists them unless one needs a retry. The local pack is
+// named after the state key, or the pack's display name when the state key is
+// empty. New stickers are recorded as collected by importer.
+func (b *Bot) ImportPack(ctx context.Context, importer id.UserID, roomID id.RoomID, stateKey string) (*ImportResult, error) {
+	// The images are collected by jobs, so don't create a pack they can't be queued for
+	if err := b.jobs.open(); err != nil {
+		return nil, fmt.Errorf("failed to open job queue: %w", err)
+	}
+
+	content, err := b.client.GetRoomPack(ctx, roomID, stateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(content.Images) == 0 {
+		return nil, fmt.Errorf("pack has no images")
+	}
+
+	packName := importPackName(stateKey, content.Pack.DisplayName)
+	if packName == "unsorted" {
+		return nil, fmt.Errorf("cannot import into 'unsorted' - this is a reserved name for stickers not in any pack")
+	}
+	if _, err := b.store.GetPack(packName); err == nil {
+		return nil, fmt.Errorf("pack already exists: %s", packName)
+	}
+
+	displayName := content.Pack.DisplayName
+	if displayName == "" {
+		displayName = packName
+	}
+
+	// Create the local pack with the original metadata. An avatar that's one of the
+	// pack's own images is swapped for our copy once its job has rehosted it.
+	if err := b.store.CreatePack(packName, displayName, content.Pack.Attribution); err != nil {
+		return nil, fmt.Errorf("failed to create pack: %w", err)
+	}
+	if len(content.Pack.Usage) > 0 {
+		if err := b.store.SetPackUsage(packName, content.Pack.Usage); err != nil {
+			return nil, fmt.Errorf("failed to set pack usage: %w", err)
+		}
+	}
+	if content.Pack.AvatarURL != "" {
+		if err := b.store.SetPackAvatar(packName, content.Pack.AvatarURL); err != nil {
+			return nil, fmt.Errorf("failed to set pack avatar: %w", err)
+		}
+	}
+
+	result := &ImportResult{PackName: packName, DisplayName: displayName}
+
+	// Queue in the order the room's pack lists its images
+	for _, shortcode := range content.Shortcodes() {
+		image := content.Images[shortcode]
+		job := storage.Job{
+			ID:           jobID("import " + packName + " " + shortcode),
+			RoomID:       roomID.String(),
+			RequestedBy:  importer.String(),
+			Pack:         packName,
+			SourceMXC:    image.URL,
+			OriginalBody: image.Body,
+			Usage:        image.Usage,
+		}
+		// Imported shortcodes may use characters we don't allow, so fall back to the hash
+		if storage.ValidateShortcode(shortcode) == nil {
+			job.Name = shortcode
+		}
+
+		if err := b.jobs.enqueue(job); err != nil {
+			return result, fmt.Errorf("failed to queue :%s:: %w", shortcode, err)
+		}
+		result.Queued++
+	}
+
+	log.Printf("✅ Importing pack %s: queued %d image(s)", packName, result.Queued)
+
+	return result, nil
+}
+
+// importPackName picks the local pack name for an imported pack, sanitised the
+// same way as pack create (lowercase, no spaces)
+func importPackName(stateKey string, displayName string) string {
+	name := strings.TrimSpace(stateKey)
+	if name == "" {
+		name = strings.TrimSpace(displayName)
+	}
+	if name == "" {
+		name = "imported"
+	}
+
+	return sanitizePackName(name)
+}
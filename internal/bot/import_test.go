@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+)
+
+// TestImportPackName verifies imported packs are named from the state key or display name
+func TestImportPackName(t *testing.T) {
+	tests := []struct {
+		stateKey    string
+		displayName string
+		expected    string
+	}{
+		{"cats", "Cute Cats", "cats"},
+		{"", "Cute Cats", "cute-cats"},
+		{"My Pack", "", "my-pack"},
+		{"", "", "imported"},
+		{"  ", "  ", "imported"},
+	}
+
+	for _, tt := range tests {
+		if got := importPackName(tt.stateKey, tt.displayName); got != tt.expected {
+			t.Errorf("importPackName(%q, %q) = %q, expected %q", tt.stateKey, tt.displayName, got, tt.expected)
+		}
+	}
+}
+
+// TestImportPack verifies an import creates the pack at once and queues its images,
+// which the jobs collect under their original shortcodes
+func TestImportPack(t *testing.T) {
+	images := map[string][]byte{"cat": testPNG(t, 8), "dog": testPNG(t, 16)}
+	bot, _ := setupServerBot(t, serverBotOptions{handler: func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/state/im.ponies.room_emotes/"):
+			_, _ = w.Write([]byte(`{"pack":{"display_name":"Pets","avatar_url":"mxc://matrix.org/dog"},
+				"images":{"cat":{"url":"mxc://matrix.org/cat","body":"A cat"},"dog!":{"url":"mxc://matrix.org/dog","usage":["emoticon"]}}}`))
+		case strings.HasSuffix(r.URL.Path, "/matrix.org/cat"):
+			_, _ = w.Write(images["cat"])
+		case strings.HasSuffix(r.URL.Path, "/matrix.org/dog"):
+			_, _ = w.Write(images["dog"])
+		default:
+			notFound(w)
+		}
+	}})
+	ctx := context.Background()
+
+	result, err := bot.ImportPack(ctx, "@importer:matrix.org", "!room:matrix.org", "")
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if result.PackName != "pets" || result.Queued != 2 {
+		t.Fatalf("Expected 2 images queued for pack pets, got %+v", result)
+	}
+	if pack, err := bot.store.GetPack("pets"); err != nil || len(pack.StickerIDs) != 0 {
+		t.Fatalf("Expected an empty pack until the jobs run, got %+v, %v", pack, err)
+	}
+
+	remaining, err := bot.RunJobs(ctx)
+	if err != nil || len(remaining) != 0 {
+		t.Fatalf("Expected every job to finish, got %+v, %v", remaining, err)
+	}
+
+	stickers, err := bot.store.PackStickers("pets")
+	if err != nil || len(stickers) != 2 {
+		t.Fatalf("Expected 2 stickers in the pack, got %+v, %v", stickers, err)
+	}
+	cat, dog := stickers[0], stickers[1]
+	if cat.ID != matrix.HashImage(images["cat"]) || cat.Name != "cat" || cat.CollectedBy != "@importer:matrix.org" {
+		t.Errorf("Expected the cat first under :cat:, got %+v", cat)
+	}
+	// "dog!" isn't a valid shortcode, so the dog keeps its hash
+	if dog.Shortcode() != dog.ID || len(dog.Usage) != 1 || dog.Usage[0] != "emoticon" {
+		t.Errorf("Expected the dog unnamed with emoticon usage, got %+v", dog)
+	}
+
+	pack, err := bot.store.GetPack("pets")
+	if err != nil || pack.AvatarURL != dog.LocalMXC {
+		t.Errorf("Expected the dog as avatar, got %+v, %v", pack, err)
+	}
+}
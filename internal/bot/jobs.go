@@ -0,0 +1,761 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+// Collection job stages, in the order they run
+const (
+	stageResolve  = "resolve"  // Fetch the reacted-to event and find its image
+	stageDownload = "download" // Download (and decrypt) the image
+	stageUpload   = "upload"   // Rehost the image on our homeserver
+	stageAltText  = "alt-text" // Describe and tag the image with the alt-text provider
+	stageSave     = "save"     // Add the sticker to the collection
+	stagePack     = "pack"     // File the sticker into the pack the reaction asked for
+)
+
+// Retry delays double after each failed attempt at a stage, up to maxRetryDelay
+const (
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = time.Hour
+)
+
+// permanentError marks a failure that retrying can't fix, like reacting to a text message
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent marks err as not worth retrying
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// jobID derives a short, stable job ID from what requested it: a reaction, a command
+// or an image in an imported pack
+func jobID(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// retryDelay is how long to wait before the given attempt at a stage
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// jobQueue is the persistent queue of collection jobs, kept in jobs.json. Jobs are
+// removed once they succeed; failed jobs stay until retried or cleared.
+type jobQueue struct {
+	mu          sync.Mutex
+	dataDir     string
+	maxAttempts int
+	jobs        []storage.Job // nil until loaded
+	unlock      func()        // Releases jobs.json to other processes, once loaded
+	closed      bool
+	wake        chan struct{}
+}
+
+// newJobQueue creates a job queue for the data directory. The file is read on first use.
+func newJobQueue(dataDir string, maxAttempts int) *jobQueue {
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return &jobQueue{
+		dataDir:     dataDir,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// load reads jobs.json on first use, taking it for this process until close; callers
+// must hold mu. Jobs left running by a previous process are put back in the queue.
+func (q *jobQueue) load() error {
+	if q.jobs != nil {
+		return nil
+	}
+	if q.closed {
+		return fmt.Errorf("job queue is closed")
+	}
+
+	unlock, err := storage.LockJobs(q.dataDir)
+	if err != nil {
+		return err
+	}
+	jobs, err := storage.LoadJobs(q.dataDir)
+	if err != nil {
+		unlock()
+		return err
+	}
+	q.unlock = unlock
+	for i := range jobs {
+		if jobs[i].Status == storage.JobRunning {
+			jobs[i].Status = storage.JobPending
+		}
+	}
+	q.jobs = jobs
+
+	return nil
+}
+
+// open loads the queue now, failing if another process has it
+func (q *jobQueue) open() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.load()
+}
+
+// close releases jobs.json to other processes. The queue can't be used afterwards.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.jobs = nil
+	if q.unlock != nil {
+		q.unlock()
+		q.unlock = nil
+	}
+}
+
+// notify wakes an idle worker
+func (q *jobQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue adds a new pending job, ignoring one already queued with the same ID
+func (q *jobQueue) enqueue(job storage.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return err
+	}
+	if slices.ContainsFunc(q.jobs, func(j storage.Job) bool { return j.ID == job.ID }) {
+		return nil
+	}
+
+	now := time.Now()
+	job.Status = storage.JobPending
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	q.jobs = append(q.jobs, job)
+
+	if err := storage.SaveJobs(q.dataDir, q.jobs); err != nil {
+		return err
+	}
+	q.notify()
+
+	return nil
+}
+
+// next claims the oldest job that is due, marking it running. If none is due it
+// returns how long until one will be, or zero if nothing is waiting.
+func (q *jobQueue) next() (*storage.Job, time.Duration, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	var wait time.Duration
+	for i := range q.jobs {
+		job := &q.jobs[i]
+		if job.Status != storage.JobPending {
+			continue
+		}
+		if until := job.NextAttempt.Sub(now); until > 0 {
+			if wait == 0 || until < wait {
+				wait = until
+			}
+			continue
+		}
+
+		job.Status = storage.JobRunning
+		job.UpdatedAt = now
+		if err := storage.SaveJobs(q.dataDir, q.jobs); err != nil {
+			job.Status = storage.JobPending
+			return nil, 0, err
+		}
+		claimed := *job
+		return &claimed, 0, nil
+	}
+
+	return nil, wait, nil
+}
+
+// update saves a job's progress
+func (q *jobQueue) update(job *storage.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(q.jobs, func(j storage.Job) bool { return j.ID == job.ID })
+	if i < 0 {
+		return fmt.Errorf("job not found: %s", job.ID)
+	}
+	job.UpdatedAt = time.Now()
+	q.jobs[i] = *job
+
+	return storage.SaveJobs(q.dataDir, q.jobs)
+}
+
+// fail records a failed attempt, scheduling a retry with backoff unless the error is
+// permanent or the stage has run out of attempts
+func (q *jobQueue) fail(job *storage.Job, err error) error {
+	job.Attempts++
+	job.LastError = err.Error()
+
+	var permErr *permanentError
+	if errors.As(err, &permErr) || job.Attempts >= q.maxAttempts {
+		job.Status = storage.JobFailed
+		job.NextAttempt = time.Time{}
+	} else {
+		job.Status = storage.JobPending
+		job.NextAttempt = time.Now().Add(retryDelay(job.Attempts))
+	}
+
+	return q.update(job)
+}
+
+// complete removes a finished job
+func (q *jobQueue) complete(jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return err
+	}
+	q.jobs = slices.DeleteFunc(q.jobs, func(j storage.Job) bool { return j.ID == jobID })
+
+	return storage.SaveJobs(q.dataDir, q.jobs)
+}
+
+// list returns a copy of every job in the queue
+func (q *jobQueue) list() ([]storage.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(q.jobs), nil
+}
+
+// retry puts failed jobs back in the queue, all of them if jobID is empty,
+// returning how many were requeued
+func (q *jobQueue) retry(jobID string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for i := range q.jobs {
+		job := &q.jobs[i]
+		if job.Status != storage.JobFailed || (jobID != "" && job.ID != jobID) {
+			continue
+		}
+		job.Status = storage.JobPending
+		job.Attempts = 0
+		job.NextAttempt = time.Time{}
+		job.UpdatedAt = time.Now()
+		requeued++
+	}
+	if requeued == 0 {
+		return 0, nil
+	}
+
+	if err := storage.SaveJobs(q.dataDir, q.jobs); err != nil {
+		return 0, err
+	}
+	q.notify()
+
+	return requeued, nil
+}
+
+// clearFailed removes every failed job, returning how many were removed
+func (q *jobQueue) clearFailed() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.load(); err != nil {
+		return 0, err
+	}
+
+	before := len(q.jobs)
+	q.jobs = slices.DeleteFunc(q.jobs, func(j storage.Job) bool { return j.Status == storage.JobFailed })
+	removed := before - len(q.jobs)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	return removed, storage.SaveJobs(q.dataDir, q.jobs)
+}
+
+// imageLocks lets one job at a time collect each image, so two jobs for the same
+// image can't both find it missing and the later save replace the earlier one
+type imageLocks struct {
+	mu   sync.Mutex
+	held map[string]chan struct{} // Image ID → closed when its lock is released
+}
+
+// lock waits until no other job is collecting the image, returning a func that
+// releases it
+func (l *imageLocks) lock(ctx context.Context, imageID string) (func(), error) {
+	for {
+		l.mu.Lock()
+		released, busy := l.held[imageID]
+		if !busy {
+			if l.held == nil {
+				l.held = make(map[string]chan struct{})
+			}
+			released = make(chan struct{})
+			l.held[imageID] = released
+			l.mu.Unlock()
+
+			return func() {
+				l.mu.Lock()
+				delete(l.held, imageID)
+				l.mu.Unlock()
+				close(released)
+			}, nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// startJobWorkers runs n workers until ctx is cancelled
+func (b *Bot) startJobWorkers(ctx context.Context, n int) {
+	n = max(n, 1)
+	log.Printf("Starting %d collection worker(s)", n)
+	for range n {
+		go b.jobWorker(ctx)
+	}
+}
+
+// jobWorker runs queued jobs as they become due
+func (b *Bot) jobWorker(ctx context.Context) {
+	for {
+		job, wait, err := b.jobs.next()
+		if err != nil {
+			log.Printf("Error reading job queue: %v", err)
+			wait = time.Minute
+		}
+		if job != nil {
+			b.runJob(ctx, job)
+			continue
+		}
+
+		// Sleep until a job is due, a new one arrives, or we're stopped
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.jobs.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// runJob runs a claimed job's remaining stages and records how it went
+func (b *Bot) runJob(ctx context.Context, job *storage.Job) {
+	log.Printf("Running %s job %s (stage: %s, attempt %d)", jobKind(job), job.ID, job.Stage, job.Attempts+1)
+
+	var existed bool
+	var err error
+	if job.Kind == storage.JobRegenerate {
+		err = b.runRegenerateStages(ctx, job)
+	} else {
+		existed, err = b.runJobStages(ctx, job)
+	}
+
+	switch {
+	case err == nil:
+		if err := b.jobs.complete(job.ID); err != nil {
+			log.Printf("Warning: failed to remove finished job %s: %v", job.ID, err)
+		}
+		if job.Kind == storage.JobRegenerate {
+			log.Printf("✅ Alt-text regenerated: %s", job.StickerID)
+			b.reportRegenerated(ctx, job)
+			break
+		}
+		log.Printf("✅ Sticker collected successfully: %s", job.StickerID)
+		b.reportCollected(ctx, job, existed)
+
+	case ctx.Err() != nil:
+		// Shutting down isn't the job's fault, so it goes back in the queue as it was
+		job.Status = storage.JobPending
+		if err := b.jobs.update(job); err != nil {
+			log.Printf("Warning: failed to requeue job %s: %v", job.ID, err)
+		}
+
+	default:
+		log.Printf("%s job %s failed at %s: %v", jobKind(job), job.ID, job.Stage, err)
+		if err := b.jobs.fail(job, err); err != nil {
+			log.Printf("Warning: failed to record job %s failure: %v", job.ID, err)
+		}
+		if job.Status == storage.JobFailed {
+			b.reportFailed(ctx, job)
+		}
+	}
+}
+
+// jobKind names what a job does, for logs
+func jobKind(job *storage.Job) string {
+	if job.Kind == storage.JobRegenerate {
+		return "regeneration"
+	}
+	return "collection"
+}
+
+// checkpoint saves a job's progress after a stage succeeds, so a retry resumes after it
+func (b *Bot) checkpoint(job *storage.Job, stage string) {
+	job.Stage = stage
+	job.Attempts = 0
+	job.LastError = ""
+	if err := b.jobs.update(job); err != nil {
+		log.Printf("Warning: failed to save job %s progress: %v", job.ID, err)
+	}
+}
+
+// runJobStages collects the job's sticker, skipping stages whose results were saved
+// by an earlier attempt. Each stage that succeeds is saved before the next one runs.
+// It reports whether the sticker was already in the collection.
+func (b *Bot) runJobStages(ctx context.Context, job *storage.Job) (bool, error) {
+	if job.SourceMXC == "" {
+		job.Stage = stageResolve
+		mxcURI, file, body, err := b.resolveImage(ctx, id.RoomID(job.RoomID), id.EventID(job.EventID))
+		if err != nil {
+			return false, err
+		}
+		job.SourceMXC = string(mxcURI)
+		job.OriginalBody = body
+		if file != nil {
+			if job.File, err = json.Marshal(file); err != nil {
+				return false, permanent(fmt.Errorf("failed to store encrypted file info: %w", err))
+			}
+		}
+		b.checkpoint(job, stageResolve)
+		log.Printf("Collecting sticker: %s (MXC: %s)", body, mxcURI)
+	}
+
+	// The image itself isn't kept between attempts, so it's always downloaded again
+	job.Stage = stageDownload
+	var file *event.EncryptedFileInfo
+	if len(job.File) > 0 {
+		file = &event.EncryptedFileInfo{}
+		if err := json.Unmarshal(job.File, file); err != nil {
+			return false, permanent(fmt.Errorf("failed to read encrypted file info: %w", err))
+		}
+	}
+	image, err := b.fetchImage(ctx, id.ContentURIString(job.SourceMXC), file)
+	if err != nil {
+		return false, err
+	}
+	job.StickerID = image.id
+
+	// Hold the image until it's saved, so another job for it waits and then finds it
+	unlock, err := b.collecting.lock(ctx, image.id)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	// Already collected, so there's nothing to upload or describe
+	if existing, err := b.store.GetSticker(image.id); err == nil {
+		log.Printf("Sticker %s is already in the collection", image.id)
+		if err := b.adoptImportedName(existing, job.Name); err != nil {
+			return true, err
+		}
+		return true, b.fileJobSticker(job)
+	}
+
+	if job.LocalMXC == "" {
+		job.Stage = stageUpload
+		if job.LocalMXC, err = b.rehostImage(ctx, image); err != nil {
+			return false, err
+		}
+		b.checkpoint(job, stageUpload)
+	}
+
+	if job.AltText == "" {
+		job.Stage = stageAltText
+		description, err := b.describeImage(ctx, image, job.OriginalBody, b.config.AltText.PackPrompt(job.Pack))
+		if err != nil {
+			return false, err
+		}
+		job.AltText, job.AltTexts, job.AltTextModel, job.Tags = description.altText, description.altTexts, description.model, description.tags
+		job.Shortcode = description.shortcode
+		b.checkpoint(job, stageAltText)
+	}
+
+	job.Stage = stageSave
+	sticker := newSticker(image, job.LocalMXC, job.AltText, job.OriginalBody)
+	setGeneratedAltText(sticker, job.AltTexts, job.AltTextModel)
+	sticker.Tags = job.Tags
+	sticker.SourceRoom = job.RoomID
+	sticker.SourceEvent = job.EventID
+	sticker.CollectedBy = job.RequestedBy
+	if err := b.suggestShortcode(sticker, job.Shortcode); err != nil {
+		return false, err
+	}
+	if job.Name != "" {
+		sticker.Name = job.Name
+		sticker.SuggestedName = ""
+	}
+	if len(job.Usage) > 0 {
+		sticker.Usage = job.Usage
+	}
+	if err := b.store.AddSticker(*sticker); err != nil {
+		return false, fmt.Errorf("failed to save sticker: %w", err)
+	}
+
+	return false, b.fileJobSticker(job)
+}
+
+// runRegenerateStages describes a job's sticker again and saves the new alt-text as
+// machine-written. Alt-text written by hand is kept unless the job asked to replace it.
+func (b *Bot) runRegenerateStages(ctx context.Context, job *storage.Job) error {
+	sticker, err := b.store.GetSticker(job.StickerID)
+	if errors.Is(err, storage.ErrStickerNotFound) {
+		return permanent(err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load sticker: %w", err)
+	}
+	if sticker.HumanAltText() && !job.ReplaceHuman {
+		log.Printf("Keeping hand-written alt-text for %s", sticker.ID)
+		return nil
+	}
+
+	if job.AltText == "" {
+		job.Stage = stageAltText
+		description, err := b.describeSticker(ctx, sticker, job.Hint)
+		if err != nil {
+			return err
+		}
+		job.AltText, job.AltTexts, job.AltTextModel = description.altText, description.altTexts, description.model
+		b.checkpoint(job, stageAltText)
+	}
+
+	job.Stage = stageSave
+	altText := storage.AltText{
+		Text:      job.AltText,
+		Languages: job.AltTexts,
+		By:        storage.AltTextByModel,
+		Model:     job.AltTextModel,
+	}
+	if err := b.store.UpdateAltText(sticker.ID, altText); err != nil {
+		return fmt.Errorf("failed to save alt-text: %w", err)
+	}
+
+	if note := b.refreshPersonal(sticker.InPacks...); note != "" {
+		log.Printf("Refreshing personal emotes: %s", strings.TrimSpace(note))
+	}
+	return nil
+}
+
+// adoptImportedName gives an already collected sticker the shortcode it has in an
+// imported pack, if it still has its default name and no other sticker in its packs
+// uses the shortcode
+func (b *Bot) adoptImportedName(sticker *storage.Sticker, name string) error {
+	if name == "" || sticker.Shortcode() != sticker.ID {
+		return nil
+	}
+
+	err := b.store.SetStickerName(sticker.ID, name)
+	var conflict *storage.ShortcodeConflict
+	if errors.As(err, &conflict) {
+		log.Printf("Keeping sticker %s's name: %v", sticker.ID, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set sticker name: %w", err)
+	}
+	return nil
+}
+
+// fileJobSticker adds a collected sticker to the pack its reaction asked for,
+// republishing the pack wherever it's published
+func (b *Bot) fileJobSticker(job *storage.Job) error {
+	if job.Pack == "" {
+		return nil
+	}
+	job.Stage = stagePack
+
+	packs, err := b.store.ListPacks()
+	if err != nil {
+		return fmt.Errorf("failed to load packs: %w", err)
+	}
+	i := slices.IndexFunc(packs, func(p storage.Pack) bool { return p.Name == job.Pack })
+	if i < 0 {
+		return permanent(fmt.Errorf("pack not found: %s", job.Pack))
+	}
+
+	if err := b.store.AddToPack(job.Pack, []string{job.StickerID}); err != nil {
+		// Retrying won't free the shortcode
+		var conflict *storage.ShortcodeConflict
+		if errors.As(err, &conflict) {
+			return permanent(fmt.Errorf("failed to add sticker to pack: %w", err))
+		}
+		return fmt.Errorf("failed to add sticker to pack: %w", err)
+	}
+	log.Printf("Added sticker %s to pack %s", job.StickerID, job.Pack)
+
+	// An imported pack's avatar is one of its own images until that's rehosted
+	if pack := packs[i]; pack.AvatarURL != "" && pack.AvatarURL == job.SourceMXC {
+		sticker, err := b.store.GetSticker(job.StickerID)
+		if err != nil {
+			return fmt.Errorf("failed to load sticker: %w", err)
+		}
+		if err := b.store.SetPackAvatar(job.Pack, sticker.LocalMXC); err != nil {
+			return fmt.Errorf("failed to set pack avatar: %w", err)
+		}
+	}
+
+	if pack := packs[i]; len(pack.PublishedRooms) > 0 || pack.PublishedPersonal {
+		log.Printf("Republishing pack %s: %s", job.Pack, firstLine(b.packPublish(job.Pack, "")))
+	}
+
+	return nil
+}
+
+// RunJobs runs queued jobs one at a time until none are due, for commands that use
+// the collection pipeline without starting the bot. It returns the jobs left in the
+// queue, which failed or are waiting to retry.
+func (b *Bot) RunJobs(ctx context.Context) ([]storage.Job, error) {
+	for {
+		job, _, err := b.jobs.next()
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			return b.jobs.list()
+		}
+		b.runJob(ctx, job)
+	}
+}
+
+// listJobs shows queued and failed collection jobs
+func (b *Bot) listJobs() string {
+	jobs, err := b.jobs.list()
+	if err != nil {
+		return fmt.Sprintf("❌ Failed to load jobs: %v", err)
+	}
+	if len(jobs) == 0 {
+		return "✅ No collection jobs queued or failed"
+	}
+
+	var queued, failed []storage.Job
+	for _, job := range jobs {
+		if job.Status == storage.JobFailed {
+			failed = append(failed, job)
+		} else {
+			queued = append(queued, job)
+		}
+	}
+
+	var result strings.Builder
+	if len(queued) > 0 {
+		result.WriteString(fmt.Sprintf("Queued jobs (%d):\n\n", len(queued)))
+		for _, job := range queued {
+			result.WriteString(fmt.Sprintf("- `%s` %s", job.ID, job.Status))
+			if job.Stage != "" {
+				result.WriteString(fmt.Sprintf(" after %s", job.Stage))
+			}
+			if job.LastError != "" {
+				result.WriteString(fmt.Sprintf(", attempt %d failed: %s", job.Attempts, job.LastError))
+			}
+			if !job.NextAttempt.IsZero() && job.Status == storage.JobPending {
+				result.WriteString(fmt.Sprintf(" (retrying in %s)", time.Until(job.NextAttempt).Round(time.Second)))
+			}
+			result.WriteString("\n")
+		}
+	}
+
+	if len(failed) > 0 {
+		if len(queued) > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(fmt.Sprintf("Failed jobs (%d):\n\n", len(failed)))
+		for _, job := range failed {
+			source := fmt.Sprintf("from %s in %s", job.RequestedBy, job.RoomID)
+			if job.Kind == storage.JobRegenerate {
+				source = fmt.Sprintf("regenerating `%s` for %s", job.StickerID, job.RequestedBy)
+			}
+			result.WriteString(fmt.Sprintf("- `%s` at %s (%s): %s\n", job.ID, job.Stage, source, job.LastError))
+		}
+		result.WriteString("\nUse `!sticker jobs retry [id]` to try again or `!sticker jobs clear` to discard them")
+	}
+
+	return result.String()
+}
+
+// retryJobs requeues one failed job, or all of them if jobID is empty
+func (b *Bot) retryJobs(jobID string) string {
+	requeued, err := b.jobs.retry(jobID)
+	if err != nil {
+		return fmt.Sprintf("❌ Failed to retry jobs: %v", err)
+	}
+	if requeued == 0 {
+		if jobID != "" {
+			return fmt.Sprintf("❌ No failed job with ID %s", jobID)
+		}
+		return "✅ No failed jobs to retry"
+	}
+	return fmt.Sprintf("✅ Requeued %d job(s)", requeued)
+}
+
+// clearJobs discards every failed job
+func (b *Bot) clearJobs() string {
+	removed, err := b.jobs.clearFailed()
+	if err != nil {
+		return fmt.Sprintf("❌ Failed to clear jobs: %v", err)
+	}
+	return fmt.Sprintf("✅ Cleared %d failed job(s)", removed)
+}
@@ -0,0 +1,252 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/event"
+)
+
+// TestRetryDelay verifies retry delays double up to the maximum
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{50, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.expected {
+			t.Errorf("retryDelay(%d) = %s, expected %s", tt.attempts, got, tt.expected)
+		}
+	}
+}
+
+// TestImageLocks verifies a second job for an image waits for the first to finish
+func TestImageLocks(t *testing.T) {
+	var locks imageLocks
+	unlock, err := locks.lock(context.Background(), "abc123")
+	if err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+
+	// Other images aren't held up
+	unlockOther, err := locks.lock(context.Background(), "def456")
+	if err != nil {
+		t.Fatalf("Failed to lock another image: %v", err)
+	}
+	unlockOther()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locks.lock(ctx, "abc123"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected to wait for the held image, got %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		unlockSecond, err := locks.lock(context.Background(), "abc123")
+		if err == nil {
+			unlockSecond()
+		}
+		close(acquired)
+	}()
+	unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the waiting job to get the image once released")
+	}
+}
+
+// TestJobQueue_Lifecycle verifies jobs are claimed, retried with backoff, and survive a restart
+func TestJobQueue_Lifecycle(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stickerbook-jobs-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	queue := newJobQueue(tmpDir, 2)
+	if err := queue.enqueue(storage.Job{ID: "job1", EventID: "$image1"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if err := queue.enqueue(storage.Job{ID: "job1", EventID: "$image1"}); err != nil {
+		t.Fatalf("Failed to enqueue duplicate: %v", err)
+	}
+
+	job, _, err := queue.next()
+	if err != nil || job == nil {
+		t.Fatalf("Expected to claim a job, got %v, %v", job, err)
+	}
+	if job.Status != storage.JobRunning {
+		t.Errorf("Expected claimed job to be running, got %s", job.Status)
+	}
+	if other, _, _ := queue.next(); other != nil {
+		t.Error("Expected the duplicate to be ignored and the running job not claimed twice")
+	}
+
+	// A restart puts a job that was running back in the queue
+	queue.close()
+	restarted := newJobQueue(tmpDir, 2)
+	job, _, err = restarted.next()
+	if err != nil || job == nil {
+		t.Fatalf("Expected running job to be requeued after restart, got %v, %v", job, err)
+	}
+
+	// A transient failure schedules a retry
+	job.Stage = stageAltText
+	if err := restarted.fail(job, errors.New("API overloaded")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	if job.Status != storage.JobPending || job.NextAttempt.IsZero() {
+		t.Errorf("Expected job to wait for a retry, got %+v", job)
+	}
+	claimed, wait, err := restarted.next()
+	if err != nil || claimed != nil || wait <= 0 {
+		t.Errorf("Expected nothing due yet with a wait, got %v, %s, %v", claimed, wait, err)
+	}
+
+	// Running out of attempts fails the job
+	if err := restarted.fail(job, errors.New("API overloaded")); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	if job.Status != storage.JobFailed {
+		t.Errorf("Expected job to fail after max attempts, got %s", job.Status)
+	}
+
+	requeued, err := restarted.retry("job1")
+	if err != nil || requeued != 1 {
+		t.Fatalf("Expected one job requeued, got %d, %v", requeued, err)
+	}
+	job, _, _ = restarted.next()
+	if job == nil || job.Stage != stageAltText {
+		t.Errorf("Expected retried job to be due and keep its stage, got %+v", job)
+	}
+
+	// A permanent failure isn't retried
+	if err := restarted.fail(job, permanent(errors.New("not an image"))); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	if job.Status != storage.JobFailed {
+		t.Errorf("Expected permanent error to fail the job, got %s", job.Status)
+	}
+
+	removed, err := restarted.clearFailed()
+	if err != nil || removed != 1 {
+		t.Errorf("Expected one failed job cleared, got %d, %v", removed, err)
+	}
+	jobs, _ := restarted.list()
+	if len(jobs) != 0 {
+		t.Errorf("Expected empty queue, got %d jobs", len(jobs))
+	}
+}
+
+// TestProcessReaction_QueuesJob verifies a !yoink is queued rather than collected inline
+func TestProcessReaction_QueuesJob(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	evt := &event.Event{
+		ID:     "$reaction1",
+		RoomID: "!room:matrix.org",
+		Sender: "@test:matrix.org",
+		Type:   event.EventReaction,
+		Content: event.Content{
+			Parsed: &event.ReactionEventContent{
+				RelatesTo: event.RelatesTo{Type: event.RelAnnotation, EventID: "$image1", Key: "!yoink"},
+			},
+		},
+	}
+
+	if err := bot.processReaction(context.Background(), evt); err != nil {
+		t.Fatalf("Failed to process reaction: %v", err)
+	}
+
+	jobs, err := bot.jobs.list()
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].EventID != "$image1" || jobs[0].RequestedBy != "@test:matrix.org" {
+		t.Fatalf("Expected one queued job for $image1, got %+v", jobs)
+	}
+
+	entry, err := bot.journal.Get("$reaction1")
+	if err != nil || entry == nil {
+		t.Errorf("Expected reaction to be journaled, got %v, %v", entry, err)
+	}
+}
+
+// TestRunJob_PermanentFailure verifies a reaction to a missing event fails without retries
+func TestRunJob_PermanentFailure(t *testing.T) {
+	bot, _ := setupServerBot(t, serverBotOptions{})
+
+	if err := bot.jobs.enqueue(storage.Job{ID: "job1", RoomID: "!room:matrix.org", EventID: "$missing"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	job, _, _ := bot.jobs.next()
+	if job == nil {
+		t.Fatal("Expected to claim the job")
+	}
+
+	bot.runJob(context.Background(), job)
+
+	jobs, _ := bot.jobs.list()
+	if len(jobs) != 1 || jobs[0].Status != storage.JobFailed || jobs[0].Stage != stageResolve {
+		t.Fatalf("Expected job failed at resolve, got %+v", jobs)
+	}
+
+	result := bot.executeCommand(context.Background(), bot.client.UserID, "!sticker jobs")
+	if !strings.Contains(result, "Failed jobs (1)") || !strings.Contains(result, "job1") {
+		t.Errorf("Expected failed job in jobs list, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), bot.client.UserID, "!sticker jobs clear")
+	if !strings.Contains(result, "Cleared 1") {
+		t.Errorf("Expected failed job cleared, got: %s", result)
+	}
+}
+
+// TestFileJobSticker verifies a job files its sticker into the pack its reaction named
+func TestFileJobSticker(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	if err := bot.store.AddSticker(storage.Sticker{ID: "abc123", Name: "abc123", InPacks: []string{}}); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+
+	job := &storage.Job{ID: "job1", StickerID: "abc123", Pack: "cats"}
+	err := bot.fileJobSticker(job)
+	var permErr *permanentError
+	if !errors.As(err, &permErr) || job.Stage != stagePack {
+		t.Fatalf("Expected permanent failure for a missing pack, got %v at %s", err, job.Stage)
+	}
+
+	if err := bot.store.CreatePack("cats", "Cats", ""); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	if err := bot.fileJobSticker(job); err != nil {
+		t.Fatalf("Failed to file sticker: %v", err)
+	}
+
+	pack, err := bot.store.GetPack("cats")
+	if err != nil {
+		t.Fatalf("Failed to load pack: %v", err)
+	}
+	if len(pack.StickerIDs) != 1 || pack.StickerIDs[0] != "abc123" {
+		t.Errorf("Expected sticker in pack, got %v", pack.StickerIDs)
+	}
+}
@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// Listing page sizes
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Gallery layout
+const (
+	galleryColumns   = 5  // Thumbnails per row
+	galleryThumbSize = 96 // Thumbnail height in pixels
+)
+
+// page is the slice of a long listing to show, and how to show it
+type page struct {
+	number  int  // 1-based page number
+	limit   int  // Items per page
+	gallery bool // Show stickers as a grid of thumbnails rather than a list
+}
+
+// parsePage takes --page N, --limit N and --gallery out of a command's arguments,
+// returning the page and the remaining arguments
+func parsePage(args []string) (page, []string, error) {
+	p := page{number: 1, limit: defaultPageSize}
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--gallery" {
+			p.gallery = true
+			continue
+		}
+
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if flag != "--page" && flag != "--limit" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return p, nil, fmt.Errorf("%s needs a number", flag)
+			}
+			i++
+			value = args[i]
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return p, nil, fmt.Errorf("%s needs a positive number, got %s", flag, value)
+		}
+		if flag == "--page" {
+			p.number = n
+		} else {
+			p.limit = min(n, maxPageSize)
+		}
+	}
+
+	return p, rest, nil
+}
+
+// bounds returns the range of items on the page, clamped to total
+func (p page) bounds(total int) (start, end int) {
+	start = min((p.number-1)*p.limit, total)
+	end = min(start+p.limit, total)
+	return start, end
+}
+
+// pages returns how many pages total items fill
+func (p page) pages(total int) int {
+	return max(1, (total+p.limit-1)/p.limit)
+}
+
+// check returns an error if the page is past the end of a listing of total items
+func (p page) check(total int) error {
+	if start, end := p.bounds(total); start == end && total > 0 {
+		return fmt.Errorf("page %d is past the end of the list (%d page(s))", p.number, p.pages(total))
+	}
+	return nil
+}
+
+// footer describes where the page sits in the listing and how to get the next one.
+// command is the listing command without its paging flags.
+func (p page) footer(total int, command string) string {
+	pages := p.pages(total)
+	if pages == 1 {
+		return ""
+	}
+
+	footer := fmt.Sprintf("\nPage %d of %d (%d total)", p.number, pages, total)
+	if p.number < pages {
+		next := fmt.Sprintf("%s --page %d", command, p.number+1)
+		if p.limit != defaultPageSize {
+			next += fmt.Sprintf(" --limit %d", p.limit)
+		}
+		if p.gallery {
+			next += " --gallery"
+		}
+		footer += fmt.Sprintf(" - next: `%s`", next)
+	}
+	return footer
+}
+
+// showStickers shows one page of a sticker listing, numbered from the start of the
+// whole listing. The whole listing is remembered, so a number picks the same sticker
+// whichever page it was seen on. line formats one list entry; gallery mode ignores it.
+func (b *Bot) showStickers(sender id.UserID, stickers []storage.Sticker, p page, command string, line func(n int, sticker storage.Sticker) string) string {
+	if err := p.check(len(stickers)); err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	b.rememberListing(sender, stickers)
+
+	var result strings.Builder
+	start, end := p.bounds(len(stickers))
+
+	if p.gallery {
+		result.WriteString(gallery(stickers[start:end], start+1))
+	} else {
+		for i := start; i < end; i++ {
+			result.WriteString(line(i+1, stickers[i]))
+		}
+	}
+	result.WriteString(p.footer(len(stickers), command))
+
+	return result.String()
+}
+
+// stickerLine is the usual list entry for a sticker: number, ID, shortcode and alt-text
+func stickerLine(n int, sticker storage.Sticker) string {
+	altText := sticker.GeneratedAltText
+	if altText == "" {
+		altText = "(no alt-text)"
+	}
+
+	// Use code formatting for ID, proper markdown ordered list
+	return fmt.Sprintf("%d. `%s` (:%s:) - %s\n", n, sticker.ID, sticker.Name, altText)
+}
+
+// gallery renders stickers as rows of numbered inline thumbnails. The <img> tags pass
+// through markdownToHTML into formatted_body; plainBody swaps them back to shortcodes.
+func gallery(stickers []storage.Sticker, first int) string {
+	var result strings.Builder
+
+	for i, sticker := range stickers {
+		if i > 0 && i%galleryColumns == 0 {
+			result.WriteString("\n\n")
+		} else if i > 0 {
+			result.WriteString(" ")
+		}
+
+		altText := sticker.GeneratedAltText
+		if altText == "" {
+			altText = sticker.OriginalBody
+		}
+		result.WriteString(fmt.Sprintf(`**%d.** <img src="%s" alt="%s" title=":%s:" height="%d">`,
+			first+i, html.EscapeString(sticker.LocalMXC), html.EscapeString(altText),
+			html.EscapeString(sticker.Name), galleryThumbSize))
+	}
+	result.WriteString("\n")
+
+	return result.String()
+}
+
+// galleryImage matches a gallery thumbnail, capturing its title
+var galleryImage = regexp.MustCompile(`<img [^>]*title="([^"]*)"[^>]*>`)
+
+// plainBody turns a command result into the plain-text body of a message, replacing
+// gallery thumbnails with their shortcodes
+func plainBody(text string) string {
+	return galleryImage.ReplaceAllStringFunc(text, func(tag string) string {
+		return html.UnescapeString(galleryImage.FindStringSubmatch(tag)[1])
+	})
+}
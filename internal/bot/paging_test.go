@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// TestParsePage verifies paging flags are taken out of the arguments
+func TestParsePage(t *testing.T) {
+	p, rest, err := parsePage([]string{"show", "--page", "3", "cats", "--limit=500", "--gallery"})
+	if err != nil {
+		t.Fatalf("Failed to parse page: %v", err)
+	}
+	if p.number != 3 || p.limit != maxPageSize || !p.gallery {
+		t.Errorf("Unexpected page: %+v", p)
+	}
+	if strings.Join(rest, " ") != "show cats" {
+		t.Errorf("Expected remaining args [show cats], got %v", rest)
+	}
+
+	for _, args := range [][]string{{"--page"}, {"--page", "0"}, {"--limit=lots"}} {
+		if _, _, err := parsePage(args); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+
+	footer := page{number: 1, limit: 10, gallery: true}.footer(25, "!sticker list unsorted")
+	if !strings.Contains(footer, "`!sticker list unsorted --page 2 --limit 10 --gallery`") {
+		t.Errorf("Expected next page to keep the limit and gallery, got: %s", footer)
+	}
+}
+
+// TestGallery verifies thumbnails render as inline images in rows, and as shortcodes in plain text
+func TestGallery(t *testing.T) {
+	var stickers []storage.Sticker
+	for i := range 7 {
+		stickers = append(stickers, storage.Sticker{
+			ID:               fmt.Sprintf("id%d", i),
+			Name:             fmt.Sprintf("cat_%d", i),
+			LocalMXC:         fmt.Sprintf("mxc://example.org/cat%d", i),
+			GeneratedAltText: `A "cat" <waving>`,
+		})
+	}
+
+	text := gallery(stickers, 21)
+
+	// Five to a row, numbered from the start of the listing
+	rows := strings.Split(strings.TrimSpace(text), "\n\n")
+	if len(rows) != 2 || strings.Count(rows[0], "<img") != galleryColumns {
+		t.Errorf("Expected rows of %d thumbnails, got: %s", galleryColumns, text)
+	}
+	if !strings.HasPrefix(text, "**21.**") || !strings.Contains(text, "**27.**") {
+		t.Errorf("Expected numbering from 21, got: %s", text)
+	}
+
+	html := markdownToHTML(text)
+	if !strings.Contains(html, `<img src="mxc://example.org/cat0" alt="A &#34;cat&#34; &lt;waving&gt;" title=":cat_0:" height="96">`) {
+		t.Errorf("Expected escaped inline thumbnail in HTML, got: %s", html)
+	}
+
+	plain := plainBody(text)
+	if strings.Contains(plain, "<img") || !strings.Contains(plain, "**21.** :cat_0:") {
+		t.Errorf("Expected shortcodes in plain body, got: %s", plain)
+	}
+}
+
+// TestExecuteCommand_ListPaging verifies listings page with stable numbering
+func TestExecuteCommand_ListPaging(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	if err := bot.store.CreatePack("cats", "Cats", ""); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	var ids []string
+	for i := range 30 {
+		sticker := storage.Sticker{ID: fmt.Sprintf("cat%05d", i), Name: fmt.Sprintf("cat_%d", i), InPacks: []string{}}
+		if err := bot.store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+		ids = append(ids, sticker.ID)
+	}
+
+	ctx := context.Background()
+	user := bot.client.UserID
+
+	result := bot.executeCommand(ctx, user, "!sticker list unsorted --page 2")
+	if !strings.Contains(result, "21. `cat00020`") || strings.Contains(result, "cat00019") || !strings.Contains(result, "Page 2 of 2") {
+		t.Errorf("Expected second page of unsorted stickers, got: %s", result)
+	}
+
+	// Numbers refer to the whole listing, whichever page was shown
+	if stickerID, err := bot.resolveSticker(user, "3"); err != nil || stickerID != "cat00002" {
+		t.Errorf("Expected listing position 3 to be cat00002, got %s, %v", stickerID, err)
+	}
+
+	if err := bot.store.AddToPack("cats", ids); err != nil {
+		t.Fatalf("Failed to add to pack: %v", err)
+	}
+	result = bot.executeCommand(ctx, user, "!sticker pack show cats --limit 10 --gallery")
+	if strings.Count(result, "<img") != 10 || !strings.Contains(result, "`!sticker pack show cats --page 2 --limit 10 --gallery`") {
+		t.Errorf("Expected a gallery page of 10, got: %s", result)
+	}
+
+	result = bot.executeCommand(ctx, user, "!sticker pack show cats --page 9")
+	if !strings.Contains(result, "❌") {
+		t.Errorf("Expected error past the last page, got: %s", result)
+	}
+
+	result = bot.executeCommand(ctx, user, "!sticker pack list --limit 1")
+	if !strings.Contains(result, "unsorted (0)") || strings.Contains(result, "cats") || !strings.Contains(result, "Page 1 of 2") {
+		t.Errorf("Expected first page of packs, got: %s", result)
+	}
+}
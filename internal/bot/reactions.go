@@ -2,24 +2,23 @@ package bot
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/llm"
 	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
 	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 )
 
-// validCommands are the reaction commands we respond to
-var validCommands = map[string]bool{
-	"!yoink": true,
-	"!nom":   true,
-	"!grab":  true,
-}
-
 // processReaction handles a reaction event and collects the sticker if appropriate
 func (b *Bot) processReaction(ctx context.Context, evt *event.Event) error {
 	// Parse reaction content
@@ -30,108 +29,219 @@ func (b *Bot) processReaction(ctx context.Context, evt *event.Event) error {
 
 	// Check if this is one of our collection commands
 	reaction := content.RelatesTo.Key
-	if !validCommands[reaction] {
+	pack, ok := b.collectPack(reaction)
+	if !ok {
 		return nil // Not a collection command, ignore
 	}
 
+	if b.alreadyProcessed(evt) {
+		return nil
+	}
+
 	log.Printf("Detected %s command from %s in room %s", reaction, evt.Sender, evt.RoomID)
 
-	// Get the parent event that was reacted to
-	parentEventID := content.RelatesTo.EventID
-	parentEvent, err := b.client.GetEvent(ctx, evt.RoomID, parentEventID)
-	if err != nil {
-		return fmt.Errorf("failed to get parent event: %w", err)
+	// Retrying won't create the pack, so say so now rather than once the job fails
+	if pack != "" {
+		packs, err := b.store.ListPacks()
+		if err != nil {
+			return fmt.Errorf("failed to load packs: %w", err)
+		}
+		if !slices.ContainsFunc(packs, func(p storage.Pack) bool { return p.Name == pack }) {
+			log.Printf("Not collecting %s: pack not found: %s", content.RelatesTo.EventID, pack)
+			b.reportMissingPack(ctx, evt, content.RelatesTo.EventID, pack)
+			b.recordProcessed(evt, storage.EventKindReaction, "pack not found: "+pack)
+			return nil
+		}
 	}
 
-	// Extract image data from parent event
-	mxcURI, body, err := b.extractImageData(parentEvent)
-	if err != nil {
-		return fmt.Errorf("parent event is not a valid image/sticker: %w", err)
+	// Collection runs in the background so a slow homeserver or API doesn't hold up sync
+	job := storage.Job{
+		ID:          jobID(evt.ID.String()),
+		ReactionID:  evt.ID.String(),
+		RoomID:      evt.RoomID.String(),
+		EventID:     content.RelatesTo.EventID.String(),
+		RequestedBy: evt.Sender.String(),
+		Pack:        pack,
+	}
+	if err := b.jobs.enqueue(job); err != nil {
+		return fmt.Errorf("failed to queue collection: %w", err)
 	}
 
-	log.Printf("Collecting sticker: %s (MXC: %s)", body, mxcURI)
+	log.Printf("Queued collection job %s for %s", job.ID, job.EventID)
+	b.recordProcessed(evt, storage.EventKindReaction, "queued as job "+job.ID)
 
-	// Run the collection workflow
-	if err := b.collectSticker(ctx, evt.RoomID, parentEventID, mxcURI, body); err != nil {
-		return fmt.Errorf("failed to collect sticker: %w", err)
+	return nil
+}
+
+// collectReactions returns the reactions that collect a sticker
+func (b *Bot) collectReactions() []string {
+	if len(b.config.Collection.Reactions) == 0 {
+		return config.DefaultReactions
 	}
+	return b.config.Collection.Reactions
+}
 
-	// Redact the reaction to confirm collection (cleaner timeline)
-	if err := b.redactReaction(ctx, evt.RoomID, evt.ID); err != nil {
-		log.Printf("Warning: failed to redact reaction: %v", err)
+// collectPack reports whether a reaction collects a sticker, and the pack it should be
+// filed into ("" for unsorted). Shortcuts collect into their pack, and any collect
+// reaction can name a pack after a colon, e.g. !yoink:cats.
+func (b *Bot) collectPack(key string) (string, bool) {
+	for _, shortcut := range b.config.Collection.Shortcuts {
+		if key == shortcut.Key {
+			return sanitizePackName(shortcut.Pack), true
+		}
 	}
 
-	return nil
+	for _, reaction := range b.collectReactions() {
+		if key == reaction {
+			return "", true
+		}
+		if pack, ok := strings.CutPrefix(key, reaction+":"); ok && pack != "" {
+			if pack = sanitizePackName(pack); pack == "unsorted" {
+				return "", true
+			}
+			return pack, true
+		}
+	}
+
+	return "", false
+}
+
+// resolveImage fetches the event a reaction was placed on and finds its image
+func (b *Bot) resolveImage(ctx context.Context, roomID id.RoomID, eventID id.EventID) (mxcURI id.ContentURIString, file *event.EncryptedFileInfo, body string, err error) {
+	parentEvent, err := b.client.GetEvent(ctx, roomID, eventID)
+	if err != nil {
+		if errors.Is(err, mautrix.MNotFound) || errors.Is(err, mautrix.MForbidden) {
+			return "", nil, "", permanent(fmt.Errorf("failed to get parent event: %w", err))
+		}
+		return "", nil, "", fmt.Errorf("failed to get parent event: %w", err)
+	}
+
+	// Images in encrypted rooms come back from /event still encrypted
+	parentEvent, err = b.client.DecryptEvent(ctx, parentEvent)
+	if err != nil {
+		return "", nil, "", fmt.Errorf("failed to decrypt parent event: %w", err)
+	}
+
+	// Extract image data from parent event
+	mxcURI, file, body, err = b.extractImageData(parentEvent)
+	if err != nil {
+		return "", nil, "", permanent(fmt.Errorf("parent event is not a valid image/sticker: %w", err))
+	}
+
+	return mxcURI, file, body, nil
 }
 
-// extractImageData extracts the MXC URI and body text from an image or sticker event
-func (b *Bot) extractImageData(evt *event.Event) (mxcURI id.ContentURIString, body string, err error) {
+// extractImageData extracts the MXC URI and body text from an image or sticker event.
+// Images sent in encrypted rooms also return the file info needed to decrypt them.
+func (b *Bot) extractImageData(evt *event.Event) (mxcURI id.ContentURIString, file *event.EncryptedFileInfo, body string, err error) {
 	// Handle both m.sticker and m.room.message (with msgtype=m.image)
 	switch evt.Type {
 	case event.EventSticker:
 		// m.sticker events might not be parsed - try parsed first, fall back to raw
 		if content, ok := evt.Content.Parsed.(*event.MessageEventContent); ok {
-			return content.URL, content.Body, nil
+			if content.File != nil {
+				return content.File.URL, content.File, content.Body, nil
+			}
+			return content.URL, nil, content.Body, nil
 		}
 
 		// Fall back to raw content access
+		body, _ := evt.Content.Raw["body"].(string)
+		if file := rawEncryptedFile(evt.Content.Raw); file != nil {
+			return file.URL, file, body, nil
+		}
+
 		url, ok := evt.Content.Raw["url"].(string)
 		if !ok {
-			return "", "", fmt.Errorf("sticker missing url field")
+			return "", nil, "", fmt.Errorf("sticker missing url field")
 		}
 
-		body, _ := evt.Content.Raw["body"].(string)
-		return id.ContentURIString(url), body, nil
+		return id.ContentURIString(url), nil, body, nil
 
 	case event.EventMessage:
 		// Try parsed content first
 		if content, ok := evt.Content.Parsed.(*event.MessageEventContent); ok {
 			if content.MsgType != event.MsgImage {
-				return "", "", fmt.Errorf("message is not an image (msgtype=%s)", content.MsgType)
+				return "", nil, "", fmt.Errorf("message is not an image (msgtype=%s)", content.MsgType)
+			}
+			if content.File != nil {
+				return content.File.URL, content.File, content.Body, nil
 			}
-			return content.URL, content.Body, nil
+			return content.URL, nil, content.Body, nil
 		}
 
 		// Fall back to raw content access
 		msgtype, ok := evt.Content.Raw["msgtype"].(string)
 		if !ok || msgtype != "m.image" {
-			return "", "", fmt.Errorf("message is not an image (msgtype=%s)", msgtype)
+			return "", nil, "", fmt.Errorf("message is not an image (msgtype=%s)", msgtype)
+		}
+
+		body, _ := evt.Content.Raw["body"].(string)
+		if file := rawEncryptedFile(evt.Content.Raw); file != nil {
+			return file.URL, file, body, nil
 		}
 
 		url, ok := evt.Content.Raw["url"].(string)
 		if !ok {
-			return "", "", fmt.Errorf("message missing url field")
+			return "", nil, "", fmt.Errorf("message missing url field")
 		}
 
-		body, _ := evt.Content.Raw["body"].(string)
-		return id.ContentURIString(url), body, nil
+		return id.ContentURIString(url), nil, body, nil
 
 	default:
-		return "", "", fmt.Errorf("unsupported event type: %s", evt.Type.Type)
+		return "", nil, "", fmt.Errorf("unsupported event type: %s", evt.Type.Type)
 	}
 }
 
-// collectSticker downloads, rehosts, generates alt-text, and saves a sticker
-func (b *Bot) collectSticker(ctx context.Context, roomID id.RoomID, eventID id.EventID, mxcURI id.ContentURIString, originalBody string) error {
-	// Check if media is already on our homeserver
-	parsedMXC, err := mxcURI.Parse()
+// rawEncryptedFile reads the encrypted file info from unparsed content, if present
+func rawEncryptedFile(raw map[string]interface{}) *event.EncryptedFileInfo {
+	fileData, ok := raw["file"]
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(fileData)
 	if err != nil {
-		return fmt.Errorf("invalid MXC URI: %w", err)
+		return nil
+	}
+
+	var file event.EncryptedFileInfo
+	if err := json.Unmarshal(data, &file); err != nil || file.URL == "" {
+		return nil
 	}
 
-	localMXC := string(mxcURI)
-	needsRehost := parsedMXC.Homeserver != b.client.UserID.Homeserver()
+	return &file
+}
+
+// fetchedImage is a downloaded image waiting to be turned into a sticker
+type fetchedImage struct {
+	mxcURI    id.ContentURIString
+	data      []byte
+	info      *matrix.ImageInfo
+	id        string // SHA256 hash, used as the sticker ID
+	encrypted bool   // Source media is encrypted, so it must always be re-uploaded
+}
 
+// fetchImage downloads an image and works out its info and sticker ID.
+// If file is set the media is encrypted and is decrypted after download.
+func (b *Bot) fetchImage(ctx context.Context, mxcURI id.ContentURIString, file *event.EncryptedFileInfo) (*fetchedImage, error) {
 	// Download image from source MXC URI
-	imageData, detectedMimeType, err := b.client.DownloadMedia(ctx, string(mxcURI))
+	var imageData []byte
+	var detectedMimeType string
+	var err error
+	if file != nil {
+		imageData, detectedMimeType, err = b.client.DownloadEncryptedMedia(ctx, file)
+	} else {
+		imageData, detectedMimeType, err = b.client.DownloadMedia(ctx, string(mxcURI))
+	}
 	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+		return nil, fmt.Errorf("download failed: %w", err)
 	}
 
 	// Get image info (dimensions, MIME type, size)
 	imageInfo, err := matrix.GetImageInfo(imageData)
 	if err != nil {
-		return fmt.Errorf("failed to get image info: %w", err)
+		return nil, permanent(fmt.Errorf("failed to get image info: %w", err))
 	}
 
 	// Use detected MIME type from download if GetImageInfo didn't detect it properly
@@ -145,57 +255,143 @@ func (b *Bot) collectSticker(ctx context.Context, roomID id.RoomID, eventID id.E
 	log.Printf("Image info: %dx%d, %s, %d bytes, ID=%s",
 		imageInfo.Width, imageInfo.Height, imageInfo.MimeType, imageInfo.SizeBytes, stickerID)
 
-	// Upload to local homeserver if needed (rehost)
-	if needsRehost {
-		localMXC, err = b.client.UploadMedia(ctx, imageData, imageInfo.MimeType)
+	return &fetchedImage{
+		mxcURI:    mxcURI,
+		data:      imageData,
+		info:      imageInfo,
+		id:        stickerID,
+		encrypted: file != nil,
+	}, nil
+}
+
+// rehostImage uploads a fetched image to our homeserver unless it's already there,
+// returning the MXC URI to use for it
+func (b *Bot) rehostImage(ctx context.Context, image *fetchedImage) (string, error) {
+	// Check if media is already on our homeserver
+	parsedMXC, err := image.mxcURI.Parse()
+	if err != nil {
+		return "", fmt.Errorf("invalid MXC URI: %w", err)
+	}
+
+	// Encrypted media holds ciphertext, which packs can't use even on our own homeserver
+	if !image.encrypted && parsedMXC.Homeserver == b.client.UserID.Homeserver() {
+		log.Printf("Already on local homeserver: %s", image.mxcURI)
+		return string(image.mxcURI), nil
+	}
+
+	// Upload to local homeserver (rehost)
+	localMXC, err := b.client.UploadMedia(ctx, image.data, image.info.MimeType)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	log.Printf("Rehosted: %s → %s", image.mxcURI, localMXC)
+
+	return localMXC, nil
+}
+
+// imageDescription is what describeImage makes of an image
+type imageDescription struct {
+	altText   string            // Alt-text in the main language, or the original description
+	altTexts  map[string]string // Generated alt-text by language
+	model     string            // Model that wrote altTexts, empty if it wrote none
+	tags      []string          // Valid suggested tags
+	shortcode string            // Valid suggested shortcode, not yet checked for uniqueness
+}
+
+// describeImage generates alt-text in each configured language, suggested tags and a
+// suggested shortcode for a fetched image with the configured provider, following a
+// describing prompt (empty for the built-in one). It's one request: the model
+// describes the image in the main language and translates that into the others.
+// Suggestions that aren't valid are dropped, and a missing translation falls back to
+// the main language. If the provider gives no alt-text (as with "none") the sticker
+// keeps originalBody.
+func (b *Bot) describeImage(ctx context.Context, image *fetchedImage, originalBody string, prompt string) (*imageDescription, error) {
+	languages := b.config.AltText.LanguageCodes()
+	description, err := b.llmClient.Describe(ctx, image.data, image.info.MimeType, llm.BuildPrompt(prompt, languages...))
+	if err != nil {
+		return nil, fmt.Errorf("alt-text generation failed: %w", err)
+	}
+
+	result := &imageDescription{altText: cleanAltText(description.AltText)}
+	if result.altText != "" {
+		result.altTexts = map[string]string{languages[0]: result.altText}
+		log.Printf("Generated alt-text (%s): %s", languages[0], result.altText)
+
+		for _, language := range languages[1:] {
+			translation := cleanAltText(description.Translations[strings.ToLower(language)])
+			if translation == "" {
+				log.Printf("Warning: no %s translation of the alt-text", language)
+				continue
+			}
+			result.altTexts[language] = translation
+			log.Printf("Generated alt-text (%s): %s", language, translation)
+		}
+		result.model = b.llmClient.Model()
+	}
+
+	for _, suggested := range description.Tags {
+		tag, err := storage.NormalizeTag(suggested)
 		if err != nil {
-			return fmt.Errorf("upload failed: %w", err)
+			log.Printf("Ignoring suggested tag %q: %v", suggested, err)
+			continue
+		}
+		if !slices.Contains(result.tags, tag) {
+			result.tags = append(result.tags, tag)
+		}
+	}
+	if description.Shortcode != "" {
+		if err := storage.ValidateShortcode(description.Shortcode); err != nil {
+			log.Printf("Ignoring suggested shortcode %q: %v", description.Shortcode, err)
+		} else {
+			result.shortcode = description.Shortcode
 		}
-		log.Printf("Rehosted: %s → %s", mxcURI, localMXC)
-	} else {
-		log.Printf("Already on local homeserver: %s", mxcURI)
 	}
 
-	// Generate alt-text using Claude
-	altText, err := b.llmClient.GenerateAltText(ctx, imageData, imageInfo.MimeType)
-	if err != nil {
-		return fmt.Errorf("alt-text generation failed: %w", err)
+	if result.altText == "" {
+		result.altText = strings.TrimSpace(originalBody)
+	}
+	log.Printf("Suggested tags: %s", strings.Join(result.tags, ", "))
+	if result.shortcode != "" {
+		log.Printf("Suggested shortcode: :%s:", result.shortcode)
 	}
 
-	// Clean up alt-text: replace linebreaks with spaces and trim
+	return result, nil
+}
+
+// cleanAltText puts generated alt-text on one line
+func cleanAltText(altText string) string {
 	altText = strings.ReplaceAll(altText, "\r\n", " ")
 	altText = strings.ReplaceAll(altText, "\n", " ")
 	altText = strings.ReplaceAll(altText, "\r", " ")
-	altText = strings.TrimSpace(altText)
+	return strings.TrimSpace(altText)
+}
 
-	log.Printf("Generated alt-text: %s", altText)
+// setGeneratedAltText records a sticker's alt-text by language and the model that
+// wrote it, if one did
+func setGeneratedAltText(sticker *storage.Sticker, altTexts map[string]string, model string) {
+	sticker.AltTexts = altTexts
+	if model != "" {
+		sticker.AltTextBy = storage.AltTextByModel
+		sticker.AltTextModel = model
+	}
+}
 
-	// Create sticker record
-	sticker := storage.Sticker{
-		ID:               stickerID,
-		Name:             stickerID, // Default to SHA256 hash
+// newSticker creates the sticker record for a fetched image
+func newSticker(image *fetchedImage, localMXC string, altText string, originalBody string) *storage.Sticker {
+	return &storage.Sticker{
+		ID:               image.id,
+		Name:             image.id, // Default to SHA256 hash
 		CollectedAt:      time.Now(),
-		SourceRoom:       roomID.String(),
-		SourceEvent:      eventID.String(),
-		SourceMXC:        string(mxcURI),
+		SourceMXC:        string(image.mxcURI),
 		LocalMXC:         localMXC,
-		MimeType:         imageInfo.MimeType,
-		Width:            imageInfo.Width,
-		Height:           imageInfo.Height,
-		SizeBytes:        imageInfo.SizeBytes,
+		MimeType:         image.info.MimeType,
+		Width:            image.info.Width,
+		Height:           image.info.Height,
+		SizeBytes:        image.info.SizeBytes,
 		OriginalBody:     originalBody,
 		GeneratedAltText: altText,
 		InPacks:          []string{},
 	}
-
-	// Save to collection
-	if err := storage.AddSticker(b.storageDir, sticker); err != nil {
-		return fmt.Errorf("failed to save sticker: %w", err)
-	}
-
-	log.Printf("✅ Sticker collected successfully: %s", stickerID)
-
-	return nil
 }
 
 // redactReaction redacts the reaction event to confirm collection
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// stickerArg is where a command that acts on one sticker takes its ID
+type stickerArg struct {
+	position int  // Index of the sticker ID in the command's arguments
+	count    int  // Number of arguments including the sticker ID
+	optional bool // The last argument can be left out, as name does to accept the suggested shortcode
+	variadic bool // Takes any number of arguments from count on, so a reply supplies the ID unless one is given
+}
+
+// stickerArgs lists the commands a reply to an image can target, keyed like commandRoles
+var stickerArgs = map[string]stickerArg{
+	"show":        {position: 1, count: 2},
+	"delete":      {position: 1, count: 2},
+	"remove":      {position: 1, count: 2},
+	"name":        {position: 1, count: 3, optional: true},
+	"usage":       {position: 1, count: 3},
+	"tag":         {position: 1, count: 3, variadic: true},
+	"untag":       {position: 1, count: 3, variadic: true},
+	"alt":         {position: 1, count: 2, variadic: true},
+	"pack add":    {position: 3, count: 4},
+	"pack remove": {position: 3, count: 4},
+	"pack move":   {position: 3, count: 5},
+}
+
+// replyCommand rewrites a command sent as a reply to an image so it acts on that
+// image's sticker, e.g. "!sticker name happy_cat" becomes "!sticker name <id> happy_cat".
+// Commands that don't take a sticker, or already name one, are returned unchanged. A
+// command taking any number of arguments names a sticker only with a full ID or a
+// :shortcode: as its first argument, so "!sticker tag :grumpy: cat" tags :grumpy: but
+// "!sticker alt 2 cats" and "!sticker tag cafe" act on the image.
+// If the image isn't collected yet, a job is queued to collect it and run the command
+// afterwards, answering commandID, and queued is true.
+func (b *Bot) replyCommand(ctx context.Context, sender id.UserID, roomID id.RoomID, parentID id.EventID, commandID id.EventID, body string) (command string, queued bool, err error) {
+	args := strings.Fields(strings.TrimPrefix(body, "!sticker"))
+	if len(args) == 0 {
+		return body, false, nil
+	}
+
+	key := args[0]
+	if key == "pack" && len(args) > 1 {
+		key = key + " " + args[1]
+	}
+	arg, ok := stickerArgs[key]
+	if !ok || !arg.takesReply(len(args)) {
+		return body, false, nil
+	}
+	if arg.variadic && len(args) > arg.position && namesSticker(args[arg.position]) {
+		if _, err := b.resolveSticker(sender, args[arg.position]); err == nil {
+			return body, false, nil
+		}
+	}
+
+	// Leave permission errors to executeCommand rather than collecting for nothing
+	if b.access.Role(sender) < requiredRole(args) {
+		return body, false, nil
+	}
+
+	stickerID, collected, err := b.replySticker(ctx, sender, roomID, parentID)
+	if err != nil {
+		return "", false, err
+	}
+	command = "!sticker " + strings.Join(slices.Insert(args, arg.position, stickerID), " ")
+	if collected {
+		return command, false, nil
+	}
+
+	// Collecting can take a while and may need retries, so it runs as a job like a reaction
+	job := storage.Job{
+		ID:           jobID(commandID.String()),
+		RoomID:       roomID.String(),
+		EventID:      parentID.String(),
+		RequestedBy:  sender.String(),
+		Command:      command,
+		CommandEvent: commandID.String(),
+	}
+	if err := b.jobs.enqueue(job); err != nil {
+		return "", false, fmt.Errorf("failed to queue collection: %w", err)
+	}
+	log.Printf("Queued collection job %s for replied-to image %s", job.ID, parentID)
+
+	return command, true, nil
+}
+
+// namesSticker reports whether a reference can't be mistaken for a command's text: a
+// full sticker ID or a :shortcode:. Listing positions and ID prefixes look like
+// ordinary words and numbers.
+func namesSticker(ref string) bool {
+	if strings.HasPrefix(ref, ":") {
+		return true
+	}
+	if len(ref) != stickerIDLength {
+		return false
+	}
+	_, err := hex.DecodeString(ref)
+	return err == nil
+}
+
+// takesReply reports whether a command given n arguments leaves its sticker ID out,
+// for a reply to fill in
+func (arg stickerArg) takesReply(n int) bool {
+	switch {
+	case arg.variadic:
+		return n >= arg.count-1
+	case arg.optional:
+		return n == arg.count-1 || n == arg.count-2
+	default:
+		return n == arg.count-1
+	}
+}
+
+// replySticker finds the sticker ID for an image a command replied to, and whether
+// it's in the collection yet. Only curators may have it collected.
+func (b *Bot) replySticker(ctx context.Context, sender id.UserID, roomID id.RoomID, parentID id.EventID) (string, bool, error) {
+	mxcURI, file, _, err := b.resolveImage(ctx, roomID, parentID)
+	if err != nil {
+		return "", false, err
+	}
+
+	image, err := b.fetchImage(ctx, mxcURI, file)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := b.store.GetSticker(image.id); err == nil {
+		return image.id, true, nil
+	}
+
+	if b.access.Role(sender) < RoleCurator {
+		return "", false, fmt.Errorf("this image isn't in the collection yet, and collecting it needs the %s role", RoleCurator)
+	}
+	return image.id, false, nil
+}
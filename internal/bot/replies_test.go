@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// replyOptions sets up a bot whose homeserver serves one image event, $image, and one
+// text event, $text, that only @viewer:matrix.org may browse. It returns the options
+// and the image's sticker ID.
+func replyOptions(t *testing.T) (serverBotOptions, string) {
+	imageData := testPNG(t, 8)
+	return serverBotOptions{
+		events: map[string]string{
+			"$image": `{"type":"m.room.message","event_id":"$image","room_id":"!room:matrix.org","sender":"@alice:matrix.org",
+				"content":{"msgtype":"m.image","body":"cat.png","url":"mxc://matrix.org/cat"}}`,
+			"$text": `{"type":"m.room.message","event_id":"$text","room_id":"!room:matrix.org","sender":"@alice:matrix.org",
+				"content":{"msgtype":"m.text","body":"hello"}}`,
+		},
+		image: imageData,
+		configure: func(cfg *config.Config) {
+			cfg.Access.Users = []config.AccessRule{{Match: "@viewer:matrix.org", Role: config.RoleViewer}}
+		},
+	}, matrix.HashImage(imageData)
+}
+
+// TestReplyCommand verifies a reply to an image fills in its sticker ID
+func TestReplyCommand(t *testing.T) {
+	opts, stickerID := replyOptions(t)
+	bot, _ := setupServerBot(t, opts)
+	ctx := context.Background()
+	owner := bot.client.UserID
+	room := id.RoomID("!room:matrix.org")
+	otherID := "cafe" + strings.Repeat("0", 60)
+	bot.rememberListing(owner, []storage.Sticker{{ID: otherID}, {ID: otherID}})
+
+	for _, sticker := range []storage.Sticker{
+		{ID: stickerID, Name: stickerID, InPacks: []string{}},
+		{ID: otherID, Name: "other", InPacks: []string{}},
+	} {
+		if err := bot.store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{"!sticker name happy_cat", "!sticker name " + stickerID + " happy_cat"},
+		{"!sticker name", "!sticker name " + stickerID}, // Accepts the suggested shortcode
+		{"!sticker show", "!sticker show " + stickerID},
+		{"!sticker pack add cats", "!sticker pack add cats " + stickerID},
+		{"!sticker pack move cats 1", "!sticker pack move cats " + stickerID + " 1"},
+		{"!sticker tag cat happy", "!sticker tag " + stickerID + " cat happy"}, // Any number of tags
+		{"!sticker alt A sleepy cat", "!sticker alt " + stickerID + " A sleepy cat"},
+		{"!sticker alt", "!sticker alt " + stickerID},
+		{"!sticker name cafe happy_cat", "!sticker name cafe happy_cat"},         // Already names a sticker
+		{"!sticker tag " + otherID + " cat", "!sticker tag " + otherID + " cat"}, // A full ID wins over the reply
+		{"!sticker tag :other: cat", "!sticker tag :other: cat"},
+		{"!sticker alt " + otherID + " --regenerate", "!sticker alt " + otherID + " --regenerate"},
+		{"!sticker tag cafe", "!sticker tag " + stickerID + " cafe"},                     // Only looks like an ID prefix
+		{"!sticker alt 2 cats hugging", "!sticker alt " + stickerID + " 2 cats hugging"}, // Not listing position 2
+		{"!sticker pack list", "!sticker pack list"},                                     // Doesn't take a sticker
+	}
+
+	for _, tt := range tests {
+		got, queued, err := bot.replyCommand(ctx, owner, room, "$image", "$command", tt.body)
+		if err != nil || queued || got != tt.expected {
+			t.Errorf("%q: expected %q, got %q, %v, %v", tt.body, tt.expected, got, queued, err)
+		}
+	}
+
+	// Replying to something without an image is an error
+	if _, _, err := bot.replyCommand(ctx, owner, room, "$text", "$command", "!sticker show"); err == nil {
+		t.Error("Expected error replying to a text message")
+	}
+
+	// The rewritten command runs against the replied-to sticker
+	command, _, _ := bot.replyCommand(ctx, owner, room, "$image", "$command", "!sticker name happy_cat")
+	if result := bot.executeCommand(ctx, owner, command); !strings.Contains(result, "✅") {
+		t.Fatalf("Expected name to be set, got: %s", result)
+	}
+	sticker, err := bot.store.GetSticker(stickerID)
+	if err != nil || sticker.Name != "happy_cat" {
+		t.Errorf("Expected shortcode happy_cat, got %v, %v", sticker, err)
+	}
+}
+
+// TestReplyCommand_AcceptsSuggestion verifies a bare name reply accepts the image's
+// suggested shortcode
+func TestReplyCommand_AcceptsSuggestion(t *testing.T) {
+	opts, stickerID := replyOptions(t)
+	bot, _ := setupServerBot(t, opts)
+	ctx := context.Background()
+	owner := bot.client.UserID
+
+	if err := bot.store.AddSticker(storage.Sticker{ID: stickerID, Name: stickerID, SuggestedName: "black_square", InPacks: []string{}}); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+
+	command, _, err := bot.replyCommand(ctx, owner, "!room:matrix.org", "$image", "$command", "!sticker name")
+	if err != nil {
+		t.Fatalf("Failed to rewrite command: %v", err)
+	}
+	if result := bot.executeCommand(ctx, owner, command); !strings.HasPrefix(result, "✅") {
+		t.Fatalf("Expected the suggestion to be accepted, got: %s", result)
+	}
+	if sticker, err := bot.store.GetSticker(stickerID); err != nil || sticker.Name != "black_square" {
+		t.Errorf("Expected shortcode black_square, got %v, %v", sticker, err)
+	}
+}
+
+// TestReplyCommand_Uncollected verifies only curators can collect an image by replying to it
+func TestReplyCommand_Uncollected(t *testing.T) {
+	opts, _ := replyOptions(t)
+	bot, _ := setupServerBot(t, opts)
+
+	_, _, err := bot.replyCommand(context.Background(), "@viewer:matrix.org", "!room:matrix.org", "$image", "$command", "!sticker show")
+	if err == nil || !strings.Contains(err.Error(), "isn't in the collection") {
+		t.Errorf("Expected viewer not to collect, got %v", err)
+	}
+
+	// Viewers can't rename at all, so that's left for executeCommand to refuse
+	got, _, err := bot.replyCommand(context.Background(), "@viewer:matrix.org", "!room:matrix.org", "$image", "$command", "!sticker name happy_cat")
+	if err != nil || got != "!sticker name happy_cat" {
+		t.Errorf("Expected command unchanged, got %q, %v", got, err)
+	}
+}
+
+// TestReplyCommand_Collects verifies a curator's reply to an uncollected image queues
+// a job that collects it and then runs the command
+func TestReplyCommand_Collects(t *testing.T) {
+	opts, stickerID := replyOptions(t)
+	bot, _ := setupServerBot(t, opts)
+	ctx := context.Background()
+
+	command, queued, err := bot.replyCommand(ctx, bot.client.UserID, "!room:matrix.org", "$image", "$command", "!sticker name happy_cat")
+	if err != nil || !queued || command != "!sticker name "+stickerID+" happy_cat" {
+		t.Fatalf("Expected the command to wait on a collection job, got %q, %v, %v", command, queued, err)
+	}
+	if _, err := bot.store.GetSticker(stickerID); err == nil {
+		t.Fatal("Expected the image to be collected by the job, not the command")
+	}
+
+	job, _, _ := bot.jobs.next()
+	if job == nil || job.Command != command || job.CommandEvent != "$command" || job.EventID != "$image" {
+		t.Fatalf("Expected a collection job for the image, got %+v", job)
+	}
+	bot.runJob(ctx, job)
+
+	sticker, err := bot.store.GetSticker(stickerID)
+	if err != nil || sticker.Name != "happy_cat" || sticker.GeneratedAltText != "A cat" {
+		t.Errorf("Expected the sticker collected and named happy_cat, got %+v, %v", sticker, err)
+	}
+	if jobs, _ := bot.jobs.list(); len(jobs) != 0 {
+		t.Errorf("Expected the finished job to be removed, got %+v", jobs)
+	}
+}
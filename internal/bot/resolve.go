@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// minPrefixLength is the shortest sticker ID prefix accepted, like git's short hashes.
+// Anything shorter that's a number is a position in the last listing instead.
+const minPrefixLength = 4
+
+// stickerIDLength is the length of a full sticker ID, a hex SHA-256 hash
+const stickerIDLength = 64
+
+// maxCandidates is how many matches an ambiguous reference lists
+const maxCandidates = 10
+
+// rememberListing keeps the sticker IDs of a numbered listing shown to a user, so
+// they can pick stickers from it by position
+func (b *Bot) rememberListing(user id.UserID, stickers []storage.Sticker) {
+	ids := make([]string, len(stickers))
+	for i, sticker := range stickers {
+		ids[i] = sticker.ID
+	}
+
+	b.listingsMu.Lock()
+	defer b.listingsMu.Unlock()
+	b.listings[user] = ids
+}
+
+// resolveSticker turns a sticker reference into a full sticker ID. A reference is a
+// full ID, a unique ID prefix of at least minPrefixLength characters, a :shortcode:,
+// or a position (3 or #3) in the user's last listing.
+func (b *Bot) resolveSticker(user id.UserID, ref string) (string, error) {
+	if position, ok := listingPosition(ref); ok {
+		return b.listingSticker(user, position)
+	}
+
+	stickers, err := b.store.ListStickers()
+	if err != nil {
+		return "", fmt.Errorf("failed to load collection: %w", err)
+	}
+
+	var matches []storage.Sticker
+	if shortcode, ok := strings.CutPrefix(ref, ":"); ok {
+		shortcode = strings.TrimSuffix(shortcode, ":")
+		for _, sticker := range stickers {
+			if sticker.Name == shortcode {
+				matches = append(matches, sticker)
+			}
+		}
+		return pickMatch(ref, matches)
+	}
+
+	prefix := strings.ToLower(ref)
+	if len(prefix) < minPrefixLength {
+		return "", fmt.Errorf("sticker ID %s is too short - use at least %d characters, a :shortcode:, or a number from the last listing", ref, minPrefixLength)
+	}
+	for _, sticker := range stickers {
+		if sticker.ID == prefix {
+			return sticker.ID, nil
+		}
+		if strings.HasPrefix(sticker.ID, prefix) {
+			matches = append(matches, sticker)
+		}
+	}
+
+	return pickMatch(ref, matches)
+}
+
+// withSticker resolves a sticker reference and runs a command with the full ID,
+// or returns why the reference couldn't be resolved
+func (b *Bot) withSticker(user id.UserID, ref string, command func(stickerID string) string) string {
+	stickerID, err := b.resolveSticker(user, ref)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	return command(stickerID)
+}
+
+// listingSticker returns the sticker at a 1-based position in the user's last listing
+func (b *Bot) listingSticker(user id.UserID, position int) (string, error) {
+	b.listingsMu.Lock()
+	defer b.listingsMu.Unlock()
+
+	listing, ok := b.listings[user]
+	if !ok {
+		return "", fmt.Errorf("no listing to pick #%d from - run `!sticker list unsorted` or `!sticker pack show <pack>` first", position)
+	}
+	if position < 1 || position > len(listing) {
+		return "", fmt.Errorf("no sticker #%d in your last listing (it had %d)", position, len(listing))
+	}
+
+	return listing[position-1], nil
+}
+
+// listingPosition parses a listing position, either #N or a number too short to be an ID prefix
+func listingPosition(ref string) (int, bool) {
+	digits, hashed := strings.CutPrefix(ref, "#")
+	if !hashed && len(digits) >= minPrefixLength {
+		return 0, false
+	}
+
+	position, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, false
+	}
+	return position, true
+}
+
+// pickMatch returns the only sticker a reference matched, or an error listing the candidates
+func pickMatch(ref string, matches []storage.Sticker) (string, error) {
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("sticker not found: %s", ref)
+	case 1:
+		return matches[0].ID, nil
+	}
+
+	var candidates strings.Builder
+	for _, sticker := range matches[:min(len(matches), maxCandidates)] {
+		candidates.WriteString(fmt.Sprintf("\n- `%s` (:%s:)", sticker.ID, sticker.Name))
+	}
+	if len(matches) > maxCandidates {
+		candidates.WriteString(fmt.Sprintf("\n- ...and %d more", len(matches)-maxCandidates))
+	}
+
+	return "", fmt.Errorf("%s matches %d stickers, be more specific:\n%s", ref, len(matches), candidates.String())
+}
@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// addResolveStickers adds stickers whose IDs share prefixes
+func addResolveStickers(t *testing.T, bot *Bot) {
+	t.Helper()
+
+	for _, sticker := range []storage.Sticker{
+		{ID: "abcd1111", Name: "cat"},
+		{ID: "abcd2222", Name: "dog"},
+		{ID: "beef3333", Name: "beef3333"},
+		{ID: "cafe4444", Name: "dog"},
+	} {
+		sticker.InPacks = []string{}
+		if err := bot.store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+}
+
+// TestResolveSticker verifies prefixes, shortcodes and listing positions resolve to IDs
+func TestResolveSticker(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+	addResolveStickers(t, bot)
+
+	user := bot.client.UserID
+	tests := []struct {
+		ref      string
+		expected string
+		errMsg   string
+	}{
+		{"abcd1111", "abcd1111", ""},
+		{"abcd1", "abcd1111", ""},
+		{"BEEF", "beef3333", ""},
+		{":cat:", "abcd1111", ""},
+		{"abcd", "", "matches 2 stickers"},
+		{":dog:", "", "matches 2 stickers"},
+		{"abc", "", "too short"},
+		{"0000", "", "sticker not found"},
+		{":fish:", "", "sticker not found"},
+		{"2", "", "no listing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := bot.resolveSticker(user, tt.ref)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("Expected error containing %q, got %q, %v", tt.errMsg, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("Expected %s, got %q, %v", tt.expected, got, err)
+			}
+		})
+	}
+
+	// Ambiguous references list every candidate
+	_, err := bot.resolveSticker(user, "abcd")
+	if err == nil || !strings.Contains(err.Error(), "abcd1111") || !strings.Contains(err.Error(), "abcd2222") {
+		t.Errorf("Expected both candidates listed, got %v", err)
+	}
+}
+
+// TestResolveSticker_Listing verifies numbers pick from the user's own last listing
+func TestResolveSticker_Listing(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+	addResolveStickers(t, bot)
+
+	user := bot.client.UserID
+	result := bot.executeCommand(context.Background(), user, "!sticker list unsorted")
+	if !strings.Contains(result, "abcd1111") {
+		t.Fatalf("Expected unsorted listing, got: %s", result)
+	}
+
+	for ref, expected := range map[string]string{"1": "abcd1111", "#3": "beef3333", "4": "cafe4444"} {
+		if got, err := bot.resolveSticker(user, ref); err != nil || got != expected {
+			t.Errorf("Position %s: expected %s, got %q, %v", ref, expected, got, err)
+		}
+	}
+
+	if _, err := bot.resolveSticker(user, "5"); err == nil || !strings.Contains(err.Error(), "it had 4") {
+		t.Errorf("Expected out of range error, got %v", err)
+	}
+	if _, err := bot.resolveSticker("@other:matrix.org", "1"); err == nil {
+		t.Error("Expected another user's listing not to be shared")
+	}
+
+	// Commands take any kind of reference
+	if err := bot.store.CreatePack("pets", "Pets", ""); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	result = bot.executeCommand(context.Background(), user, "!sticker pack add pets 2")
+	if !strings.Contains(result, "✅") {
+		t.Fatalf("Expected sticker added by position, got: %s", result)
+	}
+	result = bot.executeCommand(context.Background(), user, "!sticker pack add pets :cat:")
+	if !strings.Contains(result, "✅") {
+		t.Fatalf("Expected sticker added by shortcode, got: %s", result)
+	}
+	result = bot.executeCommand(context.Background(), user, "!sticker pack add pets abcd")
+	if !strings.Contains(result, "❌") || !strings.Contains(result, "matches 2 stickers") {
+		t.Errorf("Expected ambiguity error, got: %s", result)
+	}
+
+	pack, err := bot.store.GetPack("pets")
+	if err != nil {
+		t.Fatalf("Failed to load pack: %v", err)
+	}
+	if len(pack.StickerIDs) != 2 || pack.StickerIDs[0] != "abcd2222" || pack.StickerIDs[1] != "abcd1111" {
+		t.Errorf("Expected abcd2222 and abcd1111 in pack, got %v", pack.StickerIDs)
+	}
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// stickerSearch searches the collection and shows a page of results, best match
+// first. The whole result list is remembered so stickers can be picked by number.
+func (b *Bot) stickerSearch(sender id.UserID, args []string) string {
+	p, terms, err := parsePage(args)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	queryText := strings.Join(terms, " ")
+	query, err := storage.ParseSearchQuery(queryText)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	if query.IsEmpty() {
+		return "❌ Usage: !sticker search <query> [--page N] [--limit N] [--gallery]\n\n" +
+			"Searches shortcodes, alt-text, original descriptions, source rooms and packs. Filters:\n\n" +
+			"- pack:<pack> (or pack:unsorted)\n" +
+			"- usage:sticker or usage:emoticon\n" +
+			"- mime:<type>, e.g. mime:gif\n" +
+			"- before:YYYY-MM-DD and after:YYYY-MM-DD\n\n" +
+			"Example: !sticker search \"waving paw\" pack:cats mime:gif"
+	}
+
+	results, err := storage.Search(b.store, query)
+	if err != nil {
+		return fmt.Sprintf("❌ Error searching collection: %v", err)
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No stickers match: %s", queryText)
+	}
+	if err := p.check(len(results)); err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	stickers := make([]storage.Sticker, len(results))
+	for i, r := range results {
+		stickers[i] = r.Sticker
+	}
+
+	return fmt.Sprintf("Found %d sticker(s) matching: %s\n\n", len(results), queryText) +
+		b.showStickers(sender, stickers, p, "!sticker search "+queryText, searchLine)
+}
+
+// searchLine is a search result's list entry, which also names the sticker's packs
+func searchLine(n int, sticker storage.Sticker) string {
+	packs := "unsorted"
+	if len(sticker.InPacks) > 0 {
+		packs = strings.Join(sticker.InPacks, ", ")
+	}
+
+	return strings.TrimSuffix(stickerLine(n, sticker), "\n") + fmt.Sprintf(" [%s]\n", packs)
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// TestExecuteCommand_Search verifies search results are paged and can be picked by number
+func TestExecuteCommand_Search(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	for i := range 25 {
+		sticker := storage.Sticker{
+			ID:               fmt.Sprintf("cat%05d", i),
+			Name:             fmt.Sprintf("cat_%d", i),
+			GeneratedAltText: "A cat",
+			InPacks:          []string{},
+		}
+		if err := bot.store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+
+	user := bot.client.UserID
+	result := bot.executeCommand(context.Background(), user, "!sticker search cat")
+	if !strings.Contains(result, "Found 25 sticker(s)") || !strings.Contains(result, "Page 1 of 2") {
+		t.Errorf("Expected first page of 25 results, got: %s", result)
+	}
+	if !strings.Contains(result, "`!sticker search cat --page 2`") {
+		t.Errorf("Expected next page hint, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), user, "!sticker search cat --page 2")
+	if !strings.Contains(result, "21. `") || strings.Contains(result, "\n1. `") {
+		t.Errorf("Expected numbering to continue on page 2, got: %s", result)
+	}
+
+	// Numbers from the results work in later commands
+	if _, err := bot.resolveSticker(user, "25"); err != nil {
+		t.Errorf("Expected to pick a search result by number: %v", err)
+	}
+
+	result = bot.executeCommand(context.Background(), user, "!sticker search cat --page 5")
+	if !strings.Contains(result, "❌") {
+		t.Errorf("Expected error past the last page, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), user, "!sticker search dog")
+	if !strings.Contains(result, "No stickers match") {
+		t.Errorf("Expected no results, got: %s", result)
+	}
+
+	result = bot.executeCommand(context.Background(), user, "!sticker search")
+	if !strings.Contains(result, "Usage") {
+		t.Errorf("Expected usage, got: %s", result)
+	}
+}
@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// suggestShortcode applies the model's suggested shortcode to a new sticker, as its
+// name or as a suggestion for a curator depending on alt_text.shortcodes. The
+// shortcode is made unique in the collection first, so it can't clash with another
+// sticker in any of the packs it ends up in.
+func (b *Bot) suggestShortcode(sticker *storage.Sticker, shortcode string) error {
+	mode := b.config.AltText.Shortcodes
+	if shortcode == "" || mode == config.ShortcodesOff {
+		return nil
+	}
+
+	taken, err := b.takenShortcodes(sticker.ID)
+	if err != nil {
+		return err
+	}
+	shortcode = uniqueShortcode(shortcode, taken)
+
+	if mode == config.ShortcodesSuggest {
+		sticker.SuggestedName = shortcode
+		log.Printf("Suggesting shortcode :%s: for %s", shortcode, sticker.ID)
+		return nil
+	}
+	sticker.Name = shortcode
+	log.Printf("Named sticker %s :%s:", sticker.ID, shortcode)
+	return nil
+}
+
+// takenShortcodes returns the shortcodes of every sticker in the collection except
+// stickerID
+func (b *Bot) takenShortcodes(stickerID string) (map[string]bool, error) {
+	stickers, err := b.store.ListStickers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection: %w", err)
+	}
+
+	taken := make(map[string]bool, len(stickers))
+	for _, sticker := range stickers {
+		if sticker.ID != stickerID {
+			taken[sticker.Shortcode()] = true
+		}
+	}
+	return taken, nil
+}
+
+// uniqueShortcode numbers a shortcode (happy_cat_2, happy_cat_3, ...) until it isn't
+// taken, shortening it if needed to stay a valid length
+func uniqueShortcode(shortcode string, taken map[string]bool) string {
+	if !taken[shortcode] {
+		return shortcode
+	}
+	for n := 2; ; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		base := shortcode[:min(len(shortcode), storage.MaxShortcodeLength-len(suffix))]
+		if candidate := base + suffix; !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// renameHint follows an error reply for a shortcode conflict from adding or renaming
+// a sticker with a free shortcode to rename it to, or returns "" for other errors
+func (b *Bot) renameHint(err error) string {
+	var conflict *storage.ShortcodeConflict
+	if !errors.As(err, &conflict) {
+		return ""
+	}
+
+	stickerID := conflict.StickerIDs[len(conflict.StickerIDs)-1]
+	taken, err := b.takenShortcodes(stickerID)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\n\nOnly one of them would be published. Give `%s` another shortcode first, e.g. `!sticker name %s %s`",
+		stickerID, stickerID, uniqueShortcode(conflict.Shortcode, taken))
+}
+
+// lintHint follows an error reply for a pack that can't be published because of a
+// shortcode conflict, or returns "" for other errors
+func lintHint(err error) string {
+	var conflict *storage.ShortcodeConflict
+	if !errors.As(err, &conflict) {
+		return ""
+	}
+	return fmt.Sprintf("\n\nRun `!sticker pack lint %s` to see every problem, or `!sticker pack lint %s --fix` to number the clashing shortcodes",
+		conflict.Pack, conflict.Pack)
+}
+
+// withoutClashes picks the stickers that can be added to a pack together, in order:
+// ones whose shortcode is already used by a member of the pack (or an earlier
+// sticker in the list) are returned as conflicts instead
+func withoutClashes(packName string, members []storage.Sticker, stickers []storage.Sticker) ([]string, []storage.ShortcodeConflict) {
+	users := make(map[string]string) // Shortcode → sticker ID
+	for _, member := range members {
+		users[member.Shortcode()] = member.ID
+	}
+
+	var added []string
+	var clashes []storage.ShortcodeConflict
+	for _, sticker := range stickers {
+		if slices.Contains(added, sticker.ID) || slices.ContainsFunc(members, func(m storage.Sticker) bool { return m.ID == sticker.ID }) {
+			continue
+		}
+		shortcode := sticker.Shortcode()
+		if user, ok := users[shortcode]; ok {
+			clashes = append(clashes, storage.ShortcodeConflict{Pack: packName, Shortcode: shortcode, StickerIDs: []string{user, sticker.ID}})
+			continue
+		}
+		users[shortcode] = sticker.ID
+		added = append(added, sticker.ID)
+	}
+
+	return added, clashes
+}
+
+// packLint lists a pack's shortcode problems: shortcodes shared by several stickers,
+// which would publish only one of them, invalid shortcodes, and stickers still named
+// by their hash. With fix, every sticker sharing a shortcode but the first is
+// renamed to a numbered one that's free in the collection.
+func (b *Bot) packLint(packName string, fix bool) string {
+	stickers, err := b.store.PackStickers(packName)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading pack: %v", err)
+	}
+
+	conflicts := storage.FindShortcodeConflicts(packName, stickers)
+
+	var result strings.Builder
+	var renamedPacks []string
+	if fix && len(conflicts) > 0 {
+		renamed, packNames, err := b.numberConflicts(stickers, conflicts)
+		if err != nil {
+			return fmt.Sprintf("❌ Error fixing shortcodes: %v", err)
+		}
+		result.WriteString(fmt.Sprintf("✅ Renamed %d sticker(s) in pack %s:\n", len(renamed), packName))
+		for _, line := range renamed {
+			result.WriteString(fmt.Sprintf("- %s\n", line))
+		}
+		result.WriteString("\n")
+		renamedPacks = packNames
+
+		if stickers, err = b.store.PackStickers(packName); err != nil {
+			return fmt.Sprintf("❌ Error loading pack: %v", err)
+		}
+		conflicts = storage.FindShortcodeConflicts(packName, stickers)
+	}
+
+	var problems []string
+	for _, conflict := range conflicts {
+		problems = append(problems, fmt.Sprintf("❌ :%s: is used by %d stickers (`%s`) - only one would be published",
+			conflict.Shortcode, len(conflict.StickerIDs), strings.Join(conflict.StickerIDs, "`, `")))
+	}
+	for _, sticker := range stickers {
+		switch {
+		case sticker.Shortcode() == sticker.ID && sticker.SuggestedName != "":
+			problems = append(problems, fmt.Sprintf("⚠️ `%s` has no shortcode yet - accept :%s: with `!sticker name %s`",
+				sticker.ID, sticker.SuggestedName, sticker.ID))
+		case sticker.Shortcode() == sticker.ID:
+			problems = append(problems, fmt.Sprintf("⚠️ `%s` has no shortcode yet - set one with `!sticker name %s <shortcode>`",
+				sticker.ID, sticker.ID))
+		default:
+			if err := storage.ValidateShortcode(sticker.Name); err != nil {
+				problems = append(problems, fmt.Sprintf("❌ `%s` has an invalid shortcode :%s: (%v)", sticker.ID, sticker.Name, err))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		result.WriteString(fmt.Sprintf("✅ No shortcode problems in pack: %s (%d stickers)", packName, len(stickers)))
+	} else {
+		result.WriteString(fmt.Sprintf("Shortcode problems in pack %s (%d):\n\n", packName, len(problems)))
+		for _, problem := range problems {
+			result.WriteString(fmt.Sprintf("- %s\n", problem))
+		}
+		if len(conflicts) > 0 {
+			result.WriteString(fmt.Sprintf("\nAdd `--fix` to number the clashing shortcodes: `!sticker pack lint %s --fix`", packName))
+		}
+	}
+
+	if len(renamedPacks) > 0 {
+		if pack, err := b.store.GetPack(packName); err == nil && len(pack.PublishedRooms) > 0 {
+			result.WriteString(fmt.Sprintf("\n\nRepublish with `!sticker pack publish %s` to update the rooms it's published in", packName))
+		}
+		result.WriteString(b.refreshPersonal(renamedPacks...))
+	}
+	return result.String()
+}
+
+// numberConflicts renames every sticker sharing a shortcode but the first to a
+// numbered shortcode that's free in the collection, describing each rename and
+// returning the packs the renamed stickers are in
+func (b *Bot) numberConflicts(stickers []storage.Sticker, conflicts []storage.ShortcodeConflict) ([]string, []string, error) {
+	taken, err := b.takenShortcodes("")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var renamed, packNames []string
+	for _, conflict := range conflicts {
+		for _, stickerID := range conflict.StickerIDs[1:] {
+			shortcode := uniqueShortcode(conflict.Shortcode, taken)
+			if err := b.store.SetStickerName(stickerID, shortcode); err != nil {
+				return nil, nil, fmt.Errorf("failed to rename %s: %w", stickerID, err)
+			}
+			taken[shortcode] = true
+			renamed = append(renamed, fmt.Sprintf("`%s`: :%s: → :%s:", stickerID, conflict.Shortcode, shortcode))
+
+			i := slices.IndexFunc(stickers, func(s storage.Sticker) bool { return s.ID == stickerID })
+			for _, packName := range stickers[i].InPacks {
+				if !slices.Contains(packNames, packName) {
+					packNames = append(packNames, packName)
+				}
+			}
+		}
+	}
+
+	return renamed, packNames, nil
+}
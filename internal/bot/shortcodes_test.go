@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// shortcodeOptions sets up a bot in the given shortcodes mode with a collection holding
+// one sticker named happy_cat
+func shortcodeOptions(mode string) serverBotOptions {
+	return serverBotOptions{
+		configure: func(cfg *config.Config) { cfg.AltText.Shortcodes = mode },
+		stickers:  []storage.Sticker{{ID: "sha256:existing", Name: "happy_cat"}},
+	}
+}
+
+// TestUniqueShortcode verifies taken shortcodes are numbered and stay valid
+func TestUniqueShortcode(t *testing.T) {
+	taken := map[string]bool{"happy_cat": true, "happy_cat_2": true}
+
+	if got := uniqueShortcode("sad_cat", taken); got != "sad_cat" {
+		t.Errorf("Expected sad_cat unchanged, got %s", got)
+	}
+	if got := uniqueShortcode("happy_cat", taken); got != "happy_cat_3" {
+		t.Errorf("Expected happy_cat_3, got %s", got)
+	}
+
+	long := strings.Repeat("a", storage.MaxShortcodeLength)
+	got := uniqueShortcode(long, map[string]bool{long: true})
+	if err := storage.ValidateShortcode(got); err != nil || !strings.HasSuffix(got, "_2") {
+		t.Errorf("Expected a valid numbered shortcode, got %s (%v)", got, err)
+	}
+}
+
+// TestSuggestShortcode verifies each mode names the sticker, suggests a name or
+// leaves it alone, and that a clashing shortcode is made unique
+func TestSuggestShortcode(t *testing.T) {
+	tests := []struct {
+		mode          string
+		wantName      string
+		wantSuggested string
+	}{
+		{"", "happy_cat_2", ""},
+		{config.ShortcodesAuto, "happy_cat_2", ""},
+		{config.ShortcodesSuggest, "sha256:new", "happy_cat_2"},
+		{config.ShortcodesOff, "sha256:new", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			bot, _ := setupServerBot(t, shortcodeOptions(tt.mode))
+			sticker := &storage.Sticker{ID: "sha256:new", Name: "sha256:new"}
+
+			if err := bot.suggestShortcode(sticker, "happy_cat"); err != nil {
+				t.Fatalf("suggestShortcode failed: %v", err)
+			}
+			if sticker.Name != tt.wantName || sticker.SuggestedName != tt.wantSuggested {
+				t.Errorf("Expected name %q and suggestion %q, got %q and %q", tt.wantName, tt.wantSuggested, sticker.Name, sticker.SuggestedName)
+			}
+		})
+	}
+}
+
+// TestDescribeImage_Shortcode verifies only a valid suggested shortcode is kept
+func TestDescribeImage_Shortcode(t *testing.T) {
+	bot, _ := setupServerBot(t, shortcodeOptions(config.ShortcodesAuto))
+	generator := bot.llmClient.(*fakeGenerator)
+	image := &fetchedImage{data: []byte("image"), info: &matrix.ImageInfo{MimeType: "image/png"}}
+
+	generator.shortcode = "waving_cat"
+	description, err := bot.describeImage(context.Background(), image, "", "")
+	if err != nil {
+		t.Fatalf("describeImage failed: %v", err)
+	}
+	if description.shortcode != "waving_cat" {
+		t.Errorf("Expected shortcode waving_cat, got %q", description.shortcode)
+	}
+
+	generator.shortcode = "chat_qui_salue_🐱"
+	description, err = bot.describeImage(context.Background(), image, "", "")
+	if err != nil {
+		t.Fatalf("describeImage failed: %v", err)
+	}
+	if description.shortcode != "" {
+		t.Errorf("Expected invalid shortcode to be dropped, got %q", description.shortcode)
+	}
+}
+
+// TestStickerAcceptName verifies !sticker name <id> accepts the suggested shortcode
+func TestStickerAcceptName(t *testing.T) {
+	bot, _ := setupServerBot(t, shortcodeOptions(config.ShortcodesSuggest))
+
+	if result := bot.stickerAcceptName("sha256:existing"); !strings.Contains(result, "❌ No shortcode suggested") {
+		t.Errorf("Expected no suggestion error, got: %s", result)
+	}
+
+	if err := bot.store.AddSticker(storage.Sticker{ID: "sha256:new", Name: "sha256:new", SuggestedName: "waving_cat"}); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+	if result := bot.stickerAcceptName("sha256:new"); !strings.Contains(result, "✅ Set sticker shortcode to: :waving_cat:") {
+		t.Errorf("Expected shortcode accepted, got: %s", result)
+	}
+
+	sticker, err := bot.store.GetSticker("sha256:new")
+	if err != nil {
+		t.Fatalf("Failed to get sticker: %v", err)
+	}
+	if sticker.Name != "waving_cat" || sticker.SuggestedName != "" {
+		t.Errorf("Expected name waving_cat and no suggestion, got %q and %q", sticker.Name, sticker.SuggestedName)
+	}
+}
+
+// TestWithoutClashes verifies stickers whose shortcode is taken in the pack, or by an
+// earlier sticker, are left out
+func TestWithoutClashes(t *testing.T) {
+	members := []storage.Sticker{{ID: "a", Name: "cat"}}
+	stickers := []storage.Sticker{
+		{ID: "a", Name: "cat"},
+		{ID: "b", Name: "cat"},
+		{ID: "c", Name: "dog"},
+		{ID: "d", Name: "dog"},
+		{ID: "c", Name: "dog"},
+	}
+
+	added, clashes := withoutClashes("pets", members, stickers)
+	if len(added) != 1 || added[0] != "c" {
+		t.Errorf("Expected only c added, got %v", added)
+	}
+	if len(clashes) != 2 || clashes[0].StickerIDs[0] != "a" || clashes[0].StickerIDs[1] != "b" || clashes[1].Shortcode != "dog" {
+		t.Errorf("Unexpected clashes: %+v", clashes)
+	}
+}
+
+// TestExecuteCommand_PackLint verifies clashing shortcodes are refused when adding,
+// reported by lint and at publish time, and numbered by lint --fix
+func TestExecuteCommand_PackLint(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	for _, s := range []storage.Sticker{
+		{ID: "aaaa1111", Name: "cat", InPacks: []string{}},
+		{ID: "bbbb2222", Name: "cat", InPacks: []string{}},
+		{ID: "cccc3333", Name: "dog", InPacks: []string{}},
+		{ID: "dddd4444", Name: "dddd4444", InPacks: []string{}, SuggestedName: "bird"},
+	} {
+		if err := bot.store.AddSticker(s); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	user := bot.client.UserID
+	run := func(command string) string {
+		t.Helper()
+		return bot.executeCommand(ctx, user, command)
+	}
+
+	run("!sticker pack create pets")
+	run("!sticker pack add pets aaaa")
+	run("!sticker pack add pets cccc")
+	run("!sticker pack add pets dddd")
+
+	result := run("!sticker pack add pets bbbb")
+	if !strings.Contains(result, "❌") || !strings.Contains(result, ":cat:") || !strings.Contains(result, "`!sticker name bbbb2222 cat_2`") {
+		t.Errorf("Expected conflict with a rename suggestion, got: %s", result)
+	}
+	if result := run("!sticker name cccc cat"); !strings.Contains(result, "❌") || !strings.Contains(result, "cat_2") {
+		t.Errorf("Expected rename conflict, got: %s", result)
+	}
+
+	result = run("!sticker pack lint pets")
+	if !strings.Contains(result, "Shortcode problems in pack pets (1)") || !strings.Contains(result, "accept :bird: with `!sticker name dddd4444`") {
+		t.Errorf("Expected only the missing shortcode reported, got: %s", result)
+	}
+
+	// Saving a sticker again bypasses the checks, like data from older versions
+	dog, err := bot.store.GetSticker("cccc3333")
+	if err != nil {
+		t.Fatalf("Failed to get sticker: %v", err)
+	}
+	dog.Name = "cat"
+	if err := bot.store.AddSticker(*dog); err != nil {
+		t.Fatalf("Failed to save sticker: %v", err)
+	}
+
+	if result := run("!sticker pack publish pets --personal"); !strings.Contains(result, "❌") || !strings.Contains(result, "!sticker pack lint pets --fix") {
+		t.Errorf("Expected publish refused with a lint hint, got: %s", result)
+	}
+
+	result = run("!sticker pack lint pets")
+	if !strings.Contains(result, "❌ :cat: is used by 2 stickers (`aaaa1111`, `cccc3333`)") || !strings.Contains(result, "--fix") {
+		t.Errorf("Expected the conflict reported, got: %s", result)
+	}
+
+	result = run("!sticker pack lint pets --fix")
+	if !strings.Contains(result, "✅ Renamed 1 sticker(s)") || !strings.Contains(result, "`cccc3333`: :cat: → :cat_2:") {
+		t.Errorf("Expected the second cat numbered, got: %s", result)
+	}
+	if strings.Contains(result, "❌") {
+		t.Errorf("Expected no conflicts left, got: %s", result)
+	}
+	if sticker, err := bot.store.GetSticker("cccc3333"); err != nil || sticker.Name != "cat_2" {
+		t.Errorf("Expected cccc3333 renamed to cat_2, got %+v, %v", sticker, err)
+	}
+}
@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+// syncStore is a mautrix.SyncStore kept in sync.json in the data directory. The sync
+// token is only written once a sync's events have been handled (see processedSyncer),
+// and the filter ID is reused until the filter definition changes.
+type syncStore struct {
+	mu         sync.Mutex
+	dataDir    string
+	seed       string // Token from an older config.yaml, used if sync.json doesn't exist yet
+	filterHash string // Hash of the filter the bot currently wants
+	state      *storage.SyncState
+	pending    string // Token mautrix is syncing from, not yet fully processed
+}
+
+// newSyncStore creates a sync store for the data directory
+func newSyncStore(dataDir string, seed string, filter *mautrix.Filter) *syncStore {
+	return &syncStore{
+		dataDir:    dataDir,
+		seed:       seed,
+		filterHash: hashFilter(filter),
+	}
+}
+
+// load reads sync.json on first use; callers must hold mu
+func (s *syncStore) load() error {
+	if s.state != nil {
+		return nil
+	}
+
+	state, err := storage.LoadSyncState(s.dataDir)
+	if err != nil {
+		return err
+	}
+	if state.NextBatch == "" {
+		state.NextBatch = s.seed
+	}
+	s.state = state
+
+	return nil
+}
+
+func (s *syncStore) SaveFilterID(ctx context.Context, userID id.UserID, filterID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.state.FilterID = filterID
+	s.state.FilterHash = s.filterHash
+
+	return storage.SaveSyncState(s.dataDir, s.state)
+}
+
+// LoadFilterID returns the saved filter ID, or nothing if the filter has changed
+// since it was created so that a new one gets registered
+func (s *syncStore) LoadFilterID(ctx context.Context, userID id.UserID) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	if s.state.FilterHash != s.filterHash {
+		return "", nil
+	}
+
+	return s.state.FilterID, nil
+}
+
+// SaveNextBatch is called before a sync response is processed, so the token is only
+// held in memory until commit
+func (s *syncStore) SaveNextBatch(ctx context.Context, userID id.UserID, nextBatchToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = nextBatchToken
+	return nil
+}
+
+func (s *syncStore) LoadNextBatch(ctx context.Context, userID id.UserID) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending != "" {
+		return s.pending, nil
+	}
+	if err := s.load(); err != nil {
+		return "", err
+	}
+
+	return s.state.NextBatch, nil
+}
+
+// commit persists the token of a sync whose events have all been handled
+func (s *syncStore) commit(nextBatch string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if s.state.NextBatch == nextBatch {
+		return nil
+	}
+	s.state.NextBatch = nextBatch
+
+	return storage.SaveSyncState(s.dataDir, s.state)
+}
+
+// processedSyncer saves the sync token after each response has been processed,
+// so a crash replays at most the sync that was being handled
+type processedSyncer struct {
+	*mautrix.DefaultSyncer
+	store *syncStore
+}
+
+// ProcessResponse dispatches the sync's events, then records it as done
+func (s *processedSyncer) ProcessResponse(ctx context.Context, res *mautrix.RespSync, since string) error {
+	if err := s.DefaultSyncer.ProcessResponse(ctx, res, since); err != nil {
+		return err
+	}
+	if err := s.store.commit(res.NextBatch); err != nil {
+		return fmt.Errorf("failed to save sync position: %w", err)
+	}
+	return nil
+}
+
+// syncFilter limits syncs to the events the bot acts on. Timeline events are limited to
+// the given senders unless senders is nil (when whole homeservers are allowed, which a
+// filter can't express). With encryption, encrypted events and the room state needed to
+// track encrypted rooms and their members are kept too; membership changes come from
+// everyone in the room, so senders can't be limited then.
+func syncFilter(senders []id.UserID, encryption bool) *mautrix.Filter {
+	nothing := &mautrix.FilterPart{NotTypes: []event.Type{{Type: "*"}}}
+
+	timelineTypes := []event.Type{event.EventReaction, event.EventMessage, event.EventSticker}
+	state := nothing
+	if encryption {
+		cryptoTypes := []event.Type{event.StateMember, event.StateEncryption}
+		timelineTypes = append(timelineTypes, event.EventEncrypted)
+		timelineTypes = append(timelineTypes, cryptoTypes...)
+		state = &mautrix.FilterPart{Types: cryptoTypes}
+		senders = nil
+	}
+
+	return &mautrix.Filter{
+		AccountData: nothing,
+		Presence:    nothing,
+		Room: &mautrix.RoomFilter{
+			AccountData: nothing,
+			Ephemeral:   nothing,
+			State:       state,
+			Timeline: &mautrix.FilterPart{
+				Limit:   50,
+				Types:   timelineTypes,
+				Senders: senders,
+			},
+		},
+	}
+}
+
+// hashFilter fingerprints a filter definition so a changed filter gets re-registered
+func hashFilter(filter *mautrix.Filter) string {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+// TestSyncStore_CommitsAfterProcessing verifies the token is only persisted on commit
+func TestSyncStore_CommitsAfterProcessing(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stickerbook-sync-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	ctx := context.Background()
+	store := newSyncStore(tmpDir, "legacy_token", syncFilter(nil, false))
+
+	// An older config.yaml token is used until sync.json has one
+	token, err := store.LoadNextBatch(ctx, "@bot:example.org")
+	if err != nil {
+		t.Fatalf("Failed to load next batch: %v", err)
+	}
+	if token != "legacy_token" {
+		t.Errorf("Expected seeded token, got %q", token)
+	}
+
+	// mautrix saves the token before processing; that must not reach disk
+	if err := store.SaveNextBatch(ctx, "@bot:example.org", "s2"); err != nil {
+		t.Fatalf("Failed to save next batch: %v", err)
+	}
+	state, err := storage.LoadSyncState(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load sync state: %v", err)
+	}
+	if state.NextBatch != "" {
+		t.Errorf("Expected nothing persisted before processing, got %q", state.NextBatch)
+	}
+
+	if err := store.commit("s2"); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	state, err = storage.LoadSyncState(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load sync state: %v", err)
+	}
+	if state.NextBatch != "s2" {
+		t.Errorf("Expected committed token s2, got %q", state.NextBatch)
+	}
+
+	// A restarted bot resumes from the committed token, not the legacy one
+	restarted := newSyncStore(tmpDir, "legacy_token", syncFilter(nil, false))
+	if token, _ := restarted.LoadNextBatch(ctx, "@bot:example.org"); token != "s2" {
+		t.Errorf("Expected restart to resume from s2, got %q", token)
+	}
+}
+
+// TestSyncStore_FilterChanges verifies a saved filter is dropped when the filter changes
+func TestSyncStore_FilterChanges(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stickerbook-sync-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	ctx := context.Background()
+	store := newSyncStore(tmpDir, "", syncFilter([]id.UserID{"@bot:example.org"}, false))
+	if err := store.SaveFilterID(ctx, "@bot:example.org", "filter1"); err != nil {
+		t.Fatalf("Failed to save filter ID: %v", err)
+	}
+
+	same := newSyncStore(tmpDir, "", syncFilter([]id.UserID{"@bot:example.org"}, false))
+	if filterID, _ := same.LoadFilterID(ctx, "@bot:example.org"); filterID != "filter1" {
+		t.Errorf("Expected saved filter to be reused, got %q", filterID)
+	}
+
+	changed := newSyncStore(tmpDir, "", syncFilter([]id.UserID{"@bot:example.org", "@alice:example.org"}, false))
+	if filterID, _ := changed.LoadFilterID(ctx, "@bot:example.org"); filterID != "" {
+		t.Errorf("Expected changed filter to be re-registered, got %q", filterID)
+	}
+}
+
+// TestSyncFilter verifies the filter covers the bot's events and allowed senders
+func TestSyncFilter(t *testing.T) {
+	access := newAccessList("@bot:example.org", config.AccessConfig{Users: []config.AccessRule{
+		{Match: "@alice:example.org", Role: config.RoleViewer},
+	}})
+
+	filter := syncFilter(access.senders(), false)
+	timeline := filter.Room.Timeline
+	for _, evtType := range []event.Type{event.EventReaction, event.EventMessage, event.EventSticker} {
+		if !slices.Contains(timeline.Types, evtType) {
+			t.Errorf("Expected timeline to include %s", evtType.Type)
+		}
+	}
+	if slices.Contains(timeline.Types, event.EventEncrypted) {
+		t.Error("Expected no encrypted events without encryption")
+	}
+	if !slices.Equal(timeline.Senders, []id.UserID{"@alice:example.org", "@bot:example.org"}) {
+		t.Errorf("Unexpected senders: %v", timeline.Senders)
+	}
+
+	// Domain rules can't be expressed as senders
+	domains := newAccessList("@bot:example.org", config.AccessConfig{Users: []config.AccessRule{
+		{Match: "example.org", Role: config.RoleViewer},
+	}})
+	if senders := domains.senders(); senders != nil {
+		t.Errorf("Expected no sender limit with domain rules, got %v", senders)
+	}
+
+	encrypted := syncFilter(access.senders(), true)
+	if !slices.Contains(encrypted.Room.Timeline.Types, event.EventEncrypted) {
+		t.Error("Expected encrypted events with encryption")
+	}
+	if encrypted.Room.Timeline.Senders != nil {
+		t.Error("Expected no sender limit with encryption, as membership comes from everyone")
+	}
+}
@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix/id"
+)
+
+// stickerTag adds tags to a sticker
+func (b *Bot) stickerTag(stickerID string, args []string) string {
+	tags, err := storage.ParseTags(args)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	if len(tags) == 0 {
+		return "❌ No tags given"
+	}
+
+	if err := b.store.TagSticker(stickerID, tags); err != nil {
+		return fmt.Sprintf("❌ Error tagging sticker: %v", err)
+	}
+
+	return fmt.Sprintf("✅ Tagged `%s`: %s", stickerID, b.stickerTagList(stickerID))
+}
+
+// stickerUntag removes tags from a sticker
+func (b *Bot) stickerUntag(stickerID string, args []string) string {
+	tags, err := storage.ParseTags(args)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	if len(tags) == 0 {
+		return "❌ No tags given"
+	}
+
+	if err := b.store.UntagSticker(stickerID, tags); err != nil {
+		return fmt.Sprintf("❌ Error untagging sticker: %v", err)
+	}
+
+	return fmt.Sprintf("✅ Untagged `%s`: %s", stickerID, b.stickerTagList(stickerID))
+}
+
+// stickerTagList describes a sticker's current tags
+func (b *Bot) stickerTagList(stickerID string) string {
+	sticker, err := b.store.GetSticker(stickerID)
+	if err != nil || len(sticker.Tags) == 0 {
+		return "(no tags)"
+	}
+	return strings.Join(sticker.Tags, ", ")
+}
+
+// listTags lists a page of the tags in use with their sticker counts
+func (b *Bot) listTags(p page) string {
+	tags, err := b.store.ListTags()
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading tags: %v", err)
+	}
+
+	if len(tags) == 0 {
+		return "No stickers are tagged yet. Tag one with `!sticker tag <sticker-id> <tags>`"
+	}
+
+	if err := p.check(len(tags)); err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%d tag(s):\n\n", len(tags)))
+	start, end := p.bounds(len(tags))
+	for _, tag := range tags[start:end] {
+		result.WriteString(fmt.Sprintf("- **%s** (%d)\n", tag.Tag, tag.Count))
+	}
+	result.WriteString(p.footer(len(tags), "!sticker tags"))
+
+	return result.String()
+}
+
+// listTagged lists a page of the stickers matching a tag query, remembering the
+// listing so the user can pick stickers from it by number
+func (b *Bot) listTagged(sender id.UserID, queryText string, p page) string {
+	query, err := storage.ParseTagQuery(queryText)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	stickers, err := b.store.TaggedStickers(query)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading collection: %v", err)
+	}
+
+	if len(stickers) == 0 {
+		return fmt.Sprintf("No stickers tagged: %s", query)
+	}
+
+	return b.showStickers(sender, stickers, p, "!sticker list tag "+query.String(), stickerLine)
+}
+
+// packFromTags adds every sticker matching a tag query to a pack, creating the
+// pack first if it doesn't exist
+func (b *Bot) packFromTags(sender id.UserID, packName, queryText string) string {
+	query, err := storage.ParseTagQuery(queryText)
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+
+	stickers, err := b.store.TaggedStickers(query)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading collection: %v", err)
+	}
+	if len(stickers) == 0 {
+		return fmt.Sprintf("❌ No stickers tagged: %s", query)
+	}
+
+	var result strings.Builder
+	packID := sanitizePackName(packName)
+	if _, err := b.store.GetPack(packID); err != nil {
+		created := b.packCreate(packName, sender)
+		if strings.HasPrefix(created, "❌") {
+			return created
+		}
+		result.WriteString(created + "\n")
+	}
+
+	members, err := b.store.PackStickers(packID)
+	if err != nil {
+		return fmt.Sprintf("❌ Error loading pack: %v", err)
+	}
+	added, clashes := withoutClashes(packID, members, stickers)
+	if len(added) == 0 && len(clashes) == 0 {
+		return fmt.Sprintf("✅ Every sticker tagged %s is already in pack: %s", query, packID)
+	}
+
+	if len(added) > 0 {
+		if err := b.store.AddToPack(packID, added); err != nil {
+			return fmt.Sprintf("❌ Error adding to pack: %v", err)
+		}
+	}
+
+	result.WriteString(fmt.Sprintf("✅ Added %d sticker(s) tagged %s to pack: %s", len(added), query, packID))
+	if skipped := len(stickers) - len(added) - len(clashes); skipped > 0 {
+		result.WriteString(fmt.Sprintf(" (%d already there)", skipped))
+	}
+	if len(clashes) > 0 {
+		result.WriteString(fmt.Sprintf("\n\n⚠️ Left out %d sticker(s) whose shortcode is already used in the pack:\n", len(clashes)))
+		for _, clash := range clashes {
+			result.WriteString(fmt.Sprintf("- `%s` (:%s:, used by `%s`)\n", clash.StickerIDs[1], clash.Shortcode, clash.StickerIDs[0]))
+		}
+		result.WriteString("\nRename them with `!sticker name <id> <shortcode>` and run this again")
+	}
+
+	return result.String() + b.refreshPersonal(packID)
+}
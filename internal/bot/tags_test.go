@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// TestExecuteCommand_Tags verifies tagging, listing by tag and filling a pack from tags
+func TestExecuteCommand_Tags(t *testing.T) {
+	bot, tmpDir := setupTestBot(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	defer bot.Stop()
+
+	for _, s := range []storage.Sticker{
+		{ID: "aaaa1111", Name: "grumpy", InPacks: []string{}},
+		{ID: "bbbb2222", Name: "smiley", InPacks: []string{}},
+		{ID: "cccc3333", Name: "crying", InPacks: []string{}},
+	} {
+		if err := bot.store.AddSticker(s); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	user := bot.client.UserID
+	run := func(command string) string {
+		t.Helper()
+		return bot.executeCommand(ctx, user, command)
+	}
+
+	if result := run("!sticker tag :grumpy: Cat,grumpy"); !strings.Contains(result, "✅ Tagged `aaaa1111`: cat, grumpy") {
+		t.Errorf("Expected tags listed, got: %s", result)
+	}
+	run("!sticker tag bbbb cat happy")
+	run("!sticker tag cccc cat sad")
+	if result := run("!sticker tag cccc no!"); !strings.Contains(result, "❌") {
+		t.Errorf("Expected invalid tag error, got: %s", result)
+	}
+
+	result := run("!sticker tags")
+	if !strings.Contains(result, "**cat** (3)") || !strings.Contains(result, "**sad** (1)") {
+		t.Errorf("Expected tag index, got: %s", result)
+	}
+
+	result = run("!sticker list tag cat -sad")
+	if !strings.Contains(result, "1. `aaaa1111`") || !strings.Contains(result, "2. `bbbb2222`") || strings.Contains(result, "cccc3333") {
+		t.Errorf("Expected cat stickers without sad, got: %s", result)
+	}
+	// The listing can be picked from by number
+	if stickerID, err := bot.resolveSticker(user, "2"); err != nil || stickerID != "bbbb2222" {
+		t.Errorf("Expected listing position 2 to be bbbb2222, got %s, %v", stickerID, err)
+	}
+
+	result = run("!sticker pack fromtag Cats cat -sad")
+	if !strings.Contains(result, "Created pack") || !strings.Contains(result, "Added 2 sticker(s)") {
+		t.Errorf("Expected pack created and filled, got: %s", result)
+	}
+	pack, err := bot.store.GetPack("cats")
+	if err != nil || len(pack.StickerIDs) != 2 {
+		t.Fatalf("Expected pack with 2 stickers, got %v, %v", pack, err)
+	}
+
+	result = run("!sticker pack fromtag cats cat")
+	if !strings.Contains(result, "Added 1 sticker(s)") || !strings.Contains(result, "2 already there") {
+		t.Errorf("Expected only the new sticker added, got: %s", result)
+	}
+
+	run("!sticker untag aaaa grumpy cat")
+	if result := run("!sticker show aaaa"); strings.Contains(result, "Tags") {
+		t.Errorf("Expected no tags shown, got: %s", result)
+	}
+	if result := run("!sticker list tag grumpy"); !strings.Contains(result, "No stickers tagged") {
+		t.Errorf("Expected no grumpy stickers, got: %s", result)
+	}
+}
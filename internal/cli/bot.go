@@ -6,12 +6,14 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/liminalpurple/matrix-stickerbook/internal/bot"
 	"github.com/liminalpurple/matrix-stickerbook/internal/config"
 	"github.com/liminalpurple/matrix-stickerbook/internal/llm"
 	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -22,15 +24,22 @@ func NewBotCmd() *cobra.Command {
 		Short: "Run the sticker collection bot",
 		Long: `Run the Matrix bot that watches for reaction commands and collects stickers.
 
-The bot monitors all rooms for reactions from your user account. When it detects
-a !yoink, !nom, or !grab reaction, it:
+The bot monitors all rooms for reactions from your user account (and any users
+granted access in the config). When it detects
+a !yoink, !nom, or !grab reaction (or the reactions set under collection in the
+config), it:
 
   1. Downloads the image from the source homeserver
   2. Re-uploads it to your local homeserver (rehosting)
-  3. Generates alt-text using Claude vision API
-  4. Saves the sticker to your collection
+  3. Generates alt-text with the configured provider (Claude by default, or an
+     OpenAI-compatible API, Ollama, or none to keep the original description)
+  4. Saves the sticker to your collection, and into a pack for !yoink:<pack>
+     or a configured shortcut, republishing the pack if it's published
   5. Redacts the reaction to confirm collection
 
+Set matrix.encryption in the config to also work in encrypted rooms (this needs
+a binary built with -tags e2ee).
+
 The bot runs until interrupted with Ctrl+C.`,
 		RunE: runBot,
 	}
@@ -47,10 +56,16 @@ func runBot(cmd *cobra.Command, args []string) error {
 	if cfg.Matrix.AccessToken == "" {
 		return fmt.Errorf("no access token configured - run 'stickerbook login' first")
 	}
-	if cfg.Anthropic.APIKey == "" {
-		return fmt.Errorf("no Anthropic API key configured - set ANTHROPIC_API_KEY or add to config.yaml")
+
+	// Create alt-text generator
+	log.Println("Creating alt-text generator...")
+	llmClient, err := llm.NewGenerator(cfg)
+	if err != nil {
+		return err
 	}
 
+	log.Printf("Using alt-text provider: %s", llmClient.Name())
+
 	log.Println("Creating Matrix client...")
 	matrixClient, err := matrix.NewClient(
 		cfg.Matrix.Homeserver,
@@ -69,19 +84,27 @@ func runBot(cmd *cobra.Command, args []string) error {
 
 	log.Printf("Connected as %s", matrixClient.UserID)
 
-	// Create LLM client
-	log.Println("Creating LLM client...")
-	llmClient := llm.NewClient(
-		cfg.Anthropic.APIKey,
-		cfg.Anthropic.Model,
-		cfg.Anthropic.MaxTokens,
-	)
+	// Set up end-to-end encryption before the first sync
+	if cfg.Matrix.Encryption {
+		if err := enableEncryption(ctx, cfg, matrixClient); err != nil {
+			return err
+		}
+		defer func() { _ = matrixClient.CloseEncryption() }()
+		log.Printf("End-to-end encryption enabled (device %s)", matrixClient.DeviceID)
+	}
 
-	log.Printf("Using model: %s (max tokens: %d)", llmClient.Model(), llmClient.MaxTokens())
+	// Open sticker storage
+	store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.DataDir)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	log.Printf("Using %s storage in %s", cfg.Storage.Backend, cfg.Storage.DataDir)
 
 	// Create bot
 	log.Println("Starting bot...")
-	stickerbookBot := bot.NewBot(matrixClient, llmClient, cfg)
+	stickerbookBot := bot.NewBot(matrixClient, llmClient, store, cfg)
 
 	// Set up graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -111,3 +134,28 @@ func runBot(cmd *cobra.Command, args []string) error {
 	log.Println("Bot stopped")
 	return nil
 }
+
+// enableEncryption sets up the crypto store in the data directory. Its pickle key is
+// kept next to it, generated the first time, unless the config still has one from an
+// older version.
+func enableEncryption(ctx context.Context, cfg *config.Config, matrixClient *matrix.Client) error {
+	if err := os.MkdirAll(cfg.Storage.DataDir, 0700); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	pickleKey := cfg.Matrix.PickleKey
+	if pickleKey == "" {
+		key, err := matrix.LoadPickleKey(cfg.Storage.DataDir)
+		if err != nil {
+			return err
+		}
+		pickleKey = key
+	}
+
+	dbPath := filepath.Join(cfg.Storage.DataDir, matrix.CryptoDatabase)
+	if err := matrixClient.EnableEncryption(ctx, dbPath, []byte(pickleKey)); err != nil {
+		return fmt.Errorf("failed to enable encryption: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/bot"
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/llm"
+	"github.com/liminalpurple/matrix-stickerbook/internal/matrix"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"github.com/spf13/cobra"
+	"maunium.net/go/mautrix/id"
+)
+
+// NewImportCmd creates the import command
+func NewImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <room-id> [state-key]",
+		Short: "Import a room's sticker pack into the collection",
+		Long: `Import an existing MSC2545 sticker pack (im.ponies.room_emotes state event)
+from a Matrix room into your collection.
+
+Each image is downloaded, rehosted to your homeserver and given alt-text, just
+like a sticker collected with !yoink. A local pack is created with the original
+shortcodes, display name, avatar, and usage. Most rooms keep their pack under
+an empty state key; pass one to import a different pack from the same room.
+
+Images are collected through the bot's job queue (jobs.json), which only one
+process can use at a time: while the bot is running, use !sticker pack import
+instead. Images that fail are left in the queue for the bot to retry.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runImport,
+	}
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	roomID := args[0]
+	if !strings.HasPrefix(roomID, "!") {
+		return fmt.Errorf("invalid room ID %q - must start with !", roomID)
+	}
+	stateKey := ""
+	if len(args) > 1 {
+		stateKey = args[1]
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Verify required configuration
+	if cfg.Matrix.AccessToken == "" {
+		return fmt.Errorf("no access token configured - run 'stickerbook login' first")
+	}
+
+	llmClient, err := llm.NewGenerator(cfg)
+	if err != nil {
+		return err
+	}
+
+	matrixClient, err := matrix.NewClient(
+		cfg.Matrix.Homeserver,
+		cfg.Matrix.UserID,
+		cfg.Matrix.AccessToken,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create Matrix client: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := matrixClient.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to Matrix: %w", err)
+	}
+
+	store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.DataDir)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	// The bot owns the collection pipeline; it isn't started, just used to import
+	stickerbookBot := bot.NewBot(matrixClient, llmClient, store, cfg)
+
+	fmt.Printf("📥 Importing pack from %s...\n", roomID)
+	result, err := stickerbookBot.ImportPack(ctx, matrixClient.UserID, id.RoomID(roomID), stateKey)
+	if errors.Is(err, storage.ErrJobsInUse) {
+		return fmt.Errorf("the bot is running from %s - stop it first, or use !sticker pack import instead", cfg.Storage.DataDir)
+	}
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+	defer stickerbookBot.Stop()
+
+	fmt.Printf("📥 Created pack %s from '%s', collecting %d image(s)...\n", result.PackName, result.DisplayName, result.Queued)
+
+	// Nothing else works through the queue while the bot isn't running, so collect here
+	remaining, err := stickerbookBot.RunJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to run collection jobs: %w", err)
+	}
+
+	failed := 0
+	for _, job := range remaining {
+		if job.Pack != result.PackName {
+			continue
+		}
+		failed++
+		fmt.Printf("   ⚠️ %s (%s) at %s: %s\n", job.SourceMXC, job.Status, job.Stage, job.LastError)
+	}
+	fmt.Printf("✅ Imported pack '%s' as %s: %d of %d image(s) collected\n",
+		result.DisplayName, result.PackName, result.Queued-failed, result.Queued)
+	if failed > 0 {
+		fmt.Println("   The rest stay in the job queue - the bot retries them when it starts, see !sticker jobs")
+	}
+
+	return nil
+}
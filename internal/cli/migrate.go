@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewMigrateCmd creates the migrate command
+func NewMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade data files to the current schema version",
+		Long: `Upgrade collection.json and packs.json to the schema version used by this build.
+
+Each file is backed up as <file>.v<version>.bak before it is rewritten. Files
+written by a newer version of stickerbook are refused rather than downgraded.
+
+The bot also migrates on startup, so this is mainly useful with --dry-run to
+preview what an upgrade will change. With the SQLite backend, the database
+schema is upgraded as well.`,
+		RunE: runMigrate,
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show what would be migrated without changing anything")
+
+	return cmd
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	results, err := storage.Migrate(cfg.Storage.DataDir, dryRun)
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("Data files in %s are up to date (schema version %d)\n", cfg.Storage.DataDir, storage.CurrentSchemaVersion)
+	}
+	for _, result := range results {
+		if dryRun {
+			fmt.Printf("Would migrate %s from schema version %d to %d:\n", result.File, result.FromVersion, result.ToVersion)
+		} else {
+			fmt.Printf("Migrated %s from schema version %d to %d:\n", result.File, result.FromVersion, result.ToVersion)
+		}
+		for _, step := range result.Steps {
+			fmt.Printf("  - %s\n", step)
+		}
+		if result.BackupPath != "" {
+			fmt.Printf("  Backup: %s\n", result.BackupPath)
+		}
+	}
+
+	// The SQLite backend applies its own schema migrations when opened
+	if cfg.Storage.Backend == storage.BackendSQLite && !dryRun {
+		store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.DataDir)
+		if err != nil {
+			return fmt.Errorf("failed to open storage: %w", err)
+		}
+		if err := store.Close(); err != nil {
+			return fmt.Errorf("failed to close storage: %w", err)
+		}
+		fmt.Println("SQLite database is up to date")
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// NewSearchCmd creates the search command
+func NewSearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the sticker collection",
+		Long: `Search collected stickers by shortcode, tags, alt-text, original description,
+source room and pack, best match first. Quote phrases to match them whole.
+
+Filters narrow the results:
+
+  pack:<pack>            Stickers in a pack (pack:unsorted for stickers in none)
+  tag:<tag>              Stickers with this tag
+  usage:<type>           Stickers usable as a sticker or emoticon
+  mime:<type>            Stickers whose MIME type contains this, e.g. mime:gif
+  before:YYYY-MM-DD      Collected before this day
+  after:YYYY-MM-DD       Collected after this day
+
+Example: stickerbook search '"waving paw"' pack:cats mime:gif`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runSearch,
+	}
+
+	cmd.Flags().Int("page", 1, "Page of results to show")
+	cmd.Flags().Int("limit", 20, "Results per page")
+
+	return cmd
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	page, _ := cmd.Flags().GetInt("page")
+	limit, _ := cmd.Flags().GetInt("limit")
+	if page < 1 || limit < 1 {
+		return fmt.Errorf("--page and --limit must be positive")
+	}
+
+	query, err := storage.ParseSearchQuery(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.DataDir)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	results, err := storage.Search(store, query)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("No stickers match")
+		return nil
+	}
+
+	start := min((page-1)*limit, len(results))
+	end := min(start+limit, len(results))
+	pages := (len(results) + limit - 1) / limit
+	if start == end {
+		return fmt.Errorf("page %d is past the end of the results (%d page(s))", page, pages)
+	}
+
+	for i, result := range results[start:end] {
+		sticker := result.Sticker
+		packs := "unsorted"
+		if len(sticker.InPacks) > 0 {
+			packs = strings.Join(sticker.InPacks, ", ")
+		}
+		fmt.Printf("%3d. %s :%s: [%s]\n     %s\n", start+i+1, sticker.ID, sticker.Name, packs, sticker.GeneratedAltText)
+	}
+	fmt.Printf("\nPage %d of %d (%d result(s))\n", page, pages, len(results))
+
+	return nil
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/liminalpurple/matrix-stickerbook/internal/config"
@@ -22,7 +23,7 @@ func NewTestCmd() *cobra.Command {
   - Configuration loads properly
   - Matrix connection and authentication
   - Media download/upload
-  - Claude vision API (alt-text generation)
+  - Alt-text generation with the configured provider
   - Storage operations
 
 This is useful for verifying setup before running the bot.`,
@@ -49,9 +50,6 @@ func runTest(cmd *cobra.Command, args []string) error {
 	if cfg.Matrix.AccessToken == "" {
 		return fmt.Errorf("no access token configured")
 	}
-	if cfg.Anthropic.APIKey == "" {
-		return fmt.Errorf("no Anthropic API key configured")
-	}
 
 	// Test 2: Matrix connection
 	fmt.Print("🔌 Connecting to Matrix... ")
@@ -75,14 +73,14 @@ func runTest(cmd *cobra.Command, args []string) error {
 	fmt.Printf("✅\n   Logged in as: %s\n", matrixClient.UserID)
 	fmt.Println()
 
-	// Test 4: Create LLM client
-	fmt.Print("🤖 Creating LLM client... ")
-	llmClient := llm.NewClient(
-		cfg.Anthropic.APIKey,
-		cfg.Anthropic.Model,
-		cfg.Anthropic.MaxTokens,
-	)
-	fmt.Printf("✅\n   Model: %s (max tokens: %d)\n", llmClient.Model(), llmClient.MaxTokens())
+	// Test 4: Create alt-text generator
+	fmt.Print("🤖 Creating alt-text generator... ")
+	llmClient, err := llm.NewGenerator(cfg)
+	if err != nil {
+		fmt.Printf("❌\n   Error: %v\n", err)
+		return err
+	}
+	fmt.Printf("✅\n   Provider: %s\n", llmClient.Name())
 	fmt.Println()
 
 	// Test 5: Generate test image and upload
@@ -117,18 +115,25 @@ func runTest(cmd *cobra.Command, args []string) error {
 	fmt.Printf("✅\n   Dimensions: %dx%d, MIME: %s\n", imageInfo.Width, imageInfo.Height, imageInfo.MimeType)
 
 	// Test 8: Generate alt-text
-	fmt.Print("✨ Generating alt-text with Claude... ")
-	altText, err := llmClient.GenerateAltText(ctx, downloadedData, imageInfo.MimeType)
+	fmt.Print("✨ Generating alt-text... ")
+	description, err := llmClient.Describe(ctx, downloadedData, imageInfo.MimeType, llm.BuildPrompt(cfg.AltText.Prompt, cfg.AltText.LanguageCodes()[0]))
 	if err != nil {
 		fmt.Printf("❌\n   Error: %v\n", err)
 		return err
 	}
-	fmt.Printf("✅\n   Alt-text: %s\n", altText)
+	fmt.Printf("✅\n   Alt-text: %s\n   Tags: %s\n   Shortcode: %s\n", description.AltText, strings.Join(description.Tags, ", "), description.Shortcode)
 	fmt.Println()
 
 	// Test 9: Storage operations
 	fmt.Print("💾 Testing storage operations... ")
 
+	store, err := storage.Open(cfg.Storage.Backend, cfg.Storage.DataDir)
+	if err != nil {
+		fmt.Printf("❌\n   Error: %v\n", err)
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
 	// Create test sticker
 	testSticker := storage.Sticker{
 		ID:               matrix.HashImage(downloadedData),
@@ -142,18 +147,18 @@ func runTest(cmd *cobra.Command, args []string) error {
 		Height:           imageInfo.Height,
 		SizeBytes:        imageInfo.SizeBytes,
 		OriginalBody:     "Test sticker",
-		GeneratedAltText: altText,
+		GeneratedAltText: description.AltText,
 		InPacks:          []string{},
 	}
 
 	// Save to collection
-	if err := storage.AddSticker(cfg.Storage.DataDir, testSticker); err != nil {
+	if err := store.AddSticker(testSticker); err != nil {
 		fmt.Printf("❌\n   Error: %v\n", err)
 		return err
 	}
 
 	// Retrieve it back
-	retrieved, err := storage.GetSticker(cfg.Storage.DataDir, testSticker.ID)
+	retrieved, err := store.GetSticker(testSticker.ID)
 	if err != nil {
 		fmt.Printf("❌\n   Error: %v\n", err)
 		return err
@@ -164,7 +169,7 @@ func runTest(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("storage verification failed")
 	}
 
-	fmt.Printf("✅\n   Saved and retrieved test sticker: %s\n", testSticker.ID[:16]+"...")
+	fmt.Printf("✅\n   Saved and retrieved test sticker: %s (%s storage)\n", testSticker.ID[:16]+"...", cfg.Storage.Backend)
 	fmt.Println()
 
 	// All tests passed!
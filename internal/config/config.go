@@ -6,15 +6,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Matrix    MatrixConfig    `mapstructure:"matrix" yaml:"matrix"`
-	Anthropic AnthropicConfig `mapstructure:"anthropic" yaml:"anthropic"`
-	Storage   StorageConfig   `mapstructure:"storage" yaml:"storage"`
+	Matrix     MatrixConfig     `mapstructure:"matrix" yaml:"matrix"`
+	AltText    AltTextConfig    `mapstructure:"alt_text" yaml:"alt_text"`
+	Anthropic  AnthropicConfig  `mapstructure:"anthropic" yaml:"anthropic"`
+	OpenAI     OpenAIConfig     `mapstructure:"openai" yaml:"openai"`
+	Ollama     OllamaConfig     `mapstructure:"ollama" yaml:"ollama"`
+	Storage    StorageConfig    `mapstructure:"storage" yaml:"storage"`
+	Access     AccessConfig     `mapstructure:"access" yaml:"access"`
+	Jobs       JobsConfig       `mapstructure:"jobs" yaml:"jobs"`
+	Collection CollectionConfig `mapstructure:"collection" yaml:"collection"`
 }
 
 // MatrixConfig holds Matrix connection settings
@@ -23,7 +30,119 @@ type MatrixConfig struct {
 	UserID      string `mapstructure:"user_id" yaml:"user_id"`
 	DeviceID    string `mapstructure:"device_id" yaml:"device_id"`
 	AccessToken string `mapstructure:"access_token" yaml:"access_token"`
-	NextBatch   string `mapstructure:"next_batch" yaml:"next_batch"`
+	NextBatch   string `mapstructure:"next_batch" yaml:"next_batch"` // Legacy: sync position now lives in sync.json, read once to resume
+	Encryption  bool   `mapstructure:"encryption" yaml:"encryption"` // Enable end-to-end encryption (needs an e2ee build)
+	PickleKey   string `mapstructure:"pickle_key" yaml:"pickle_key"` // Protects the crypto store; older versions saved it here, newer ones in the data directory
+}
+
+// AltTextConfig selects how stickers are described
+type AltTextConfig struct {
+	Provider   string        `mapstructure:"provider" yaml:"provider"`     // "anthropic", "openai", "ollama" or "none"
+	Prompt     string        `mapstructure:"prompt" yaml:"prompt"`         // Replaces the built-in prompt if set
+	Languages  []string      `mapstructure:"languages" yaml:"languages"`   // Language codes to describe stickers in, main one first
+	Packs      []PackAltText `mapstructure:"packs" yaml:"packs"`           // Per-pack overrides
+	Shortcodes string        `mapstructure:"shortcodes" yaml:"shortcodes"` // What to do with suggested shortcodes: "auto", "suggest" or "off"
+}
+
+// PackAltText overrides the alt-text settings for one pack
+type PackAltText struct {
+	Pack     string `mapstructure:"pack" yaml:"pack"`
+	Prompt   string `mapstructure:"prompt" yaml:"prompt"`     // Prompt for stickers collected into the pack
+	Language string `mapstructure:"language" yaml:"language"` // Language of sticker bodies when the pack is published
+}
+
+// DefaultLanguages are the alt-text languages when none are configured
+var DefaultLanguages = []string{"en"}
+
+// Alt-text providers
+const (
+	ProviderAnthropic = "anthropic" // Claude vision, configured under anthropic
+	ProviderOpenAI    = "openai"    // Any OpenAI-compatible chat completions API, configured under openai
+	ProviderOllama    = "ollama"    // A local Ollama server, configured under ollama
+	ProviderNone      = "none"      // No model; stickers keep their original description
+)
+
+// Suggested shortcode modes
+const (
+	ShortcodesAuto    = "auto"    // Name new stickers with the model's suggestion
+	ShortcodesSuggest = "suggest" // Keep the suggestion for a curator to accept
+	ShortcodesOff     = "off"     // Ignore suggestions
+)
+
+// Validate checks that the provider and shortcode mode are known, languages aren't
+// repeated, and every pack override names a pack and a configured language
+func (c AltTextConfig) Validate() error {
+	switch c.Provider {
+	case "", ProviderAnthropic, ProviderOpenAI, ProviderOllama, ProviderNone:
+	default:
+		return fmt.Errorf("unknown provider %q (valid: %s, %s, %s, %s)", c.Provider, ProviderAnthropic, ProviderOpenAI, ProviderOllama, ProviderNone)
+	}
+	switch c.Shortcodes {
+	case "", ShortcodesAuto, ShortcodesSuggest, ShortcodesOff:
+	default:
+		return fmt.Errorf("unknown shortcodes mode %q (valid: %s, %s, %s)", c.Shortcodes, ShortcodesAuto, ShortcodesSuggest, ShortcodesOff)
+	}
+
+	for i, language := range c.Languages {
+		if language == "" {
+			return fmt.Errorf("language %d is empty", i+1)
+		}
+		if slices.Contains(c.Languages[:i], language) {
+			return fmt.Errorf("language %s is listed twice", language)
+		}
+	}
+	for i, pack := range c.Packs {
+		if pack.Pack == "" {
+			return fmt.Errorf("pack override %d has no pack", i+1)
+		}
+		if pack.Language != "" && !slices.Contains(c.LanguageCodes(), pack.Language) {
+			return fmt.Errorf("pack %s uses language %s, which isn't in languages", pack.Pack, pack.Language)
+		}
+	}
+	return nil
+}
+
+// LanguageCodes returns the languages to describe stickers in, or DefaultLanguages if
+// none are configured. The first is the main one.
+func (c AltTextConfig) LanguageCodes() []string {
+	if len(c.Languages) == 0 {
+		return DefaultLanguages
+	}
+	return c.Languages
+}
+
+// PackLanguage returns the language a pack is published in: its override, or the
+// first configured language
+func (c AltTextConfig) PackLanguage(pack string) string {
+	if override := c.pack(pack); override != nil && override.Language != "" {
+		return override.Language
+	}
+	return c.LanguageCodes()[0]
+}
+
+// PackPrompt returns the prompt for a sticker in the given packs (none, for an
+// unsorted sticker): the first of their overrides, or the configured prompt. An
+// empty result means the built-in prompt.
+func (c AltTextConfig) PackPrompt(packs ...string) string {
+	for _, pack := range packs {
+		if override := c.pack(pack); override != nil && override.Prompt != "" {
+			return override.Prompt
+		}
+	}
+	return c.Prompt
+}
+
+// pack returns the override for a pack, or nil if it has none
+func (c AltTextConfig) pack(pack string) *PackAltText {
+	if pack == "" {
+		return nil
+	}
+	for i := range c.Packs {
+		if c.Packs[i].Pack == pack {
+			return &c.Packs[i]
+		}
+	}
+	return nil
 }
 
 // AnthropicConfig holds Anthropic API settings
@@ -31,11 +150,121 @@ type AnthropicConfig struct {
 	APIKey    string `mapstructure:"api_key" yaml:"api_key"`
 	Model     string `mapstructure:"model" yaml:"model"`
 	MaxTokens int    `mapstructure:"max_tokens" yaml:"max_tokens"`
+	BaseURL   string `mapstructure:"base_url" yaml:"base_url"` // Optional, for a proxy in front of the API
+}
+
+// OpenAIConfig holds settings for an OpenAI-compatible chat completions API, such as
+// OpenAI itself, vLLM, llama.cpp's server or LM Studio
+type OpenAIConfig struct {
+	BaseURL   string `mapstructure:"base_url" yaml:"base_url"` // API root, e.g. https://api.openai.com/v1
+	APIKey    string `mapstructure:"api_key" yaml:"api_key"`   // Optional for self-hosted servers
+	Model     string `mapstructure:"model" yaml:"model"`
+	MaxTokens int    `mapstructure:"max_tokens" yaml:"max_tokens"`
+}
+
+// OllamaConfig holds settings for a local Ollama server
+type OllamaConfig struct {
+	URL       string `mapstructure:"url" yaml:"url"`     // Server URL, e.g. http://localhost:11434
+	Model     string `mapstructure:"model" yaml:"model"` // A vision model, e.g. llava
+	MaxTokens int    `mapstructure:"max_tokens" yaml:"max_tokens"`
 }
 
 // StorageConfig holds storage settings
 type StorageConfig struct {
 	DataDir string `mapstructure:"data_dir" yaml:"data_dir"`
+	Backend string `mapstructure:"backend" yaml:"backend"` // "json" (default) or "sqlite"
+
+	// JournalRetentionDays is how long handled events are remembered, so replayed
+	// reactions and commands aren't run twice
+	JournalRetentionDays int `mapstructure:"journal_retention_days" yaml:"journal_retention_days"`
+}
+
+// JobsConfig controls the background sticker collection queue
+type JobsConfig struct {
+	Workers     int `mapstructure:"workers" yaml:"workers"`           // Collections run at once
+	MaxAttempts int `mapstructure:"max_attempts" yaml:"max_attempts"` // Tries per stage before a job fails
+}
+
+// CollectionConfig controls which reactions collect a sticker, and how collecting
+// is reported back in the room
+type CollectionConfig struct {
+	Feedback  string             `mapstructure:"feedback" yaml:"feedback"`   // "quiet", "reaction" or "verbose"
+	Reactions []string           `mapstructure:"reactions" yaml:"reactions"` // Collect unsorted, or into a pack with "<reaction>:<pack>"
+	Shortcuts []ReactionShortcut `mapstructure:"shortcuts" yaml:"shortcuts"` // Collect straight into a pack
+}
+
+// ReactionShortcut is a reaction key that collects a sticker into a pack
+type ReactionShortcut struct {
+	Key  string `mapstructure:"key" yaml:"key"`
+	Pack string `mapstructure:"pack" yaml:"pack"`
+}
+
+// DefaultReactions are the reactions that collect a sticker when none are configured
+var DefaultReactions = []string{"!yoink", "!nom", "!grab"}
+
+// Collection feedback modes
+const (
+	FeedbackQuiet    = "quiet"    // Only redact the reaction once the sticker is collected
+	FeedbackReaction = "reaction" // Also react ✅ or ❌ to the image
+	FeedbackVerbose  = "verbose"  // Reply to the image with the sticker's details or the error
+)
+
+// Validate checks that the feedback mode is known and every shortcut has a key and pack
+func (c CollectionConfig) Validate() error {
+	switch c.Feedback {
+	case "", FeedbackQuiet, FeedbackReaction, FeedbackVerbose:
+	default:
+		return fmt.Errorf("unknown feedback mode %q (valid: %s, %s, %s)", c.Feedback, FeedbackQuiet, FeedbackReaction, FeedbackVerbose)
+	}
+
+	for i, reaction := range c.Reactions {
+		if reaction == "" {
+			return fmt.Errorf("reaction %d is empty", i+1)
+		}
+	}
+	for i, shortcut := range c.Shortcuts {
+		if shortcut.Key == "" {
+			return fmt.Errorf("shortcut %d has no key", i+1)
+		}
+		if shortcut.Pack == "" {
+			return fmt.Errorf("shortcut for %s has no pack", shortcut.Key)
+		}
+	}
+	return nil
+}
+
+// AccessConfig controls who besides the bot's own account may use the bot
+type AccessConfig struct {
+	Users []AccessRule `mapstructure:"users" yaml:"users"`
+}
+
+// AccessRule grants a role to one Matrix ID (@user:server) or to every user on a
+// homeserver domain (server)
+type AccessRule struct {
+	Match string `mapstructure:"match" yaml:"match"`
+	Role  string `mapstructure:"role" yaml:"role"` // "admin", "curator" or "viewer"
+}
+
+// Access roles, from least to most privileged
+const (
+	RoleViewer  = "viewer"  // List and show stickers and packs
+	RoleCurator = "curator" // Also collect stickers and edit packs
+	RoleAdmin   = "admin"   // Also delete, rename, unpublish, and manage account data
+)
+
+// Validate checks that every rule has a match and a known role
+func (a AccessConfig) Validate() error {
+	for i, rule := range a.Users {
+		if rule.Match == "" {
+			return fmt.Errorf("access rule %d has no match", i+1)
+		}
+		switch rule.Role {
+		case RoleViewer, RoleCurator, RoleAdmin:
+		default:
+			return fmt.Errorf("access rule for %s has unknown role %q (valid: %s, %s, %s)", rule.Match, rule.Role, RoleViewer, RoleCurator, RoleAdmin)
+		}
+	}
+	return nil
 }
 
 // Load reads configuration from file and environment variables
@@ -45,6 +274,15 @@ func Load() (*Config, error) {
 	// Set defaults
 	v.SetDefault("anthropic.model", "claude-3-haiku-20240307")
 	v.SetDefault("anthropic.max_tokens", 100)
+	v.SetDefault("alt_text.provider", ProviderAnthropic)
+	v.SetDefault("alt_text.languages", DefaultLanguages)
+	v.SetDefault("alt_text.shortcodes", ShortcodesAuto)
+	v.SetDefault("openai.base_url", "https://api.openai.com/v1")
+	v.SetDefault("openai.model", "gpt-4o-mini")
+	v.SetDefault("openai.max_tokens", 100)
+	v.SetDefault("ollama.url", "http://localhost:11434")
+	v.SetDefault("ollama.model", "llava")
+	v.SetDefault("ollama.max_tokens", 100)
 
 	// Determine config directory
 	configDir, err := getConfigDir()
@@ -54,6 +292,12 @@ func Load() (*Config, error) {
 
 	// Set default storage directory
 	v.SetDefault("storage.data_dir", configDir)
+	v.SetDefault("storage.backend", "json")
+	v.SetDefault("storage.journal_retention_days", 30)
+	v.SetDefault("jobs.workers", 2)
+	v.SetDefault("jobs.max_attempts", 5)
+	v.SetDefault("collection.feedback", FeedbackReaction)
+	v.SetDefault("collection.reactions", DefaultReactions)
 
 	// Configure viper to read from config file
 	v.SetConfigName("config")
@@ -76,6 +320,7 @@ func Load() (*Config, error) {
 	// Specific env var bindings
 	_ = v.BindEnv("matrix.access_token", "MATRIX_ACCESS_TOKEN")
 	_ = v.BindEnv("anthropic.api_key", "ANTHROPIC_API_KEY")
+	_ = v.BindEnv("openai.api_key", "OPENAI_API_KEY")
 
 	// Unmarshal into config struct
 	var cfg Config
@@ -83,6 +328,16 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := cfg.AltText.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid alt_text config: %w", err)
+	}
+	if err := cfg.Access.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid access config: %w", err)
+	}
+	if err := cfg.Collection.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid collection config: %w", err)
+	}
+
 	return &cfg, nil
 }
 
@@ -102,8 +357,14 @@ func Save(cfg *Config) error {
 
 	v := viper.New()
 	v.Set("matrix", cfg.Matrix)
+	v.Set("alt_text", cfg.AltText)
 	v.Set("anthropic", cfg.Anthropic)
+	v.Set("openai", cfg.OpenAI)
+	v.Set("ollama", cfg.Ollama)
 	v.Set("storage", cfg.Storage)
+	v.Set("access", cfg.Access)
+	v.Set("jobs", cfg.Jobs)
+	v.Set("collection", cfg.Collection)
 
 	if err := v.WriteConfigAs(configPath); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
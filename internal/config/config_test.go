@@ -73,3 +73,145 @@ func TestSavePermissions(t *testing.T) {
 		t.Errorf("Expected permissions 0600, got %o", info.Mode().Perm())
 	}
 }
+
+// TestAccessConfigValidate verifies access rules need a match and a known role
+func TestAccessConfigValidate(t *testing.T) {
+	valid := AccessConfig{Users: []AccessRule{
+		{Match: "@alice:example.org", Role: RoleAdmin},
+		{Match: "example.org", Role: RoleViewer},
+	}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	for _, rule := range []AccessRule{
+		{Match: "", Role: RoleAdmin},
+		{Match: "@bob:example.org", Role: "owner"},
+	} {
+		if err := (AccessConfig{Users: []AccessRule{rule}}).Validate(); err == nil {
+			t.Errorf("Expected error for rule %+v", rule)
+		}
+	}
+}
+
+// TestCollectionConfigValidate verifies feedback modes, reactions and shortcuts are checked
+func TestCollectionConfigValidate(t *testing.T) {
+	for _, mode := range []string{"", FeedbackQuiet, FeedbackReaction, FeedbackVerbose} {
+		if err := (CollectionConfig{Feedback: mode}).Validate(); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", mode, err)
+		}
+	}
+
+	if err := (CollectionConfig{Feedback: "loud"}).Validate(); err == nil {
+		t.Error("Expected error for unknown feedback mode")
+	}
+
+	valid := CollectionConfig{
+		Reactions: []string{"!yoink"},
+		Shortcuts: []ReactionShortcut{{Key: "🐱", Pack: "cats"}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	for _, invalid := range []CollectionConfig{
+		{Reactions: []string{""}},
+		{Shortcuts: []ReactionShortcut{{Key: "", Pack: "cats"}}},
+		{Shortcuts: []ReactionShortcut{{Key: "🐱", Pack: ""}}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
+
+// TestAltTextConfigValidate verifies only known providers and shortcode modes are accepted
+func TestAltTextConfigValidate(t *testing.T) {
+	for _, provider := range []string{"", ProviderAnthropic, ProviderOpenAI, ProviderOllama, ProviderNone} {
+		if err := (AltTextConfig{Provider: provider}).Validate(); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", provider, err)
+		}
+	}
+
+	if err := (AltTextConfig{Provider: "gemini"}).Validate(); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+
+	for _, mode := range []string{"", ShortcodesAuto, ShortcodesSuggest, ShortcodesOff} {
+		if err := (AltTextConfig{Shortcodes: mode}).Validate(); err != nil {
+			t.Errorf("Expected shortcodes mode %q to be valid, got %v", mode, err)
+		}
+	}
+	if err := (AltTextConfig{Shortcodes: "always"}).Validate(); err == nil {
+		t.Error("Expected error for unknown shortcodes mode")
+	}
+}
+
+// TestAltTextConfigValidate_Languages verifies languages and pack overrides are checked
+func TestAltTextConfigValidate_Languages(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AltTextConfig
+		wantErr bool
+	}{
+		{"default languages", AltTextConfig{Packs: []PackAltText{{Pack: "cats", Language: "en"}}}, false},
+		{"configured languages", AltTextConfig{Languages: []string{"en", "de"}, Packs: []PackAltText{{Pack: "cats", Language: "de"}}}, false},
+		{"prompt only", AltTextConfig{Packs: []PackAltText{{Pack: "emotes", Prompt: "Two words."}}}, false},
+		{"empty language", AltTextConfig{Languages: []string{"en", ""}}, true},
+		{"repeated language", AltTextConfig{Languages: []string{"en", "de", "en"}}, true},
+		{"override without pack", AltTextConfig{Packs: []PackAltText{{Prompt: "Two words."}}}, true},
+		{"unconfigured pack language", AltTextConfig{Languages: []string{"en"}, Packs: []PackAltText{{Pack: "cats", Language: "fr"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestAltTextConfig_PackOverrides verifies pack overrides fall back to the global settings
+func TestAltTextConfig_PackOverrides(t *testing.T) {
+	cfg := AltTextConfig{
+		Prompt:    "Describe it.",
+		Languages: []string{"en", "de"},
+		Packs: []PackAltText{
+			{Pack: "emotes", Prompt: "Two words."},
+			{Pack: "katzen", Language: "de"},
+		},
+	}
+
+	tests := []struct {
+		pack         string
+		wantPrompt   string
+		wantLanguage string
+	}{
+		{"", "Describe it.", "en"},
+		{"cats", "Describe it.", "en"},
+		{"emotes", "Two words.", "en"},
+		{"katzen", "Describe it.", "de"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.PackPrompt(tt.pack); got != tt.wantPrompt {
+			t.Errorf("PackPrompt(%q) = %q, want %q", tt.pack, got, tt.wantPrompt)
+		}
+		if got := cfg.PackLanguage(tt.pack); got != tt.wantLanguage {
+			t.Errorf("PackLanguage(%q) = %q, want %q", tt.pack, got, tt.wantLanguage)
+		}
+	}
+
+	if got := cfg.PackPrompt("cats", "katzen", "emotes"); got != "Two words." {
+		t.Errorf("Expected the first pack prompt override, got %q", got)
+	}
+	if got := cfg.PackPrompt(); got != "Describe it." {
+		t.Errorf("Expected the configured prompt with no packs, got %q", got)
+	}
+
+	if got := (AltTextConfig{}).LanguageCodes(); len(got) != 1 || got[0] != "en" {
+		t.Errorf("Expected default languages [en], got %v", got)
+	}
+}
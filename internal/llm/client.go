@@ -1,7 +1,11 @@
-// Package llm provides integration with Anthropic's Claude for generating sticker alt-text.
+// Package llm generates sticker alt-text with a vision model. AltTextGenerator has
+// implementations for Anthropic's Claude, OpenAI-compatible chat completions APIs and
+// a local Ollama server, plus one that leaves stickers undescribed.
 package llm
 
 import (
+	"fmt"
+
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 )
@@ -13,10 +17,11 @@ type Client struct {
 	maxTokens int64
 }
 
-// NewClient creates a new LLM client for alt-text generation
-func NewClient(apiKey string, model string, maxTokens int) *Client {
+// NewClient creates a new LLM client for alt-text generation. Extra options are
+// passed to the Anthropic SDK, e.g. option.WithBaseURL for a proxy.
+func NewClient(apiKey string, model string, maxTokens int, opts ...option.RequestOption) *Client {
 	client := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
+		append([]option.RequestOption{option.WithAPIKey(apiKey)}, opts...)...,
 	)
 
 	return &Client{
@@ -35,3 +40,8 @@ func (c *Client) Model() string {
 func (c *Client) MaxTokens() int64 {
 	return c.maxTokens
 }
+
+// Name describes the provider and model
+func (c *Client) Name() string {
+	return fmt.Sprintf("anthropic (%s, max tokens: %d)", c.model, c.maxTokens)
+}
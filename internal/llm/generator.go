@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+)
+
+// requestTimeout bounds one description request to a self-hosted model, which can
+// be slow to load
+const requestTimeout = 2 * time.Minute
+
+// AltTextGenerator describes sticker images
+type AltTextGenerator interface {
+	// Describe generates alt-text and suggested tags for an image, following a
+	// prompt from BuildPrompt
+	Describe(ctx context.Context, imageData []byte, mimeType string, prompt string) (*Description, error)
+	// Model names the model, recorded on the alt-text it writes (empty for none)
+	Model() string
+	// Name describes the provider and model, for logs
+	Name() string
+}
+
+// NewGenerator creates the alt-text generator selected by alt_text.provider
+func NewGenerator(cfg *config.Config) (AltTextGenerator, error) {
+	switch cfg.AltText.Provider {
+	case "", config.ProviderAnthropic:
+		if cfg.Anthropic.APIKey == "" {
+			return nil, fmt.Errorf("no Anthropic API key configured - set ANTHROPIC_API_KEY or add to config.yaml, or pick another alt_text.provider")
+		}
+		var opts []option.RequestOption
+		if cfg.Anthropic.BaseURL != "" {
+			opts = append(opts, option.WithBaseURL(cfg.Anthropic.BaseURL))
+		}
+		return NewClient(cfg.Anthropic.APIKey, cfg.Anthropic.Model, cfg.Anthropic.MaxTokens, opts...), nil
+	case config.ProviderOpenAI:
+		if cfg.OpenAI.BaseURL == "" || cfg.OpenAI.Model == "" {
+			return nil, fmt.Errorf("openai.base_url and openai.model must be set for the openai provider")
+		}
+		return NewOpenAIClient(cfg.OpenAI.BaseURL, cfg.OpenAI.APIKey, cfg.OpenAI.Model, cfg.OpenAI.MaxTokens), nil
+	case config.ProviderOllama:
+		if cfg.Ollama.URL == "" || cfg.Ollama.Model == "" {
+			return nil, fmt.Errorf("ollama.url and ollama.model must be set for the ollama provider")
+		}
+		return NewOllamaClient(cfg.Ollama.URL, cfg.Ollama.Model, cfg.Ollama.MaxTokens), nil
+	case config.ProviderNone:
+		return NoAltText{}, nil
+	default:
+		return nil, fmt.Errorf("unknown alt-text provider: %s", cfg.AltText.Provider)
+	}
+}
+
+// NoAltText is the "none" provider. It describes nothing, so stickers fall back to
+// the description they were sent with.
+type NoAltText struct{}
+
+// Describe checks the image and returns an empty description
+func (NoAltText) Describe(ctx context.Context, imageData []byte, mimeType string, prompt string) (*Description, error) {
+	if err := checkImage(imageData, mimeType); err != nil {
+		return nil, err
+	}
+	return &Description{}, nil
+}
+
+// Model is empty, since no model writes the alt-text
+func (NoAltText) Model() string {
+	return ""
+}
+
+// Name describes the provider
+func (NoAltText) Name() string {
+	return "none (original descriptions only)"
+}
+
+// checkImage rejects empty data and MIME types the providers can't read
+func checkImage(imageData []byte, mimeType string) error {
+	if len(imageData) == 0 {
+		return fmt.Errorf("image data is empty")
+	}
+
+	// Validate MIME type is an image
+	if !isImageMimeType(mimeType) {
+		return fmt.Errorf("invalid MIME type for image: %s", mimeType)
+	}
+
+	return nil
+}
+
+// postJSON sends a JSON request and decodes the JSON response. Error responses are
+// returned with their body, which is where these APIs explain what went wrong.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, request any, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/liminalpurple/matrix-stickerbook/internal/config"
+)
+
+// testImage is some stand-in image data; the providers only pass it along
+var testImage = []byte("\x89PNG test image")
+
+// testReply is a model reply in the format parseDescription reads
+const testReply = "A cat waving hello\nTags: cat, waving\nShortcode: waving_cat"
+
+// checkDescription verifies a description parsed from testReply
+func checkDescription(t *testing.T, description *Description) {
+	t.Helper()
+	if description.AltText != "A cat waving hello" {
+		t.Errorf("Expected alt-text from reply, got %q", description.AltText)
+	}
+	if strings.Join(description.Tags, ",") != "cat,waving" {
+		t.Errorf("Expected tags cat,waving, got %v", description.Tags)
+	}
+	if description.Shortcode != "waving_cat" {
+		t.Errorf("Expected shortcode waving_cat, got %q", description.Shortcode)
+	}
+}
+
+// TestNewGenerator verifies each provider setting creates the right generator
+func TestNewGenerator(t *testing.T) {
+	cfg := &config.Config{
+		Anthropic: config.AnthropicConfig{APIKey: "key", Model: "claude", MaxTokens: 100},
+		OpenAI:    config.OpenAIConfig{BaseURL: "http://localhost:8080/v1", Model: "gpt", MaxTokens: 100},
+		Ollama:    config.OllamaConfig{URL: "http://localhost:11434", Model: "llava", MaxTokens: 100},
+	}
+
+	tests := []struct {
+		provider string
+		want     string
+	}{
+		{"", "anthropic"},
+		{config.ProviderAnthropic, "anthropic"},
+		{config.ProviderOpenAI, "openai"},
+		{config.ProviderOllama, "ollama"},
+		{config.ProviderNone, "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			cfg.AltText.Provider = tt.provider
+			generator, err := NewGenerator(cfg)
+			if err != nil {
+				t.Fatalf("NewGenerator failed: %v", err)
+			}
+			if !strings.HasPrefix(generator.Name(), tt.want) {
+				t.Errorf("Expected %s generator, got %s", tt.want, generator.Name())
+			}
+		})
+	}
+}
+
+// TestNewGenerator_Errors verifies missing settings and unknown providers are reported
+func TestNewGenerator_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+	}{
+		{"anthropic without key", config.Config{AltText: config.AltTextConfig{Provider: config.ProviderAnthropic}}},
+		{"openai without model", config.Config{
+			AltText: config.AltTextConfig{Provider: config.ProviderOpenAI},
+			OpenAI:  config.OpenAIConfig{BaseURL: "http://localhost:8080/v1"},
+		}},
+		{"ollama without url", config.Config{
+			AltText: config.AltTextConfig{Provider: config.ProviderOllama},
+			Ollama:  config.OllamaConfig{Model: "llava"},
+		}},
+		{"unknown provider", config.Config{AltText: config.AltTextConfig{Provider: "gemini"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewGenerator(&tt.cfg); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+// TestNoAltText verifies the none provider returns an empty description but still
+// rejects bad images
+func TestNoAltText(t *testing.T) {
+	description, err := NoAltText{}.Describe(context.Background(), testImage, "image/png", BuildPrompt("", ""))
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	if description.AltText != "" || len(description.Tags) != 0 {
+		t.Errorf("Expected empty description, got %+v", description)
+	}
+
+	if _, err := (NoAltText{}).Describe(context.Background(), nil, "image/png", ""); err == nil {
+		t.Error("Expected error for empty image data")
+	}
+}
+
+// TestClient_Describe verifies the Anthropic client against a stand-in Messages API
+func TestClient_Describe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected /v1/messages, got %s", r.URL.Path)
+		}
+		if key := r.Header.Get("X-Api-Key"); key != "test-api-key" {
+			t.Errorf("Expected API key header, got %q", key)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-3-haiku-20240307",
+			"content":     []map[string]any{{"type": "text", "text": testReply}},
+			"stop_reason": "end_turn",
+			"usage":       map[string]int{"input_tokens": 1, "output_tokens": 1},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key", "claude-3-haiku-20240307", 100, option.WithBaseURL(server.URL), option.WithMaxRetries(0))
+	description, err := client.Describe(context.Background(), testImage, "image/png", BuildPrompt("", ""))
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	checkDescription(t, description)
+}
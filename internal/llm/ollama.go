@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OllamaClient generates alt-text with a vision model on an Ollama server
+type OllamaClient struct {
+	http      *http.Client
+	url       string
+	model     string
+	maxTokens int
+}
+
+// ollamaRequest is a non-streaming /api/chat request
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // Base64 image data
+}
+
+type ollamaOptions struct {
+	NumPredict int `json:"num_predict,omitempty"` // Maximum tokens to generate
+}
+
+// ollamaResponse is the part of an /api/chat response we read
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+}
+
+// NewOllamaClient creates a client for the Ollama server at url (e.g. http://localhost:11434)
+func NewOllamaClient(url string, model string, maxTokens int) *OllamaClient {
+	return &OllamaClient{
+		http:      &http.Client{Timeout: requestTimeout},
+		url:       strings.TrimSuffix(url, "/"),
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+// Describe generates alt-text and suggested tags for an image
+func (c *OllamaClient) Describe(ctx context.Context, imageData []byte, mimeType string, prompt string) (*Description, error) {
+	if err := checkImage(imageData, mimeType); err != nil {
+		return nil, err
+	}
+
+	request := ollamaRequest{
+		Model: c.model,
+		Messages: []ollamaMessage{{
+			Role:    "user",
+			Content: prompt,
+			Images:  []string{base64.StdEncoding.EncodeToString(imageData)},
+		}},
+	}
+	if c.maxTokens > 0 {
+		request.Options = &ollamaOptions{NumPredict: c.maxTokens}
+	}
+
+	var response ollamaResponse
+	if err := postJSON(ctx, c.http, c.url+"/api/chat", nil, request, &response); err != nil {
+		return nil, fmt.Errorf("failed to generate alt-text: %w", err)
+	}
+
+	if strings.TrimSpace(response.Message.Content) == "" {
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	return parseDescription(response.Message.Content), nil
+}
+
+// Model returns the configured model name
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// Name describes the provider and model
+func (c *OllamaClient) Name() string {
+	return fmt.Sprintf("ollama (%s at %s)", c.model, c.url)
+}
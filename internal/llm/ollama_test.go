@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOllamaClient_Describe verifies the /api/chat request and response
+func TestOllamaClient_Describe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected /api/chat, got %s", r.URL.Path)
+		}
+
+		var request ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if request.Model != "llava" || request.Stream {
+			t.Errorf("Expected non-streaming llava request, got %+v", request)
+		}
+		if request.Options == nil || request.Options.NumPredict != 100 {
+			t.Errorf("Expected num_predict 100, got %+v", request.Options)
+		}
+		images := request.Messages[0].Images
+		if len(images) != 1 || images[0] != base64.StdEncoding.EncodeToString(testImage) {
+			t.Errorf("Expected the image as base64, got %v", images)
+		}
+
+		_, _ = w.Write([]byte(`{"model":"llava","message":{"role":"assistant","content":` + jsonString(testReply) + `},"done":true}`))
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL+"/", "llava", 100)
+	description, err := client.Describe(context.Background(), testImage, "image/png", BuildPrompt("", ""))
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	checkDescription(t, description)
+}
+
+// TestOllamaClient_Errors verifies a missing model and empty replies are reported
+func TestOllamaClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"missing model", http.StatusNotFound, `{"error":"model \"llava\" not found, try pulling it first"}`, "try pulling it first"},
+		{"empty reply", http.StatusOK, `{"message":{"role":"assistant","content":""}}`, "no content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewOllamaClient(server.URL, "llava", 100)
+			_, err := client.Describe(context.Background(), testImage, "image/png", BuildPrompt("", ""))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
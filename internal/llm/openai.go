@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// OpenAIClient generates alt-text with an OpenAI-compatible chat completions API
+type OpenAIClient struct {
+	http      *http.Client
+	baseURL   string
+	apiKey    string
+	model     string
+	maxTokens int
+}
+
+// openAIRequest is a chat completions request with one user message
+type openAIRequest struct {
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens,omitempty"`
+	Messages  []openAIMessage `json:"messages"`
+}
+
+type openAIMessage struct {
+	Role    string          `json:"role"`
+	Content []openAIContent `json:"content"`
+}
+
+type openAIContent struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL string `json:"url"` // A data: URL holding the image
+}
+
+// openAIResponse is the part of a chat completions response we read
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIClient creates a client for the chat completions API under baseURL
+// (e.g. https://api.openai.com/v1). apiKey may be empty for servers that don't check it.
+func NewOpenAIClient(baseURL string, apiKey string, model string, maxTokens int) *OpenAIClient {
+	return &OpenAIClient{
+		http:      &http.Client{Timeout: requestTimeout},
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+	}
+}
+
+// Describe generates alt-text and suggested tags for an image
+func (c *OpenAIClient) Describe(ctx context.Context, imageData []byte, mimeType string, prompt string) (*Description, error) {
+	if err := checkImage(imageData, mimeType); err != nil {
+		return nil, err
+	}
+
+	request := openAIRequest{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		Messages: []openAIMessage{{
+			Role: "user",
+			Content: []openAIContent{
+				{Type: "image_url", ImageURL: &openAIImageURL{
+					URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(imageData),
+				}},
+				{Type: "text", Text: prompt},
+			},
+		}},
+	}
+
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+
+	var response openAIResponse
+	if err := postJSON(ctx, c.http, c.baseURL+"/chat/completions", headers, request, &response); err != nil {
+		return nil, fmt.Errorf("failed to generate alt-text: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	return parseDescription(response.Choices[0].Message.Content), nil
+}
+
+// Model returns the configured model name
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// Name describes the provider and model
+func (c *OpenAIClient) Name() string {
+	return fmt.Sprintf("openai (%s at %s, max tokens: %d)", c.model, c.baseURL, c.maxTokens)
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOpenAIClient_Describe verifies the chat completions request and response
+func TestOpenAIClient_Describe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Expected /v1/chat/completions, got %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Expected bearer token, got %q", auth)
+		}
+
+		var request openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if request.Model != "gpt-4o-mini" || request.MaxTokens != 100 {
+			t.Errorf("Expected model and max tokens to be sent, got %+v", request)
+		}
+		image := request.Messages[0].Content[0].ImageURL
+		if image == nil || !strings.HasPrefix(image.URL, "data:image/png;base64,") {
+			t.Errorf("Expected image as a data URL, got %+v", request.Messages[0].Content[0])
+		}
+		if text := request.Messages[0].Content[1].Text; text != "Describe the sticker." {
+			t.Errorf("Expected the prompt to be sent, got %q", text)
+		}
+
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + jsonString(testReply) + `}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL+"/v1/", "test-key", "gpt-4o-mini", 100)
+	description, err := client.Describe(context.Background(), testImage, "image/png", "Describe the sticker.")
+	if err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+	checkDescription(t, description)
+}
+
+// TestOpenAIClient_NoKey verifies no Authorization header is sent without an API key
+func TestOpenAIClient_NoKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Expected no Authorization header, got %q", auth)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"A cat"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL, "", "local-model", 100)
+	if _, err := client.Describe(context.Background(), testImage, "image/png", BuildPrompt("", "")); err != nil {
+		t.Fatalf("Describe failed: %v", err)
+	}
+}
+
+// TestOpenAIClient_Errors verifies error statuses and empty responses are reported
+func TestOpenAIClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"error status", http.StatusUnauthorized, `{"error":{"message":"invalid api key"}}`},
+		{"no choices", http.StatusOK, `{"choices":[]}`},
+		{"bad json", http.StatusOK, `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewOpenAIClient(server.URL, "test-key", "gpt-4o-mini", 100)
+			if _, err := client.Describe(context.Background(), testImage, "image/png", BuildPrompt("", "")); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+// jsonString encodes s as a JSON string literal
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -4,30 +4,131 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 )
 
-const defaultPrompt = `Describe this sticker in one short sentence.
+// DefaultPrompt says how to describe a sticker, unless alt_text.prompt replaces it
+const DefaultPrompt = `Describe this sticker in one short sentence.
 Aim for ~15 words, max 30 words unless the image contains text.
 Focus on: main subject, emotion/action, distinctive shapes/colors, clothing/art style.
 IMPORTANT: If there is any text visible in the image, include it verbatim (for accessibility).
-Output ONLY the description - no markdown, no headers, no formatting.
 
 Good examples:
 "Anime girl with cat ears and school uniform looking surprised"
 "Two characters in spacesuits kissing against starry background"
 "Bright pink octopus wearing top hat with text 'Nope' in bold letters"`
 
+// formatPrompt follows every prompt, asking for a reply parseDescription can read
+const formatPrompt = `Output ONLY the description on the first line - no markdown, no headers, no formatting.
+On a second line, write "Tags:" followed by 3-6 comma-separated lowercase single-word
+English tags for finding the sticker later (subject, emotion, action, style).
+On a third line, write "Shortcode:" followed by a short emoji shortcode for the sticker:
+2-3 lowercase English words joined by underscores, naming what makes it distinctive.
+For example:
+Bright pink octopus wearing top hat with text 'Nope' in bold letters
+Tags: octopus, nope, hat, pink
+Shortcode: octopus_nope`
+
+// languageNames spells out common language codes for the prompt. Other languages
+// are named by their code, which models generally understand.
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// WithHint adds a user's hint about an image, such as who a character is, to a
+// describing prompt (DefaultPrompt if empty), for passing on to BuildPrompt
+func WithHint(prompt string, hint string) string {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	hint = strings.TrimSpace(hint)
+	if hint == "" {
+		return prompt
+	}
+	return prompt + fmt.Sprintf("\n\nA person who knows this sticker adds: %q. Use this where it helps describe the image.", hint)
+}
+
+// languageName spells out a language code for the prompt
+func languageName(language string) string {
+	if name, ok := languageNames[strings.ToLower(language)]; ok {
+		return name
+	}
+	return language
+}
+
+// BuildPrompt combines a describing prompt (DefaultPrompt if empty) with the reply
+// format and, if languages are given, which language to write the description in.
+// Any languages after the first are asked for as translations of the description in
+// the same reply, read into Description.Translations.
+func BuildPrompt(prompt string, languages ...string) string {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	prompt += "\n\n" + formatPrompt
+
+	if len(languages) > 0 && languages[0] != "" {
+		prompt += fmt.Sprintf("\n\nWrite the description in %s, keeping any text from the image as it appears.", languageName(languages[0]))
+	}
+
+	if len(languages) > 1 {
+		prompt += "\n\nAfter those lines, translate the description into each of these languages, one line each, " +
+			"starting with \"Translation\" and the language code, keeping any text from the image as it appears:"
+		for _, language := range languages[1:] {
+			prompt += fmt.Sprintf("\nTranslation %s: <the description in %s>", language, languageName(language))
+		}
+	}
+
+	return prompt
+}
+
+// Line prefixes in a response, matched case-insensitively
+const (
+	tagsPrefix        = "tags:"        // Starts the line of suggested tags
+	shortcodePrefix   = "shortcode:"   // Starts the line with the suggested shortcode
+	translationPrefix = "translation " // Starts a translation line, followed by the language code and a colon
+)
+
+// Description is what a vision model makes of a sticker image
+type Description struct {
+	AltText      string            // One-sentence description for screen readers
+	Tags         []string          // Suggested lowercase tags, not yet validated
+	Shortcode    string            // Suggested shortcode, lowercased with spaces as underscores but not yet validated
+	Translations map[string]string // AltText in each extra language BuildPrompt asked for, by lowercase language code
+}
+
 // GenerateAltText generates alt-text description for an image using Claude vision
+// and the built-in prompt
 func (c *Client) GenerateAltText(ctx context.Context, imageData []byte, mimeType string) (string, error) {
-	if len(imageData) == 0 {
-		return "", fmt.Errorf("image data is empty")
+	description, err := c.Describe(ctx, imageData, mimeType, BuildPrompt("", ""))
+	if err != nil {
+		return "", err
 	}
+	return description.AltText, nil
+}
 
-	// Validate MIME type is an image
-	if !isImageMimeType(mimeType) {
-		return "", fmt.Errorf("invalid MIME type for image: %s", mimeType)
+// Describe generates alt-text and suggested tags for an image in one Claude vision call
+func (c *Client) Describe(ctx context.Context, imageData []byte, mimeType string, prompt string) (*Description, error) {
+	if err := checkImage(imageData, mimeType); err != nil {
+		return nil, err
 	}
 
 	// Encode image to base64
@@ -40,26 +141,91 @@ func (c *Client) GenerateAltText(ctx context.Context, imageData []byte, mimeType
 		Messages: []anthropic.MessageParam{
 			anthropic.NewUserMessage(
 				anthropic.NewImageBlockBase64(mimeType, base64Image),
-				anthropic.NewTextBlock(defaultPrompt),
+				anthropic.NewTextBlock(prompt),
 			),
 		},
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("failed to generate alt-text: %w", err)
+		return nil, fmt.Errorf("failed to generate alt-text: %w", err)
 	}
 
 	// Extract text from response
 	if len(message.Content) == 0 {
-		return "", fmt.Errorf("no content in response")
+		return nil, fmt.Errorf("no content in response")
 	}
 
 	// The response should contain a text block - the union has all fields
 	if message.Content[0].Type != "text" {
-		return "", fmt.Errorf("unexpected response type: %s", message.Content[0].Type)
+		return nil, fmt.Errorf("unexpected response type: %s", message.Content[0].Type)
+	}
+
+	return parseDescription(message.Content[0].Text), nil
+}
+
+// parseDescription splits a response into the description and the "Tags:",
+// "Shortcode:" and "Translation <code>:" lines. A response without them is all
+// description.
+func parseDescription(text string) *Description {
+	description := &Description{}
+	var lines []string
+
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.TrimSpace(line)
+		if language, translation, ok := cutTranslation(line); ok {
+			if description.Translations == nil {
+				description.Translations = make(map[string]string)
+			}
+			description.Translations[language] = translation
+			continue
+		}
+		if value, ok := cutPrefixFold(line, shortcodePrefix); ok {
+			shortcode := strings.ToLower(strings.Trim(strings.TrimSpace(value), "\"'.:`"))
+			description.Shortcode = strings.Join(strings.FieldsFunc(shortcode, func(r rune) bool {
+				return r == ' ' || r == '-' || r == '_'
+			}), "_")
+			continue
+		}
+		if value, ok := cutPrefixFold(line, tagsPrefix); ok {
+			for tag := range strings.SplitSeq(value, ",") {
+				tag = strings.ToLower(strings.Trim(strings.TrimSpace(tag), `"'.#`))
+				if tag != "" {
+					description.Tags = append(description.Tags, tag)
+				}
+			}
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	description.AltText = strings.Join(lines, " ")
+	return description
+}
+
+// cutTranslation reads a "Translation <code>: <text>" line, returning the lowercase
+// language code and the text
+func cutTranslation(line string) (string, string, bool) {
+	value, ok := cutPrefixFold(line, translationPrefix)
+	if !ok {
+		return "", "", false
+	}
+	language, translation, ok := strings.Cut(value, ":")
+	language = strings.ToLower(strings.TrimSpace(language))
+	translation = strings.TrimSpace(translation)
+	if !ok || language == "" || strings.ContainsAny(language, " \t") || translation == "" {
+		return "", "", false
 	}
+	return language, translation, true
+}
 
-	return message.Content[0].Text, nil
+// cutPrefixFold is strings.CutPrefix ignoring case
+func cutPrefixFold(s string, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
 }
 
 // isImageMimeType checks if the MIME type is a valid image type
@@ -5,6 +5,7 @@ import (
 	"context"
 	"image"
 	"image/png"
+	"strings"
 	"testing"
 )
 
@@ -89,6 +90,45 @@ func TestGenerateAltText_InvalidMimeType(t *testing.T) {
 	}
 }
 
+// TestParseDescription verifies the description and tags lines are split apart
+func TestParseDescription(t *testing.T) {
+	d := parseDescription("Orange cat waving a paw\nTags: Cat, waving, #orange, \"cute\".\n")
+	if d.AltText != "Orange cat waving a paw" {
+		t.Errorf("Unexpected alt-text: %q", d.AltText)
+	}
+	expected := []string{"cat", "waving", "orange", "cute"}
+	if len(d.Tags) != len(expected) {
+		t.Fatalf("Expected tags %v, got %v", expected, d.Tags)
+	}
+	for i := range expected {
+		if d.Tags[i] != expected[i] {
+			t.Errorf("Expected tags %v, got %v", expected, d.Tags)
+			break
+		}
+	}
+
+	// The shortcode line is normalised to lowercase words joined by underscores
+	d = parseDescription("Pink octopus saying nope\nTags: octopus\nshortcode: `:Octopus Nope-Hat:`")
+	if d.AltText != "Pink octopus saying nope" || d.Shortcode != "octopus_nope_hat" {
+		t.Errorf("Unexpected description: %+v", d)
+	}
+
+	// Without a tags line the whole response is the description
+	d = parseDescription("Two characters\nin spacesuits")
+	if d.AltText != "Two characters in spacesuits" || len(d.Tags) != 0 || d.Shortcode != "" || d.Translations != nil {
+		t.Errorf("Unexpected description: %+v", d)
+	}
+
+	// Translation lines are keyed by their lowercase language code
+	d = parseDescription("Orange cat waving\nTags: cat\nShortcode: cat_wave\nTranslation DE: Orange Katze winkt\ntranslation pt-br: Gato laranja acenando")
+	if d.AltText != "Orange cat waving" || d.Shortcode != "cat_wave" || len(d.Translations) != 2 {
+		t.Fatalf("Unexpected description: %+v", d)
+	}
+	if d.Translations["de"] != "Orange Katze winkt" || d.Translations["pt-br"] != "Gato laranja acenando" {
+		t.Errorf("Unexpected translations: %v", d.Translations)
+	}
+}
+
 // Note: We don't test actual API calls here since that would require:
 // 1. Real API credentials
 // 2. Network access
@@ -103,4 +143,57 @@ func TestGenerateAltText_InvalidMimeType(t *testing.T) {
 // - Client creation
 // - MIME type validation
 // - Error handling for invalid inputs
+// - Response parsing
 // - Request structure (indirectly through error cases)
+
+// TestBuildPrompt verifies custom prompts replace the built-in one while keeping the
+// reply format, and that the language is named
+func TestBuildPrompt(t *testing.T) {
+	prompt := BuildPrompt("", "")
+	if !strings.HasPrefix(prompt, DefaultPrompt) || !strings.Contains(prompt, "Tags:") {
+		t.Errorf("Expected built-in prompt with reply format, got %q", prompt)
+	}
+	if strings.Contains(prompt, "Write the description in") {
+		t.Errorf("Expected no language instruction, got %q", prompt)
+	}
+
+	prompt = BuildPrompt("  Two words only.\n", "de")
+	if !strings.HasPrefix(prompt, "Two words only.\n\n") || strings.Contains(prompt, DefaultPrompt) {
+		t.Errorf("Expected custom prompt to replace the built-in one, got %q", prompt)
+	}
+	if !strings.Contains(prompt, "Tags:") || !strings.Contains(prompt, "Write the description in German") {
+		t.Errorf("Expected reply format and German instruction, got %q", prompt)
+	}
+
+	if prompt := BuildPrompt("", "eo"); !strings.Contains(prompt, "Write the description in eo") {
+		t.Errorf("Expected unknown language to be named by code, got %q", prompt)
+	}
+
+	// Extra languages are asked for as translations in the same reply
+	prompt = BuildPrompt("", "en", "de", "eo")
+	if !strings.Contains(prompt, "Write the description in English") ||
+		!strings.Contains(prompt, "Translation de: <the description in German>") ||
+		!strings.Contains(prompt, "Translation eo: <the description in eo>") {
+		t.Errorf("Expected English with German and eo translations, got %q", prompt)
+	}
+	if strings.Contains(BuildPrompt("", "en"), "Translation") {
+		t.Error("Expected no translations for a single language")
+	}
+}
+
+// TestWithHint verifies hints are added to the built-in or custom prompt
+func TestWithHint(t *testing.T) {
+	if prompt := WithHint("", ""); prompt != DefaultPrompt {
+		t.Errorf("Expected built-in prompt without a hint, got %q", prompt)
+	}
+
+	prompt := WithHint("", "it's Ferris the crab")
+	if !strings.HasPrefix(prompt, DefaultPrompt) || !strings.Contains(prompt, `"it's Ferris the crab"`) {
+		t.Errorf("Expected built-in prompt with the hint, got %q", prompt)
+	}
+
+	prompt = BuildPrompt(WithHint("Two words only.", "a crab"), "en")
+	if !strings.HasPrefix(prompt, "Two words only.") || !strings.Contains(prompt, `"a crab"`) || !strings.Contains(prompt, "Tags:") {
+		t.Errorf("Expected custom prompt, hint and reply format, got %q", prompt)
+	}
+}
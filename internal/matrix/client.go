@@ -41,6 +41,9 @@ func (c *Client) Connect(ctx context.Context) error {
 		return fmt.Errorf("user ID mismatch: expected %s, got %s", c.UserID, resp.UserID)
 	}
 
+	// The crypto store is keyed by device, so remember which one this token belongs to
+	c.DeviceID = resp.DeviceID
+
 	return nil
 }
 
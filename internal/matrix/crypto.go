@@ -0,0 +1,119 @@
+package matrix
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+const (
+	// CryptoDatabase is the file under the data directory holding encryption keys and room state
+	CryptoDatabase = "crypto.db"
+	// PickleKeyFile is the file under the data directory holding the key that protects CryptoDatabase
+	PickleKeyFile = "pickle.key"
+)
+
+// LoadPickleKey reads the crypto store's pickle key from the data directory,
+// generating one the first time. The file is only readable by its owner.
+func LoadPickleKey(dataDir string) (string, error) {
+	path := filepath.Join(dataDir, PickleKeyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read pickle key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate pickle key: %w", err)
+	}
+	encoded := base64.RawStdEncoding.EncodeToString(key)
+
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+	// O_EXCL so a key written meanwhile is never replaced, which would lock us out of crypto.db
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create pickle key: %w", err)
+	}
+	if _, err := f.WriteString(encoded + "\n"); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("failed to write pickle key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write pickle key: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// EncryptionEnabled reports whether end-to-end encryption has been set up
+func (c *Client) EncryptionEnabled() bool {
+	return c.Crypto != nil
+}
+
+// DecryptEvent decrypts an m.room.encrypted event, returning other events unchanged.
+// Events fetched directly (rather than through sync) aren't decrypted automatically.
+func (c *Client) DecryptEvent(ctx context.Context, evt *event.Event) (*event.Event, error) {
+	if evt.Type != event.EventEncrypted {
+		return evt, nil
+	}
+	if c.Crypto == nil {
+		return nil, fmt.Errorf("event is encrypted but encryption is not enabled")
+	}
+
+	if evt.Content.Parsed == nil {
+		if err := evt.Content.ParseRaw(evt.Type); err != nil {
+			return nil, fmt.Errorf("failed to parse encrypted event: %w", err)
+		}
+	}
+
+	decrypted, err := c.Crypto.Decrypt(ctx, evt)
+	if err != nil {
+		return nil, err
+	}
+	return decrypted, nil
+}
+
+// LoadEncryptedRoom fetches a room's state if the state store doesn't know the room
+// is encrypted yet, so replies to it get encrypted. This happens when the bot resumes
+// from a sync token saved before encryption was enabled, as the room's encryption
+// event is never seen again.
+func (c *Client) LoadEncryptedRoom(ctx context.Context, roomID id.RoomID) error {
+	if c.Crypto == nil {
+		return nil
+	}
+
+	encrypted, err := c.StateStore.IsEncrypted(ctx, roomID)
+	if err != nil {
+		return fmt.Errorf("failed to check room encryption: %w", err)
+	}
+	if encrypted {
+		return nil
+	}
+
+	if _, err := c.State(ctx, roomID); err != nil {
+		return fmt.Errorf("failed to load room state: %w", err)
+	}
+	return nil
+}
+
+// CloseEncryption closes the crypto store, if encryption was enabled
+func (c *Client) CloseEncryption() error {
+	if closer, ok := c.Crypto.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
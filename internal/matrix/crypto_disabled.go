@@ -0,0 +1,14 @@
+//go:build !e2ee
+
+package matrix
+
+import (
+	"context"
+	"fmt"
+)
+
+// EnableEncryption reports that this binary was built without end-to-end encryption.
+// Build with -tags e2ee (plus goolm, or with libolm installed) to enable it.
+func (c *Client) EnableEncryption(ctx context.Context, dbPath string, pickleKey []byte) error {
+	return fmt.Errorf("end-to-end encryption is not available in this build (rebuild with -tags e2ee)")
+}
@@ -0,0 +1,35 @@
+//go:build e2ee
+
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"maunium.net/go/mautrix/crypto/cryptohelper"
+	"maunium.net/go/mautrix/event"
+)
+
+// EnableEncryption sets up end-to-end encryption with keys kept in an SQLite
+// database at dbPath, protected by pickleKey. Once enabled, encrypted events are
+// decrypted before reaching the syncer's handlers and messages sent to encrypted
+// rooms are encrypted. Connect must be called first so the device ID is known.
+func (c *Client) EnableEncryption(ctx context.Context, dbPath string, pickleKey []byte) error {
+	helper, err := cryptohelper.NewCryptoHelper(c.Client, pickleKey, dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to create crypto helper: %w", err)
+	}
+
+	helper.DecryptErrorCallback = func(evt *event.Event, err error) {
+		log.Printf("Failed to decrypt event %s in %s: %v", evt.ID, evt.RoomID, err)
+	}
+
+	if err := helper.Init(ctx); err != nil {
+		_ = helper.Close()
+		return fmt.Errorf("failed to initialise encryption: %w", err)
+	}
+
+	c.Crypto = helper
+	return nil
+}
@@ -0,0 +1,60 @@
+package matrix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"maunium.net/go/mautrix/event"
+)
+
+// TestDecryptEvent_WithoutEncryption verifies plain events pass through and encrypted ones fail
+func TestDecryptEvent_WithoutEncryption(t *testing.T) {
+	client, err := NewClient("https://matrix.org", "@test:matrix.org", "test-token")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.EncryptionEnabled() {
+		t.Error("Expected encryption to be off by default")
+	}
+
+	plain := &event.Event{Type: event.EventMessage}
+	got, err := client.DecryptEvent(context.Background(), plain)
+	if err != nil || got != plain {
+		t.Errorf("Expected plain event returned unchanged, got %v, %v", got, err)
+	}
+
+	encrypted := &event.Event{Type: event.EventEncrypted}
+	if _, err := client.DecryptEvent(context.Background(), encrypted); err == nil {
+		t.Error("Expected encrypted event to fail without encryption enabled")
+	}
+
+	// Rooms don't need loading when encryption is off
+	if err := client.LoadEncryptedRoom(context.Background(), "!room:matrix.org"); err != nil {
+		t.Errorf("Expected no-op without encryption, got %v", err)
+	}
+}
+
+// TestLoadPickleKey verifies a pickle key is generated once, readable only by its owner,
+// and read back after that
+func TestLoadPickleKey(t *testing.T) {
+	dataDir := filepath.Join(t.TempDir(), "data")
+
+	key, err := LoadPickleKey(dataDir)
+	if err != nil || key == "" {
+		t.Fatalf("Failed to generate pickle key: %q, %v", key, err)
+	}
+	info, err := os.Stat(filepath.Join(dataDir, PickleKeyFile))
+	if err != nil {
+		t.Fatalf("Expected the key to be saved: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected permissions 0600, got %o", perm)
+	}
+
+	again, err := LoadPickleKey(dataDir)
+	if err != nil || again != key {
+		t.Errorf("Expected the saved key %q, got %q, %v", key, again, err)
+	}
+}
@@ -0,0 +1,134 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+	"maunium.net/go/mautrix"
+)
+
+// EmoteRoomsEventType is the account data event listing room packs the user
+// wants available everywhere, not just in the room they were published to
+const EmoteRoomsEventType = "im.ponies.emote_rooms"
+
+// EmoteRoomsContent is the im.ponies.emote_rooms content: room ID → state key → options.
+// Options are kept as raw JSON so entries added by other clients survive a round trip,
+// and updateEmoteRooms keeps any other top-level keys the same way.
+type EmoteRoomsContent struct {
+	Rooms map[string]map[string]json.RawMessage `json:"rooms"`
+}
+
+// SubscribePack adds every room the pack is published to (with its state key) to
+// the user's emote rooms, returning how many subscriptions were added
+func (c *Client) SubscribePack(ctx context.Context, store storage.Store, packName string) (int, error) {
+	pack, err := store.GetPack(packName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pack: %w", err)
+	}
+	if len(pack.PublishedRooms) == 0 {
+		return 0, fmt.Errorf("pack has not been published to any rooms")
+	}
+
+	return c.updateEmoteRooms(ctx, func(content *EmoteRoomsContent) int {
+		return addEmoteRooms(content, pack.PublishedRooms)
+	})
+}
+
+// UnsubscribePack removes the pack's rooms and state keys from the user's emote
+// rooms, returning how many subscriptions were removed
+func (c *Client) UnsubscribePack(ctx context.Context, store storage.Store, packName string) (int, error) {
+	pack, err := store.GetPack(packName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load pack: %w", err)
+	}
+
+	return c.UnsubscribeRooms(ctx, pack.PublishedRooms)
+}
+
+// UnsubscribeRooms removes room ID → state key pairs from the user's emote rooms,
+// returning how many subscriptions were removed
+func (c *Client) UnsubscribeRooms(ctx context.Context, rooms map[string]string) (int, error) {
+	return c.updateEmoteRooms(ctx, func(content *EmoteRoomsContent) int {
+		return removeEmoteRooms(content, rooms)
+	})
+}
+
+// updateEmoteRooms reads the emote rooms account data and applies fn, writing it
+// back only if fn reports that it changed something. Only the rooms key is
+// replaced, so top-level keys written by other clients survive.
+func (c *Client) updateEmoteRooms(ctx context.Context, fn func(content *EmoteRoomsContent) int) (int, error) {
+	var raw map[string]json.RawMessage
+	if err := c.GetAccountData(ctx, EmoteRoomsEventType, &raw); err != nil && !errors.Is(err, mautrix.MNotFound) {
+		return 0, fmt.Errorf("failed to get emote rooms: %w", err)
+	}
+
+	var content EmoteRoomsContent
+	if rooms, ok := raw["rooms"]; ok {
+		if err := json.Unmarshal(rooms, &content.Rooms); err != nil {
+			return 0, fmt.Errorf("failed to parse emote rooms: %w", err)
+		}
+	}
+
+	changed := fn(&content)
+	if changed == 0 {
+		return 0, nil
+	}
+	if content.Rooms == nil {
+		content.Rooms = make(map[string]map[string]json.RawMessage)
+	}
+
+	rooms, err := json.Marshal(content.Rooms)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode emote rooms: %w", err)
+	}
+	if raw == nil {
+		raw = make(map[string]json.RawMessage)
+	}
+	raw["rooms"] = rooms
+
+	if err := c.SetAccountData(ctx, EmoteRoomsEventType, raw); err != nil {
+		return 0, fmt.Errorf("failed to set emote rooms: %w", err)
+	}
+
+	return changed, nil
+}
+
+// addEmoteRooms subscribes to each room/state key pair not already present
+func addEmoteRooms(content *EmoteRoomsContent, rooms map[string]string) int {
+	if content.Rooms == nil {
+		content.Rooms = make(map[string]map[string]json.RawMessage)
+	}
+
+	added := 0
+	for roomID, stateKey := range rooms {
+		if content.Rooms[roomID] == nil {
+			content.Rooms[roomID] = make(map[string]json.RawMessage)
+		}
+		if _, ok := content.Rooms[roomID][stateKey]; !ok {
+			content.Rooms[roomID][stateKey] = json.RawMessage("{}")
+			added++
+		}
+	}
+
+	return added
+}
+
+// removeEmoteRooms unsubscribes from each room/state key pair, dropping rooms left empty
+func removeEmoteRooms(content *EmoteRoomsContent, rooms map[string]string) int {
+	removed := 0
+	for roomID, stateKey := range rooms {
+		if _, ok := content.Rooms[roomID][stateKey]; !ok {
+			continue
+		}
+		delete(content.Rooms[roomID], stateKey)
+		if len(content.Rooms[roomID]) == 0 {
+			delete(content.Rooms, roomID)
+		}
+		removed++
+	}
+
+	return removed
+}
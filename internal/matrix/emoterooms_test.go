@@ -0,0 +1,104 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// TestAddEmoteRooms verifies new subscriptions are added without touching existing ones
+func TestAddEmoteRooms(t *testing.T) {
+	content := &EmoteRoomsContent{}
+	if err := json.Unmarshal([]byte(`{"rooms": {"!a:example.org": {"other": {"custom": true}}}}`), content); err != nil {
+		t.Fatalf("Failed to parse content: %v", err)
+	}
+
+	added := addEmoteRooms(content, map[string]string{
+		"!a:example.org": "cats",
+		"!b:example.org": "cats",
+	})
+	if added != 2 {
+		t.Errorf("Expected 2 subscriptions added, got %d", added)
+	}
+	if string(content.Rooms["!a:example.org"]["other"]) != `{"custom": true}` {
+		t.Errorf("Expected existing entry to be preserved, got %s", content.Rooms["!a:example.org"]["other"])
+	}
+
+	// Subscribing again is a no-op
+	if added := addEmoteRooms(content, map[string]string{"!b:example.org": "cats"}); added != 0 {
+		t.Errorf("Expected no subscriptions added, got %d", added)
+	}
+}
+
+// TestRemoveEmoteRooms verifies subscriptions are removed and empty rooms dropped
+func TestRemoveEmoteRooms(t *testing.T) {
+	content := &EmoteRoomsContent{}
+	addEmoteRooms(content, map[string]string{"!a:example.org": "cats", "!b:example.org": "cats"})
+	addEmoteRooms(content, map[string]string{"!a:example.org": "dogs"})
+
+	removed := removeEmoteRooms(content, map[string]string{
+		"!a:example.org": "cats",
+		"!b:example.org": "cats",
+		"!c:example.org": "cats",
+	})
+	if removed != 2 {
+		t.Errorf("Expected 2 subscriptions removed, got %d", removed)
+	}
+	if _, ok := content.Rooms["!b:example.org"]; ok {
+		t.Error("Expected empty room to be dropped")
+	}
+	if _, ok := content.Rooms["!a:example.org"]["dogs"]; !ok {
+		t.Error("Expected other pack in the same room to remain")
+	}
+}
+
+// TestSubscribePack_KeepsOtherKeys verifies subscribing rewrites only the rooms key of
+// the emote rooms account data, keeping top-level keys written by other clients
+func TestSubscribePack_KeepsOtherKeys(t *testing.T) {
+	var written map[string]json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			if err := json.NewDecoder(r.Body).Decode(&written); err != nil {
+				t.Errorf("Failed to decode account data: %v", err)
+			}
+		}
+		_, _ = w.Write([]byte(`{"rooms": {"!a:example.org": {"other": {}}}, "custom": {"keep": true}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "@test:example.org", "test-token")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	store := storage.NewJSONStore(t.TempDir())
+	if err := store.CreatePack("cats", "Cats", ""); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	if err := store.UpdatePublished("cats", "!b:example.org", "cats"); err != nil {
+		t.Fatalf("Failed to record publish: %v", err)
+	}
+
+	added, err := client.SubscribePack(context.Background(), store, "cats")
+	if err != nil || added != 1 {
+		t.Fatalf("Expected 1 subscription added, got %d, %v", added, err)
+	}
+	if string(written["custom"]) != `{"keep":true}` {
+		t.Errorf("Expected the custom key to be kept, got %s", written["custom"])
+	}
+
+	var rooms map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(written["rooms"], &rooms); err != nil {
+		t.Fatalf("Failed to parse rooms: %v", err)
+	}
+	if _, ok := rooms["!a:example.org"]["other"]; !ok {
+		t.Error("Expected the existing subscription to be kept")
+	}
+	if _, ok := rooms["!b:example.org"]["cats"]; !ok {
+		t.Error("Expected the pack's room to be subscribed")
+	}
+}
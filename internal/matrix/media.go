@@ -12,6 +12,7 @@ import (
 	_ "image/png"  // Import for image format support
 
 	_ "golang.org/x/image/webp" // Import for WebP support
+	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 )
 
@@ -41,6 +42,25 @@ func (c *Client) DownloadMedia(ctx context.Context, mxcURI string) ([]byte, stri
 	return data, mimeType, nil
 }
 
+// DownloadEncryptedMedia downloads media sent in an encrypted room and decrypts it
+func (c *Client) DownloadEncryptedMedia(ctx context.Context, file *event.EncryptedFileInfo) ([]byte, string, error) {
+	parsedURI, err := file.URL.Parse()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse MXC URI: %w", err)
+	}
+
+	data, err := c.DownloadBytes(ctx, parsedURI)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+
+	if err := file.DecryptInPlace(data); err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt media: %w", err)
+	}
+
+	return data, detectMimeType(data), nil
+}
+
 // UploadMedia uploads media to the homeserver and returns the new MXC URI
 func (c *Client) UploadMedia(ctx context.Context, data []byte, mimeType string) (string, error) {
 	uploadResp, err := c.UploadBytes(ctx, data, mimeType)
@@ -1,8 +1,11 @@
 package matrix
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
 	"maunium.net/go/mautrix/event"
@@ -11,6 +14,9 @@ import (
 
 // MSC2545 Sticker Pack Types
 
+// RoomEmotesEventType is the state event type holding a room's sticker packs (one per state key)
+var RoomEmotesEventType = event.Type{Type: "im.ponies.room_emotes", Class: event.StateEventType}
+
 // PackInfo represents the pack metadata
 type PackInfo struct {
 	DisplayName string   `json:"display_name"`
@@ -36,47 +42,259 @@ type StickerData struct {
 type PackContent struct {
 	Pack   PackInfo               `json:"pack"`
 	Images map[string]StickerData `json:"images"`
+
+	// order lists the Images keys in pack order. Clients show images in the order
+	// they appear in the event, which a Go map would otherwise lose.
+	order []string
 }
 
-// PublishPack publishes a sticker pack to a Matrix room as an MSC2545 state event
-func (c *Client) PublishPack(ctx context.Context, dataDir string, packName string, roomID id.RoomID) error {
-	// Load pack
-	pack, err := storage.GetPack(dataDir, packName)
-	if err != nil {
-		return fmt.Errorf("failed to load pack: %w", err)
+// Shortcodes returns the image shortcodes in pack order. Images added to the map
+// directly (without a recorded order) come last, sorted.
+func (p *PackContent) Shortcodes() []string {
+	shortcodes := make([]string, 0, len(p.Images))
+	seen := make(map[string]bool, len(p.Images))
+	for _, shortcode := range p.order {
+		if _, ok := p.Images[shortcode]; ok && !seen[shortcode] {
+			shortcodes = append(shortcodes, shortcode)
+			seen[shortcode] = true
+		}
 	}
 
-	// Load collection to get sticker details
-	collection, err := storage.LoadCollection(dataDir)
+	var rest []string
+	for shortcode := range p.Images {
+		if !seen[shortcode] {
+			rest = append(rest, shortcode)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(shortcodes, rest...)
+}
+
+// MarshalJSON writes the images object in pack order
+func (p PackContent) MarshalJSON() ([]byte, error) {
+	packJSON, err := json.Marshal(p.Pack)
 	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
+		return nil, err
 	}
 
-	// Build images map
-	images := make(map[string]StickerData)
-	for _, stickerID := range pack.StickerIDs {
-		// Find sticker in collection
-		var sticker *storage.Sticker
-		for i := range collection.Stickers {
-			if collection.Stickers[i].ID == stickerID {
-				sticker = &collection.Stickers[i]
-				break
-			}
+	var buf bytes.Buffer
+	buf.WriteString(`{"pack":`)
+	buf.Write(packJSON)
+	buf.WriteString(`,"images":{`)
+	for i, shortcode := range p.Shortcodes() {
+		if i > 0 {
+			buf.WriteByte(',')
 		}
+		key, err := json.Marshal(shortcode)
+		if err != nil {
+			return nil, err
+		}
+		image, err := json.Marshal(p.Images[shortcode])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(image)
+	}
+	buf.WriteString(`}}`)
 
-		if sticker == nil {
-			return fmt.Errorf("sticker not found in collection: %s", stickerID)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads the content, remembering the order of the images object
+func (p *PackContent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Pack   PackInfo        `json:"pack"`
+		Images json.RawMessage `json:"images"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	p.Pack = raw.Pack
+	p.Images = make(map[string]StickerData)
+	p.order = nil
+	if len(raw.Images) == 0 || string(raw.Images) == "null" {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw.Images))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("images must be an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
 		}
+		shortcode, _ := tok.(string)
 
-		// Use alt-text if available, otherwise original body
-		body := sticker.GeneratedAltText
-		if body == "" {
-			body = sticker.OriginalBody
+		var image StickerData
+		if err := dec.Decode(&image); err != nil {
+			return fmt.Errorf("invalid image %q: %w", shortcode, err)
 		}
+		if _, dup := p.Images[shortcode]; !dup {
+			p.order = append(p.order, shortcode)
+		}
+		p.Images[shortcode] = image
+	}
+
+	return nil
+}
+
+// GetRoomPack fetches the MSC2545 pack published in a room under the given state key
+func (c *Client) GetRoomPack(ctx context.Context, roomID id.RoomID, stateKey string) (*PackContent, error) {
+	var content PackContent
+	if err := c.StateEvent(ctx, roomID, RoomEmotesEventType, stateKey, &content); err != nil {
+		return nil, fmt.Errorf("failed to get pack state event: %w", err)
+	}
+
+	return &content, nil
+}
+
+// UserEmotesEventType is the account data event holding the user's personal emotes
+const UserEmotesEventType = "im.ponies.user_emotes"
+
+// PublishPack publishes a sticker pack to a Matrix room as an MSC2545 state event,
+// with sticker bodies in the given language where they have alt-text in it
+func (c *Client) PublishPack(ctx context.Context, store storage.Store, packName string, roomID id.RoomID, language string) error {
+	content, err := buildPackContent(store, packName, language)
+	if err != nil {
+		return err
+	}
+
+	pack, err := store.GetPack(packName)
+	if err != nil {
+		return fmt.Errorf("failed to load pack: %w", err)
+	}
+
+	// State key is the pack name, unless the room already has this pack under
+	// another key (e.g. from before a rename) - reuse it rather than orphan it
+	stateKey, ok := pack.PublishedRooms[roomID.String()]
+	if !ok {
+		stateKey = packName
+	}
+
+	// Send state event
+	_, err = c.SendStateEvent(ctx, roomID, RoomEmotesEventType, stateKey, content)
+	if err != nil {
+		return fmt.Errorf("failed to send state event: %w", err)
+	}
+
+	// Update pack's published rooms
+	if err := store.UpdatePublished(packName, roomID.String(), stateKey); err != nil {
+		return fmt.Errorf("failed to update published rooms: %w", err)
+	}
+
+	return nil
+}
+
+// UnpublishPack removes a pack from a room by replacing its state event with empty
+// content, then forgets the room. Any emote rooms subscription to it is dropped too,
+// since it would only point at an empty pack.
+func (c *Client) UnpublishPack(ctx context.Context, store storage.Store, packName string, roomID id.RoomID) error {
+	pack, err := store.GetPack(packName)
+	if err != nil {
+		return fmt.Errorf("failed to load pack: %w", err)
+	}
+
+	stateKey, ok := pack.PublishedRooms[roomID.String()]
+	if !ok {
+		return fmt.Errorf("pack %s is not published to %s", packName, roomID)
+	}
+
+	// State events can't be deleted; empty content is how MSC2545 clients see "no pack"
+	_, err = c.SendStateEvent(ctx, roomID, RoomEmotesEventType, stateKey, struct{}{})
+	if err != nil {
+		return fmt.Errorf("failed to send state event: %w", err)
+	}
+
+	if err := store.RemovePublished(packName, roomID.String()); err != nil {
+		return fmt.Errorf("failed to update published rooms: %w", err)
+	}
+
+	if _, err := c.UnsubscribeRooms(ctx, map[string]string{roomID.String(): stateKey}); err != nil {
+		return fmt.Errorf("unpublished, but failed to update emote rooms: %w", err)
+	}
+
+	return nil
+}
+
+// PublishPersonalPack publishes a sticker pack as the user's personal emotes in
+// account data, making it available in every room without needing state-event power.
+// There is only one personal pack, so this replaces whatever was published before.
+// Sticker bodies are in the given language where they have alt-text in it.
+func (c *Client) PublishPersonalPack(ctx context.Context, store storage.Store, packName string, language string) error {
+	content, err := buildPackContent(store, packName, language)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SetAccountData(ctx, UserEmotesEventType, content); err != nil {
+		return fmt.Errorf("failed to set account data: %w", err)
+	}
+
+	if err := store.SetPersonalPack(packName); err != nil {
+		return fmt.Errorf("failed to update personal pack: %w", err)
+	}
+
+	return nil
+}
+
+// UnpublishPersonalPack clears the user's personal emotes if they hold this pack
+func (c *Client) UnpublishPersonalPack(ctx context.Context, store storage.Store, packName string) error {
+	pack, err := store.GetPack(packName)
+	if err != nil {
+		return fmt.Errorf("failed to load pack: %w", err)
+	}
+	if !pack.PublishedPersonal {
+		return fmt.Errorf("pack %s is not published as the bot account's personal emotes", packName)
+	}
+
+	if err := c.SetAccountData(ctx, UserEmotesEventType, struct{}{}); err != nil {
+		return fmt.Errorf("failed to set account data: %w", err)
+	}
 
+	if err := store.SetPersonalPack(""); err != nil {
+		return fmt.Errorf("failed to update personal pack: %w", err)
+	}
+
+	return nil
+}
+
+// buildPackContent builds the MSC2545 event content for a pack, shared by room and
+// personal publishing. A pack with stickers sharing a shortcode isn't built, since
+// all but one of them would be lost; the error is the first *storage.ShortcodeConflict.
+func buildPackContent(store storage.Store, packName string, language string) (*PackContent, error) {
+	// Load pack
+	pack, err := store.GetPack(packName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pack: %w", err)
+	}
+
+	// Load sticker details in pack order
+	stickers, err := store.PackStickers(packName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pack stickers: %w", err)
+	}
+	if conflicts := storage.FindShortcodeConflicts(packName, stickers); len(conflicts) > 0 {
+		return nil, &conflicts[0]
+	}
+
+	// Build images map, remembering pack order
+	images := make(map[string]StickerData)
+	order := make([]string, 0, len(stickers))
+	for i := range stickers {
+		sticker := &stickers[i]
+
+		// Use alt-text in the pack's language if available, otherwise original body
 		stickerData := StickerData{
 			URL:  sticker.LocalMXC,
-			Body: body,
+			Body: sticker.AltTextIn(language),
 		}
 		stickerData.Info.Width = sticker.Width
 		stickerData.Info.Height = sticker.Height
@@ -89,11 +307,9 @@ func (c *Client) PublishPack(ctx context.Context, dataDir string, packName strin
 		}
 
 		// Use Name as the shortcode key (defaults to SHA256 if not set)
-		shortcode := sticker.Name
-		if shortcode == "" {
-			shortcode = stickerID
-		}
+		shortcode := sticker.Shortcode()
 		images[shortcode] = stickerData
+		order = append(order, shortcode)
 	}
 
 	// Build pack content
@@ -115,24 +331,9 @@ func (c *Client) PublishPack(ctx context.Context, dataDir string, packName strin
 		packInfo.Attribution = pack.Attribution
 	}
 
-	content := PackContent{
+	return &PackContent{
 		Pack:   packInfo,
 		Images: images,
-	}
-
-	// State key is the pack name
-	stateKey := packName
-
-	// Send state event
-	_, err = c.SendStateEvent(ctx, roomID, event.Type{Type: "im.ponies.room_emotes", Class: event.StateEventType}, stateKey, content)
-	if err != nil {
-		return fmt.Errorf("failed to send state event: %w", err)
-	}
-
-	// Update pack's published rooms
-	if err := storage.UpdatePublished(dataDir, packName, roomID.String(), stateKey); err != nil {
-		return fmt.Errorf("failed to update published rooms: %w", err)
-	}
-
-	return nil
+		order:  order,
+	}, nil
 }
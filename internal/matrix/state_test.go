@@ -0,0 +1,133 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liminalpurple/matrix-stickerbook/internal/storage"
+)
+
+// TestBuildPackContent verifies pack and sticker metadata map onto the MSC2545 content
+func TestBuildPackContent(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "stickerbook-state-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	store := storage.NewJSONStore(tmpDir)
+	for _, sticker := range []storage.Sticker{
+		{ID: "sha256:one", Name: "happy_cat", CollectedAt: time.Now(), LocalMXC: "mxc://example.org/one",
+			GeneratedAltText: "A happy cat", AltTexts: map[string]string{"en": "A happy cat", "de": "Eine fröhliche Katze"}, Width: 128, Height: 96, MimeType: "image/png", Usage: []string{"emoticon"}},
+		{ID: "sha256:two", CollectedAt: time.Now(), LocalMXC: "mxc://example.org/two", OriginalBody: "original"},
+	} {
+		if err := store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+	}
+	if err := store.CreatePack("cats", "Cats", "@me:example.org"); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	if err := store.AddToPack("cats", []string{"sha256:one", "sha256:two"}); err != nil {
+		t.Fatalf("Failed to add to pack: %v", err)
+	}
+
+	content, err := buildPackContent(store, "cats", "en")
+	if err != nil {
+		t.Fatalf("Failed to build content: %v", err)
+	}
+
+	if content.Pack.DisplayName != "Cats" || content.Pack.Attribution != "@me:example.org" {
+		t.Errorf("Unexpected pack info: %+v", content.Pack)
+	}
+	if len(content.Pack.Usage) != 2 {
+		t.Errorf("Expected default usage of both, got %v", content.Pack.Usage)
+	}
+
+	happy, ok := content.Images["happy_cat"]
+	if !ok {
+		t.Fatalf("Expected image keyed by shortcode, got %v", content.Images)
+	}
+	if happy.Body != "A happy cat" || happy.Info.Width != 128 || len(happy.Usage) != 1 {
+		t.Errorf("Unexpected image data: %+v", happy)
+	}
+
+	// Stickers without a name fall back to their ID, and without alt-text to the original body
+	if two, ok := content.Images["sha256:two"]; !ok || two.Body != "original" {
+		t.Errorf("Expected fallback shortcode and body, got %+v", content.Images)
+	}
+
+	// Bodies use the pack's language, falling back to the main alt-text and original body
+	content, err = buildPackContent(store, "cats", "de")
+	if err != nil {
+		t.Fatalf("Failed to build content: %v", err)
+	}
+	if body := content.Images["happy_cat"].Body; body != "Eine fröhliche Katze" {
+		t.Errorf("Expected German body, got %q", body)
+	}
+	if body := content.Images["sha256:two"].Body; body != "original" {
+		t.Errorf("Expected original body fallback, got %q", body)
+	}
+	content, err = buildPackContent(store, "cats", "fr")
+	if err != nil {
+		t.Fatalf("Failed to build content: %v", err)
+	}
+	if body := content.Images["happy_cat"].Body; body != "A happy cat" {
+		t.Errorf("Expected main alt-text fallback, got %q", body)
+	}
+
+	if _, err := buildPackContent(store, "missing", "en"); err == nil {
+		t.Error("Expected error for missing pack")
+	}
+
+	// A pack whose stickers share a shortcode isn't built, since one would be lost.
+	// Saving a sticker again bypasses the checks, like data from older versions.
+	two, err := store.GetSticker("sha256:two")
+	if err != nil {
+		t.Fatalf("Failed to get sticker: %v", err)
+	}
+	two.Name = "happy_cat"
+	if err := store.AddSticker(*two); err != nil {
+		t.Fatalf("Failed to save sticker: %v", err)
+	}
+	_, err = buildPackContent(store, "cats", "en")
+	var conflict *storage.ShortcodeConflict
+	if !errors.As(err, &conflict) || conflict.Shortcode != "happy_cat" || len(conflict.StickerIDs) != 2 {
+		t.Errorf("Expected a shortcode conflict, got %v", err)
+	}
+}
+
+// TestPackContent_KeepsImageOrder verifies images keep their pack order through JSON
+func TestPackContent_KeepsImageOrder(t *testing.T) {
+	raw := `{"pack":{"display_name":"Cats"},"images":{"zebra":{"url":"mxc://a/1","body":"z","info":{"w":1,"h":1,"size":1,"mimetype":"image/png"}},"apple":{"url":"mxc://a/2","body":"a","info":{"w":1,"h":1,"size":1,"mimetype":"image/png"}}}}`
+
+	var content PackContent
+	if err := json.Unmarshal([]byte(raw), &content); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	shortcodes := content.Shortcodes()
+	if len(shortcodes) != 2 || shortcodes[0] != "zebra" || shortcodes[1] != "apple" {
+		t.Errorf("Expected event order [zebra apple], got %v", shortcodes)
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if strings.Index(string(data), `"zebra"`) > strings.Index(string(data), `"apple"`) {
+		t.Errorf("Expected zebra before apple, got %s", data)
+	}
+
+	// Round trip keeps the data itself intact
+	var again PackContent
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatalf("Failed to unmarshal marshalled content: %v", err)
+	}
+	if again.Pack.DisplayName != "Cats" || again.Images["apple"].URL != "mxc://a/2" {
+		t.Errorf("Unexpected round trip result: %+v", again)
+	}
+}
@@ -1,5 +1,7 @@
 // Package storage provides persistent storage for collected stickers and curated packs.
-// It manages two JSON files: collection.json (all collected stickers) and packs.json (pack definitions).
+// The Store interface has two implementations: JSONStore manages two JSON files,
+// collection.json (all collected stickers) and packs.json (pack definitions), and
+// SQLiteStore keeps the same data in an indexed stickerbook.db (built with -tags sqlite).
 package storage
 
 import (
@@ -9,25 +11,24 @@ import (
 	"path/filepath"
 )
 
-// AddSticker adds a new sticker to the collection
+// AddSticker adds a new sticker to the collection, replacing one with the same ID but
+// keeping the packs it's in
 func AddSticker(dataDir string, sticker Sticker) error {
-	collection, err := LoadCollection(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
-	}
-
-	// Check if sticker already exists (by ID)
-	for i, existing := range collection.Stickers {
-		if existing.ID == sticker.ID {
-			// Update existing sticker
-			collection.Stickers[i] = sticker
-			return SaveCollection(dataDir, collection)
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		// Check if sticker already exists (by ID)
+		for i, existing := range collection.Stickers {
+			if existing.ID == sticker.ID {
+				// Update existing sticker; pack membership belongs to the packs
+				sticker.InPacks = existing.InPacks
+				collection.Stickers[i] = sticker
+				return nil
+			}
 		}
-	}
 
-	// Add new sticker
-	collection.Stickers = append(collection.Stickers, sticker)
-	return SaveCollection(dataDir, collection)
+		// Add new sticker
+		collection.Stickers = append(collection.Stickers, sticker)
+		return nil
+	})
 }
 
 // GetSticker retrieves a sticker by ID
@@ -43,7 +44,7 @@ func GetSticker(dataDir string, id string) (*Sticker, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("sticker not found: %s", id)
+	return nil, fmt.Errorf("%w: %s", ErrStickerNotFound, id)
 }
 
 // ListStickers returns all collected stickers
@@ -56,103 +57,91 @@ func ListStickers(dataDir string) ([]Sticker, error) {
 	return collection.Stickers, nil
 }
 
-// UpdateAltText updates the generated alt-text for a sticker
-func UpdateAltText(dataDir string, id string, altText string) error {
+// ListUnsorted returns stickers that are not in any pack
+func ListUnsorted(dataDir string) ([]Sticker, error) {
 	collection, err := LoadCollection(dataDir)
 	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
+		return nil, fmt.Errorf("failed to load collection: %w", err)
 	}
 
-	for i, sticker := range collection.Stickers {
-		if sticker.ID == id {
-			collection.Stickers[i].GeneratedAltText = altText
-			return SaveCollection(dataDir, collection)
+	unsorted := []Sticker{}
+	for _, sticker := range collection.Stickers {
+		if len(sticker.InPacks) == 0 {
+			unsorted = append(unsorted, sticker)
 		}
 	}
 
-	return fmt.Errorf("sticker not found: %s", id)
+	return unsorted, nil
+}
+
+// UpdateAltText replaces a sticker's alt-text in every language and records who wrote it
+func UpdateAltText(dataDir string, id string, altText AltText) error {
+	return updateSticker(dataDir, id, func(sticker *Sticker) {
+		sticker.GeneratedAltText = altText.Text
+		sticker.AltTexts = altText.Languages
+		sticker.AltTextBy = altText.By
+		sticker.AltTextModel = altText.Model
+	})
 }
 
 // DeleteSticker removes a sticker from the collection and all packs
 func DeleteSticker(dataDir string, id string) error {
-	// Load collection
-	collection, err := LoadCollection(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
-	}
-
-	// Find and remove sticker
-	found := false
-	var packNames []string
-	for i, sticker := range collection.Stickers {
-		if sticker.ID == id {
-			// Remember which packs it's in
-			packNames = sticker.InPacks
-			// Remove from collection
-			collection.Stickers = append(collection.Stickers[:i], collection.Stickers[i+1:]...)
-			found = true
-			break
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		// Find and remove sticker
+		found := false
+		for i, sticker := range collection.Stickers {
+			if sticker.ID == id {
+				collection.Stickers = append(collection.Stickers[:i], collection.Stickers[i+1:]...)
+				found = true
+				break
+			}
 		}
-	}
-
-	if !found {
-		return fmt.Errorf("sticker not found: %s", id)
-	}
 
-	// Save updated collection
-	if err := SaveCollection(dataDir, collection); err != nil {
-		return fmt.Errorf("failed to save collection: %w", err)
-	}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrStickerNotFound, id)
+		}
 
-	// Remove from all packs it was in
-	for _, packName := range packNames {
-		if err := RemoveFromPack(dataDir, packName, []string{id}); err != nil {
-			// Log but don't fail - the sticker is already deleted from collection
-			fmt.Printf("Warning: failed to remove sticker from pack %s: %v\n", packName, err)
+		// Remove from all packs it was in, in the same transaction
+		for i := range packsData.Packs {
+			packsData.Packs[i].StickerIDs = removeString(packsData.Packs[i].StickerIDs, id)
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 // LoadCollection loads the collection from disk
 func LoadCollection(dataDir string) (*Collection, error) {
-	collectionPath := filepath.Join(dataDir, "collection.json")
-
-	// Check if file exists
-	if _, err := os.Stat(collectionPath); os.IsNotExist(err) {
-		// Return empty collection if file doesn't exist
-		return &Collection{Stickers: []Sticker{}}, nil
-	}
-
-	data, err := os.ReadFile(collectionPath)
+	unlock, err := lockForRead(dataDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read collection file: %w", err)
-	}
-
-	var collection Collection
-	if err := json.Unmarshal(data, &collection); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal collection: %w", err)
+		return nil, err
 	}
+	defer unlock()
 
-	return &collection, nil
+	collection, _, err := readCollection(dataDir)
+	return collection, err
 }
 
-// SaveCollection saves the collection to disk
+// SaveCollection saves the collection to disk, atomically replacing the previous file
 func SaveCollection(dataDir string, collection *Collection) error {
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	collectionPath := filepath.Join(dataDir, "collection.json")
-
+	collection.SchemaVersion = CurrentSchemaVersion
 	data, err := json.MarshalIndent(collection, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal collection: %w", err)
 	}
 
-	if err := os.WriteFile(collectionPath, data, 0644); err != nil {
+	unlock, err := lockDataDir(dataDir, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := writeFileAtomic(filepath.Join(dataDir, collectionFile), data, 0644); err != nil {
 		return fmt.Errorf("failed to write collection file: %w", err)
 	}
 
@@ -161,36 +150,59 @@ func SaveCollection(dataDir string, collection *Collection) error {
 
 // SetStickerUsage sets the usage types for a specific sticker
 func SetStickerUsage(dataDir string, stickerID string, usage []string) error {
-	collection, err := LoadCollection(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
-	}
+	return updateSticker(dataDir, stickerID, func(sticker *Sticker) {
+		sticker.Usage = usage
+	})
+}
 
-	// Find the sticker
-	for i, sticker := range collection.Stickers {
-		if sticker.ID == stickerID {
-			collection.Stickers[i].Usage = usage
-			return SaveCollection(dataDir, collection)
+// SetStickerName sets the shortcode name for a specific sticker, dropping any
+// suggested one. It fails with a *ShortcodeConflict if another sticker in one of its
+// packs already uses the name.
+func SetStickerName(dataDir string, stickerID string, name string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		for i := range packsData.Packs {
+			pack := &packsData.Packs[i]
+			if !containsString(pack.StickerIDs, stickerID) {
+				continue
+			}
+			if err := checkShortcodeFree(pack.Name, packMembers(collection, pack), stickerID, name); err != nil {
+				return err
+			}
+		}
+
+		for i := range collection.Stickers {
+			if collection.Stickers[i].ID == stickerID {
+				collection.Stickers[i].Name = name
+				collection.Stickers[i].SuggestedName = ""
+				return nil
+			}
 		}
-	}
 
-	return fmt.Errorf("sticker not found: %s", stickerID)
+		return fmt.Errorf("%w: %s", ErrStickerNotFound, stickerID)
+	})
 }
 
-// SetStickerName sets the shortcode name for a specific sticker
-func SetStickerName(dataDir string, stickerID string, name string) error {
-	collection, err := LoadCollection(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
-	}
+// updateSticker applies fn to one sticker inside a transaction
+func updateSticker(dataDir string, stickerID string, fn func(sticker *Sticker)) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		for i := range collection.Stickers {
+			if collection.Stickers[i].ID == stickerID {
+				fn(&collection.Stickers[i])
+				return nil
+			}
+		}
 
-	// Find the sticker
-	for i, sticker := range collection.Stickers {
-		if sticker.ID == stickerID {
-			collection.Stickers[i].Name = name
-			return SaveCollection(dataDir, collection)
+		return fmt.Errorf("%w: %s", ErrStickerNotFound, stickerID)
+	})
+}
+
+// removeString returns slice without any occurrences of str
+func removeString(slice []string, str string) []string {
+	result := []string{}
+	for _, s := range slice {
+		if s != str {
+			result = append(result, s)
 		}
 	}
-
-	return fmt.Errorf("sticker not found: %s", stickerID)
+	return result
 }
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// jobsFile holds the bot's queue of sticker collection jobs
+	jobsFile = "jobs.json"
+	// jobsLockFile is flocked by the process working through the queue, for as long as it runs
+	jobsLockFile = "jobs.lock"
+)
+
+// ErrJobsInUse is returned by LockJobs when another process owns the job queue
+var ErrJobsInUse = errors.New("the job queue is in use by another stickerbook process")
+
+// Job statuses
+const (
+	JobPending = "pending" // Waiting for a worker, possibly until NextAttempt
+	JobRunning = "running" // Being worked on
+	JobFailed  = "failed"  // Gave up; kept until retried or cleared
+)
+
+// Job kinds
+const (
+	JobCollect    = ""           // Collect an image as a sticker
+	JobRegenerate = "regenerate" // Describe a collected sticker again
+)
+
+// Job is a queued sticker collection or alt-text regeneration. Each stage's result is kept on the job, so a
+// retry (or a restart) resumes at the stage that failed rather than redoing the
+// upload or alt-text generation.
+type Job struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind,omitempty"` // JobCollect or JobRegenerate
+	ReactionID  string    `json:"reaction_id"`    // Reaction that requested the collection
+	RoomID      string    `json:"room_id"`
+	EventID     string    `json:"event_id"` // Image or sticker event to collect
+	RequestedBy string    `json:"requested_by"`
+	Pack        string    `json:"pack,omitempty"` // Pack to file the sticker into, if the reaction asked for one
+	Status      string    `json:"status"`
+	Stage       string    `json:"stage,omitempty"`    // Stage that last ran or failed
+	Attempts    int       `json:"attempts,omitempty"` // Failed attempts at the current stage
+	LastError   string    `json:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitzero"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	FeedbackID  string    `json:"feedback_id,omitempty"` // The bot's failure reaction or reply, removed if a retry succeeds
+
+	// Set by a command replying to an image that isn't collected yet
+	Command      string `json:"command,omitempty"`       // Command to run as RequestedBy once the sticker is collected
+	CommandEvent string `json:"command_event,omitempty"` // The command's message, answered with the result (also for regeneration)
+
+	// Set by !sticker alt --regenerate, which redescribes one sticker on request
+	Hint         string `json:"hint,omitempty"`          // Passed on to the model
+	ReplaceHuman bool   `json:"replace_human,omitempty"` // Replace alt-text written by hand too
+
+	// Set by a pack import, which has no event to react or reply to
+	Name  string   `json:"name,omitempty"`  // Shortcode from the imported pack
+	Usage []string `json:"usage,omitempty"` // Usage from the imported pack
+
+	// Stage results
+	SourceMXC    string            `json:"source_mxc,omitempty"`
+	File         json.RawMessage   `json:"file,omitempty"` // Encrypted file info, for media in encrypted rooms
+	OriginalBody string            `json:"original_body,omitempty"`
+	LocalMXC     string            `json:"local_mxc,omitempty"`
+	AltText      string            `json:"alt_text,omitempty"`
+	AltTexts     map[string]string `json:"alt_texts,omitempty"`      // Alt-text by language
+	AltTextModel string            `json:"alt_text_model,omitempty"` // Model that wrote AltTexts
+	Tags         []string          `json:"tags,omitempty"`           // Tags suggested alongside the alt-text
+	Shortcode    string            `json:"shortcode,omitempty"`      // Shortcode suggested alongside the alt-text
+	StickerID    string            `json:"sticker_id,omitempty"`
+}
+
+// jobsData is the structure of jobs.json
+type jobsData struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// LoadJobs reads jobs.json, returning no jobs if it doesn't exist yet
+func LoadJobs(dataDir string) ([]Job, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, jobsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Job{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", jobsFile, err)
+	}
+
+	var jobs jobsData
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", jobsFile, err)
+	}
+	if jobs.Jobs == nil {
+		jobs.Jobs = []Job{}
+	}
+
+	return jobs.Jobs, nil
+}
+
+// SaveJobs atomically replaces jobs.json
+func SaveJobs(dataDir string, jobs []Job) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(jobsData{Jobs: jobs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal jobs: %w", err)
+	}
+
+	return writeFileAtomic(filepath.Join(dataDir, jobsFile), data, 0644)
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// eventJournalFile records the Matrix events the bot has already handled
+const eventJournalFile = "events.json"
+
+// DefaultJournalRetention is how long handled events are remembered when not configured
+const DefaultJournalRetention = 30 * 24 * time.Hour
+
+// Kinds of handled events
+const (
+	EventKindReaction = "reaction"
+	EventKindCommand  = "command"
+)
+
+// ProcessedEvent is a Matrix event the bot has acted on and what came of it
+type ProcessedEvent struct {
+	EventID     string    `json:"event_id"`
+	RoomID      string    `json:"room_id"`
+	Kind        string    `json:"kind"`    // EventKindReaction or EventKindCommand
+	Outcome     string    `json:"outcome"` // Short summary of the result or error
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// eventJournalData is the structure of events.json
+type eventJournalData struct {
+	Events []ProcessedEvent `json:"events"`
+}
+
+// EventJournal remembers handled events so a reaction or command replayed after a
+// restart isn't acted on twice. Entries older than the retention window are pruned
+// whenever the journal is loaded or written.
+type EventJournal struct {
+	mu        sync.Mutex
+	dataDir   string
+	retention time.Duration
+	events    map[string]ProcessedEvent // Event ID → entry, nil until loaded
+}
+
+// NewEventJournal creates a journal in the data directory. The file is read on first use.
+func NewEventJournal(dataDir string, retention time.Duration) *EventJournal {
+	if retention <= 0 {
+		retention = DefaultJournalRetention
+	}
+	return &EventJournal{dataDir: dataDir, retention: retention}
+}
+
+// Get returns the journal entry for an event, if it has been handled
+func (j *EventJournal) Get(eventID string) (*ProcessedEvent, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	entry, ok := j.events[eventID]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Record adds a handled event to the journal and writes it out
+func (j *EventJournal) Record(entry ProcessedEvent) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.load(); err != nil {
+		return err
+	}
+
+	if entry.ProcessedAt.IsZero() {
+		entry.ProcessedAt = time.Now()
+	}
+	j.events[entry.EventID] = entry
+	j.prune()
+
+	return j.save()
+}
+
+// load reads events.json on first use; callers must hold mu
+func (j *EventJournal) load() error {
+	if j.events != nil {
+		return nil
+	}
+
+	var journal eventJournalData
+	data, err := os.ReadFile(filepath.Join(j.dataDir, eventJournalFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", eventJournalFile, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &journal); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", eventJournalFile, err)
+		}
+	}
+
+	j.events = make(map[string]ProcessedEvent, len(journal.Events))
+	for _, entry := range journal.Events {
+		j.events[entry.EventID] = entry
+	}
+	j.prune()
+
+	return nil
+}
+
+// prune drops entries older than the retention window; callers must hold mu
+func (j *EventJournal) prune() {
+	cutoff := time.Now().Add(-j.retention)
+	for eventID, entry := range j.events {
+		if entry.ProcessedAt.Before(cutoff) {
+			delete(j.events, eventID)
+		}
+	}
+}
+
+// save atomically replaces events.json, oldest entries first; callers must hold mu
+func (j *EventJournal) save() error {
+	if err := os.MkdirAll(j.dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	journal := eventJournalData{Events: make([]ProcessedEvent, 0, len(j.events))}
+	for _, entry := range j.events {
+		journal.Events = append(journal.Events, entry)
+	}
+	slices.SortFunc(journal.Events, func(a, b ProcessedEvent) int {
+		return a.ProcessedAt.Compare(b.ProcessedAt)
+	})
+
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal event journal: %w", err)
+	}
+
+	return writeFileAtomic(filepath.Join(j.dataDir, eventJournalFile), data, 0644)
+}
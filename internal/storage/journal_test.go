@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestEventJournal_RecordAndGet verifies handled events are remembered across instances
+func TestEventJournal_RecordAndGet(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	journal := NewEventJournal(tmpDir, time.Hour)
+
+	entry, err := journal.Get("$event1")
+	if err != nil {
+		t.Fatalf("Failed to get from empty journal: %v", err)
+	}
+	if entry != nil {
+		t.Errorf("Expected no entry, got %+v", entry)
+	}
+
+	if err := journal.Record(ProcessedEvent{EventID: "$event1", RoomID: "!room", Kind: EventKindReaction, Outcome: "collected"}); err != nil {
+		t.Fatalf("Failed to record event: %v", err)
+	}
+
+	// A fresh journal (as after a restart) reads it back from disk
+	reopened := NewEventJournal(tmpDir, time.Hour)
+	entry, err = reopened.Get("$event1")
+	if err != nil {
+		t.Fatalf("Failed to get event: %v", err)
+	}
+	if entry == nil || entry.Outcome != "collected" || entry.Kind != EventKindReaction {
+		t.Errorf("Expected recorded reaction, got %+v", entry)
+	}
+	if entry != nil && entry.ProcessedAt.IsZero() {
+		t.Error("Expected processed time to be filled in")
+	}
+}
+
+// TestEventJournal_Prunes verifies entries older than the retention window are dropped
+func TestEventJournal_Prunes(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	journal := NewEventJournal(tmpDir, time.Hour)
+	if err := journal.Record(ProcessedEvent{EventID: "$old", Kind: EventKindCommand, ProcessedAt: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("Failed to record old event: %v", err)
+	}
+	if err := journal.Record(ProcessedEvent{EventID: "$new", Kind: EventKindCommand}); err != nil {
+		t.Fatalf("Failed to record new event: %v", err)
+	}
+
+	reopened := NewEventJournal(tmpDir, time.Hour)
+	if entry, _ := reopened.Get("$old"); entry != nil {
+		t.Error("Expected old event to be pruned")
+	}
+	if entry, _ := reopened.Get("$new"); entry == nil {
+		t.Error("Expected new event to be kept")
+	}
+}
@@ -0,0 +1,168 @@
+package storage
+
+// JSONStore is a Store backed by collection.json and packs.json in a data directory.
+// Every operation rereads and rewrites the files, so it suits small collections that
+// are easy to view and edit by hand. Writes are atomic and serialised with a lock on
+// the data directory, so it is safe to share between goroutines and processes.
+type JSONStore struct {
+	dataDir string
+}
+
+// NewJSONStore creates a JSON file store rooted at dataDir
+func NewJSONStore(dataDir string) *JSONStore {
+	return &JSONStore{dataDir: dataDir}
+}
+
+// DataDir returns the directory holding the JSON files
+func (s *JSONStore) DataDir() string {
+	return s.dataDir
+}
+
+// Update runs fn in a transaction over both JSON files (see the package-level Update)
+func (s *JSONStore) Update(fn func(collection *Collection, packsData *PacksData) error) error {
+	return Update(s.dataDir, fn)
+}
+
+// AddSticker adds a new sticker to the collection, replacing one with the same ID but
+// keeping the packs it's in
+func (s *JSONStore) AddSticker(sticker Sticker) error {
+	return AddSticker(s.dataDir, sticker)
+}
+
+// GetSticker retrieves a sticker by ID
+func (s *JSONStore) GetSticker(id string) (*Sticker, error) {
+	return GetSticker(s.dataDir, id)
+}
+
+// ListStickers returns all collected stickers
+func (s *JSONStore) ListStickers() ([]Sticker, error) {
+	return ListStickers(s.dataDir)
+}
+
+// ListUnsorted returns stickers that are not in any pack
+func (s *JSONStore) ListUnsorted() ([]Sticker, error) {
+	return ListUnsorted(s.dataDir)
+}
+
+// UpdateAltText replaces a sticker's alt-text and records who wrote it
+func (s *JSONStore) UpdateAltText(id string, altText AltText) error {
+	return UpdateAltText(s.dataDir, id, altText)
+}
+
+// SetStickerUsage sets the usage types for a specific sticker
+func (s *JSONStore) SetStickerUsage(id string, usage []string) error {
+	return SetStickerUsage(s.dataDir, id, usage)
+}
+
+// SetStickerName sets the shortcode name for a specific sticker, dropping any
+// suggested one. It fails with a *ShortcodeConflict if another sticker in one of its
+// packs already uses the name.
+func (s *JSONStore) SetStickerName(id string, name string) error {
+	return SetStickerName(s.dataDir, id, name)
+}
+
+// DeleteSticker removes a sticker from the collection and all packs
+func (s *JSONStore) DeleteSticker(id string) error {
+	return DeleteSticker(s.dataDir, id)
+}
+
+// TagSticker adds tags to a sticker, ignoring ones it already has
+func (s *JSONStore) TagSticker(id string, tags []string) error {
+	return TagSticker(s.dataDir, id, tags)
+}
+
+// UntagSticker removes tags from a sticker
+func (s *JSONStore) UntagSticker(id string, tags []string) error {
+	return UntagSticker(s.dataDir, id, tags)
+}
+
+// ListTags returns every tag in use with its sticker count
+func (s *JSONStore) ListTags() ([]TagCount, error) {
+	return ListTags(s.dataDir)
+}
+
+// TaggedStickers returns the stickers matching a tag query
+func (s *JSONStore) TaggedStickers(query TagQuery) ([]Sticker, error) {
+	return TaggedStickers(s.dataDir, query)
+}
+
+// CreatePack creates a new empty pack with author attribution
+func (s *JSONStore) CreatePack(name string, displayName string, attribution string) error {
+	return CreatePackWithAttribution(s.dataDir, name, displayName, attribution)
+}
+
+// DeletePack removes a pack, leaving its stickers in the collection
+func (s *JSONStore) DeletePack(name string) error {
+	return DeletePack(s.dataDir, name)
+}
+
+// RenamePack changes a pack's name, updating every sticker that references it
+func (s *JSONStore) RenamePack(oldName string, newName string) error {
+	return RenamePack(s.dataDir, oldName, newName)
+}
+
+// SetPackDisplayName sets the user-facing name of a pack
+func (s *JSONStore) SetPackDisplayName(name string, displayName string) error {
+	return SetPackDisplayName(s.dataDir, name, displayName)
+}
+
+// MovePackSticker moves a sticker to a 1-based position within a pack
+func (s *JSONStore) MovePackSticker(packName string, stickerID string, position int) error {
+	return MovePackSticker(s.dataDir, packName, stickerID, position)
+}
+
+// GetPack retrieves a pack by name
+func (s *JSONStore) GetPack(name string) (*Pack, error) {
+	return GetPack(s.dataDir, name)
+}
+
+// ListPacks returns all packs
+func (s *JSONStore) ListPacks() ([]Pack, error) {
+	return ListPacks(s.dataDir)
+}
+
+// PackStickers returns the stickers in a pack, in pack order
+func (s *JSONStore) PackStickers(name string) ([]Sticker, error) {
+	return PackStickers(s.dataDir, name)
+}
+
+// AddToPack adds stickers to a pack, failing with a *ShortcodeConflict if one would
+// share its shortcode with another sticker in the pack
+func (s *JSONStore) AddToPack(packName string, stickerIDs []string) error {
+	return AddToPack(s.dataDir, packName, stickerIDs)
+}
+
+// RemoveFromPack removes stickers from a pack
+func (s *JSONStore) RemoveFromPack(packName string, stickerIDs []string) error {
+	return RemoveFromPack(s.dataDir, packName, stickerIDs)
+}
+
+// UpdatePublished records that a pack has been published to a room
+func (s *JSONStore) UpdatePublished(packName string, roomID string, stateKey string) error {
+	return UpdatePublished(s.dataDir, packName, roomID, stateKey)
+}
+
+// RemovePublished forgets that a pack was published to a room
+func (s *JSONStore) RemovePublished(packName string, roomID string) error {
+	return RemovePublished(s.dataDir, packName, roomID)
+}
+
+// SetPackAvatar sets the avatar URL for a pack
+func (s *JSONStore) SetPackAvatar(packName string, avatarURL string) error {
+	return SetPackAvatar(s.dataDir, packName, avatarURL)
+}
+
+// SetPackUsage sets the default usage for all stickers in a pack
+func (s *JSONStore) SetPackUsage(packName string, usage []string) error {
+	return SetPackUsage(s.dataDir, packName, usage)
+}
+
+// SetPersonalPack records which pack is published as the user's personal emotes
+func (s *JSONStore) SetPersonalPack(packName string) error {
+	return SetPersonalPack(s.dataDir, packName)
+}
+
+// Close is a no-op for the JSON store
+func (s *JSONStore) Close() error {
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package storage
+
+import "sync"
+
+// dataDirLock serialises access within this process on platforms without flock
+var dataDirLock sync.RWMutex
+
+// lockDataDir falls back to an in-process lock; separate processes are not excluded
+func lockDataDir(dataDir string, exclusive bool) (func(), error) {
+	if exclusive {
+		dataDirLock.Lock()
+		return dataDirLock.Unlock, nil
+	}
+	dataDirLock.RLock()
+	return dataDirLock.RUnlock, nil
+}
+
+// LockJobs falls back to a no-op; separate processes are not excluded
+func LockJobs(dataDir string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestLockJobs verifies only one owner can have the job queue at a time
+func TestLockJobs(t *testing.T) {
+	dataDir := t.TempDir()
+
+	unlock, err := LockJobs(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to lock job queue: %v", err)
+	}
+	if _, err := LockJobs(dataDir); !errors.Is(err, ErrJobsInUse) {
+		t.Fatalf("Expected ErrJobsInUse while locked, got %v", err)
+	}
+
+	unlock()
+	unlock, err = LockJobs(dataDir)
+	if err != nil {
+		t.Fatalf("Expected the lock to be free again, got %v", err)
+	}
+	unlock()
+}
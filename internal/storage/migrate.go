@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// migration upgrades the raw JSON of the data files by one schema version.
+// Migrations work on generic maps rather than the structs so they keep working
+// after the structs have moved on.
+type migration struct {
+	description string
+	collection  func(doc map[string]any) error
+	packs       func(doc map[string]any) error
+}
+
+// migrations[i] upgrades files from schema version i to i+1
+var migrations = []migration{
+	{
+		description: "add schema_version, fill in missing in_packs and published_rooms",
+		collection: func(doc map[string]any) error {
+			stickers, _ := doc["stickers"].([]any)
+			for _, s := range stickers {
+				if sticker, ok := s.(map[string]any); ok && sticker["in_packs"] == nil {
+					sticker["in_packs"] = []any{}
+				}
+			}
+			if stickers == nil {
+				doc["stickers"] = []any{}
+			}
+			return nil
+		},
+		packs: func(doc map[string]any) error {
+			packs, _ := doc["packs"].([]any)
+			for _, p := range packs {
+				if pack, ok := p.(map[string]any); ok {
+					if pack["sticker_ids"] == nil {
+						pack["sticker_ids"] = []any{}
+					}
+					if pack["published_rooms"] == nil {
+						pack["published_rooms"] = map[string]any{}
+					}
+				}
+			}
+			if packs == nil {
+				doc["packs"] = []any{}
+			}
+			return nil
+		},
+	},
+}
+
+// CurrentSchemaVersion is the schema version written by this build
+var CurrentSchemaVersion = len(migrations)
+
+// MigrationResult describes the upgrade of one data file
+type MigrationResult struct {
+	File        string   // Data file name (collection.json or packs.json)
+	FromVersion int      // Schema version found on disk
+	ToVersion   int      // Schema version after migration
+	Steps       []string // Description of each migration applied
+	BackupPath  string   // Copy of the pre-migration file (empty in a dry run)
+}
+
+// fileState remembers what a data file looked like on disk before decoding
+type fileState struct {
+	raw     []byte // Contents on disk, nil if the file doesn't exist
+	version int    // Schema version on disk
+}
+
+// Migrate upgrades collection.json and packs.json in dataDir to CurrentSchemaVersion,
+// keeping a backup of each file as it was before migration. With dryRun set it only
+// reports what would change. Files already at the current version are left alone.
+func Migrate(dataDir string, dryRun bool) ([]MigrationResult, error) {
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	unlock, err := lockDataDir(dataDir, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := recoverJournal(dataDir); err != nil {
+		return nil, err
+	}
+
+	collection, collectionState, err := readCollection(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	packsData, packsState, err := readPacks(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MigrationResult
+	changes := make(map[string][]byte)
+	for _, file := range []struct {
+		name  string
+		state fileState
+		data  any
+	}{
+		{collectionFile, collectionState, collection},
+		{packsFile, packsState, packsData},
+	} {
+		if file.state.raw == nil || file.state.version == CurrentSchemaVersion {
+			continue
+		}
+
+		result := MigrationResult{
+			File:        file.name,
+			FromVersion: file.state.version,
+			ToVersion:   CurrentSchemaVersion,
+		}
+		for _, m := range migrations[file.state.version:] {
+			result.Steps = append(result.Steps, m.description)
+		}
+
+		if !dryRun {
+			if result.BackupPath, err = backupFile(dataDir, file.name, file.state); err != nil {
+				return nil, err
+			}
+			data, err := json.MarshalIndent(file.data, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal %s: %w", file.name, err)
+			}
+			changes[file.name] = data
+		}
+
+		results = append(results, result)
+	}
+
+	if err := commitFiles(dataDir, changes); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// migrateJSON upgrades raw file contents to CurrentSchemaVersion, returning the
+// upgraded JSON and the version found. Files newer than this build are rejected.
+func migrateJSON(name string, raw []byte) ([]byte, int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	version := 0
+	if v, ok := doc["schema_version"].(float64); ok {
+		version = int(v)
+	}
+
+	if version > CurrentSchemaVersion {
+		return nil, version, fmt.Errorf("%s has schema version %d, but this stickerbook only supports up to %d - please upgrade stickerbook", name, version, CurrentSchemaVersion)
+	}
+	if version == CurrentSchemaVersion {
+		return raw, version, nil
+	}
+
+	for i := version; i < CurrentSchemaVersion; i++ {
+		apply := migrations[i].collection
+		if name == packsFile {
+			apply = migrations[i].packs
+		}
+		if apply != nil {
+			if err := apply(doc); err != nil {
+				return nil, version, fmt.Errorf("failed to migrate %s to schema version %d: %w", name, i+1, err)
+			}
+		}
+		doc["schema_version"] = i + 1
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to marshal migrated %s: %w", name, err)
+	}
+
+	return migrated, version, nil
+}
+
+// backupFile keeps a copy of a data file from before it was migrated.
+// An existing backup for the same version is kept, since it is the older copy.
+func backupFile(dataDir string, name string, state fileState) (string, error) {
+	backupPath := filepath.Join(dataDir, fmt.Sprintf("%s.v%d.bak", name, state.version))
+	if _, err := os.Stat(backupPath); err == nil {
+		return backupPath, nil
+	}
+
+	if err := writeFileAtomic(backupPath, state.raw, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", name, err)
+	}
+
+	return backupPath, nil
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyCollection is a collection.json from before schema versioning
+const legacyCollection = `{
+  "stickers": [
+    {"id": "sha256:abc123", "name": "old", "in_packs": null}
+  ]
+}`
+
+// legacyPacks is a packs.json from before schema versioning
+const legacyPacks = `{
+  "packs": [
+    {"name": "cats", "display_name": "Cats", "sticker_ids": null}
+  ]
+}`
+
+// TestLoad_UpgradesLegacyFiles verifies unversioned files are upgraded in memory on load
+func TestLoad_UpgradesLegacyFiles(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	writeTestFile(t, tmpDir, collectionFile, legacyCollection)
+	writeTestFile(t, tmpDir, packsFile, legacyPacks)
+
+	collection, err := LoadCollection(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load collection: %v", err)
+	}
+	if collection.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", CurrentSchemaVersion, collection.SchemaVersion)
+	}
+	if collection.Stickers[0].InPacks == nil {
+		t.Error("Expected in_packs to be filled in")
+	}
+
+	pack, err := GetPack(tmpDir, "cats")
+	if err != nil {
+		t.Fatalf("Failed to get pack: %v", err)
+	}
+	if pack.StickerIDs == nil || pack.PublishedRooms == nil {
+		t.Error("Expected sticker_ids and published_rooms to be filled in")
+	}
+
+	// Loading alone must not touch the files
+	data, err := os.ReadFile(filepath.Join(tmpDir, collectionFile))
+	if err != nil {
+		t.Fatalf("Failed to read collection: %v", err)
+	}
+	if string(data) != legacyCollection {
+		t.Error("Expected collection.json to be unchanged by loading")
+	}
+}
+
+// TestMigrate_DryRun verifies a dry run reports changes without writing anything
+func TestMigrate_DryRun(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	writeTestFile(t, tmpDir, collectionFile, legacyCollection)
+	writeTestFile(t, tmpDir, packsFile, legacyPacks)
+
+	results, err := Migrate(tmpDir, true)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, result := range results {
+		if result.FromVersion != 0 || result.ToVersion != CurrentSchemaVersion {
+			t.Errorf("Unexpected versions for %s: %d -> %d", result.File, result.FromVersion, result.ToVersion)
+		}
+		if len(result.Steps) == 0 {
+			t.Errorf("Expected steps for %s", result.File)
+		}
+		if result.BackupPath != "" {
+			t.Errorf("Expected no backup in a dry run, got %s", result.BackupPath)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, collectionFile))
+	if err != nil {
+		t.Fatalf("Failed to read collection: %v", err)
+	}
+	if string(data) != legacyCollection {
+		t.Error("Expected collection.json to be unchanged by a dry run")
+	}
+	assertNotExists(t, filepath.Join(tmpDir, collectionFile+".v0.bak"))
+}
+
+// TestMigrate_WritesVersionAndBackup verifies migration rewrites files and keeps the originals
+func TestMigrate_WritesVersionAndBackup(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	writeTestFile(t, tmpDir, collectionFile, legacyCollection)
+	writeTestFile(t, tmpDir, packsFile, legacyPacks)
+
+	if _, err := Migrate(tmpDir, false); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	for name, original := range map[string]string{collectionFile: legacyCollection, packsFile: legacyPacks} {
+		backup, err := os.ReadFile(filepath.Join(tmpDir, name+".v0.bak"))
+		if err != nil {
+			t.Fatalf("Expected backup of %s: %v", name, err)
+		}
+		if string(backup) != original {
+			t.Errorf("Expected backup of %s to match the original", name)
+		}
+
+		data, err := os.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if !strings.Contains(string(data), `"schema_version": 1`) {
+			t.Errorf("Expected %s to record the schema version", name)
+		}
+	}
+
+	// A second run has nothing left to do
+	results, err := Migrate(tmpDir, false)
+	if err != nil {
+		t.Fatalf("Second migrate failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected no migrations on second run, got %d", len(results))
+	}
+}
+
+// TestUpdate_BacksUpLegacyFile verifies writing to an old file keeps a backup first
+func TestUpdate_BacksUpLegacyFile(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	writeTestFile(t, tmpDir, collectionFile, legacyCollection)
+
+	if err := SetStickerName(tmpDir, "sha256:abc123", "new"); err != nil {
+		t.Fatalf("Failed to set name: %v", err)
+	}
+
+	backup, err := os.ReadFile(filepath.Join(tmpDir, collectionFile+".v0.bak"))
+	if err != nil {
+		t.Fatalf("Expected backup: %v", err)
+	}
+	if string(backup) != legacyCollection {
+		t.Error("Expected backup to match the original file")
+	}
+}
+
+// TestLoad_RejectsNewerVersion verifies files from a newer stickerbook are refused
+func TestLoad_RejectsNewerVersion(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	writeTestFile(t, tmpDir, collectionFile, `{"schema_version": 999, "stickers": []}`)
+
+	if _, err := LoadCollection(tmpDir); err == nil || !strings.Contains(err.Error(), "please upgrade") {
+		t.Errorf("Expected newer schema version to be refused, got %v", err)
+	}
+	if err := AddSticker(tmpDir, testSticker("sha256:def456")); err == nil {
+		t.Error("Expected write to a newer schema version to be refused")
+	}
+	if _, err := Migrate(tmpDir, true); err == nil {
+		t.Error("Expected migrate to refuse a newer schema version")
+	}
+}
@@ -14,167 +14,171 @@ func CreatePack(dataDir string, name string, displayName string) error {
 
 // CreatePackWithAttribution creates a new empty pack with author attribution
 func CreatePackWithAttribution(dataDir string, name string, displayName string, attribution string) error {
-	packsData, err := LoadPacks(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load packs: %w", err)
-	}
-
-	// Check if pack already exists
-	for _, pack := range packsData.Packs {
-		if pack.Name == name {
-			return fmt.Errorf("pack already exists: %s", name)
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		// Check if pack already exists
+		for _, pack := range packsData.Packs {
+			if pack.Name == name {
+				return fmt.Errorf("pack already exists: %s", name)
+			}
 		}
-	}
-
-	// Create new pack
-	newPack := Pack{
-		Name:           name,
-		DisplayName:    displayName,
-		Attribution:    attribution,
-		StickerIDs:     []string{},
-		PublishedRooms: make(map[string]string),
-	}
-
-	packsData.Packs = append(packsData.Packs, newPack)
-	return SavePacks(dataDir, packsData)
-}
-
-// AddToPack adds stickers to a pack
-func AddToPack(dataDir string, packName string, stickerIDs []string) error {
-	packsData, err := LoadPacks(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load packs: %w", err)
-	}
 
-	collection, err := LoadCollection(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
-	}
-
-	// Find the pack
-	packIndex := -1
-	for i, pack := range packsData.Packs {
-		if pack.Name == packName {
-			packIndex = i
-			break
+		// Create new pack
+		newPack := Pack{
+			Name:           name,
+			DisplayName:    displayName,
+			Attribution:    attribution,
+			StickerIDs:     []string{},
+			PublishedRooms: make(map[string]string),
 		}
-	}
 
-	if packIndex == -1 {
-		return fmt.Errorf("pack not found: %s", packName)
-	}
+		packsData.Packs = append(packsData.Packs, newPack)
+		return nil
+	})
+}
 
-	// Verify all stickers exist and add to pack
-	for _, stickerID := range stickerIDs {
-		// Check if sticker exists in collection
+// DeletePack removes a pack, leaving its stickers in the collection
+func DeletePack(dataDir string, name string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
 		found := false
-		for _, sticker := range collection.Stickers {
-			if sticker.ID == stickerID {
+		for i, pack := range packsData.Packs {
+			if pack.Name == name {
+				packsData.Packs = append(packsData.Packs[:i], packsData.Packs[i+1:]...)
 				found = true
 				break
 			}
 		}
 
 		if !found {
-			return fmt.Errorf("sticker not found in collection: %s", stickerID)
+			return fmt.Errorf("pack not found: %s", name)
 		}
 
-		// Check if sticker is already in pack
-		alreadyInPack := false
-		for _, existingID := range packsData.Packs[packIndex].StickerIDs {
-			if existingID == stickerID {
-				alreadyInPack = true
-				break
-			}
+		// Stickers that were only in this pack become unsorted
+		for i := range collection.Stickers {
+			collection.Stickers[i].InPacks = removeString(collection.Stickers[i].InPacks, name)
 		}
 
-		if !alreadyInPack {
-			packsData.Packs[packIndex].StickerIDs = append(packsData.Packs[packIndex].StickerIDs, stickerID)
+		return nil
+	})
+}
+
+// RenamePack changes a pack's name, updating every sticker that references it
+func RenamePack(dataDir string, oldName string, newName string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, oldName)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", oldName)
+		}
+		if findPack(packsData, newName) != nil {
+			return fmt.Errorf("pack already exists: %s", newName)
 		}
-	}
 
-	// Update sticker's InPacks field
-	for i := range collection.Stickers {
-		for _, stickerID := range stickerIDs {
-			if collection.Stickers[i].ID == stickerID {
-				// Check if pack is already in sticker's InPacks
-				inPacks := false
-				for _, packInList := range collection.Stickers[i].InPacks {
-					if packInList == packName {
-						inPacks = true
-						break
-					}
-				}
-				if !inPacks {
-					collection.Stickers[i].InPacks = append(collection.Stickers[i].InPacks, packName)
+		pack.Name = newName
+		for i := range collection.Stickers {
+			for j, packName := range collection.Stickers[i].InPacks {
+				if packName == oldName {
+					collection.Stickers[i].InPacks[j] = newName
 				}
 			}
 		}
-	}
 
-	if err := SaveCollection(dataDir, collection); err != nil {
-		return fmt.Errorf("failed to update collection: %w", err)
-	}
+		return nil
+	})
+}
 
-	return SavePacks(dataDir, packsData)
+// SetPackDisplayName sets the user-facing name of a pack
+func SetPackDisplayName(dataDir string, name string, displayName string) error {
+	return updatePack(dataDir, name, func(pack *Pack) {
+		pack.DisplayName = displayName
+	})
 }
 
-// RemoveFromPack removes stickers from a pack
-func RemoveFromPack(dataDir string, packName string, stickerIDs []string) error {
-	packsData, err := LoadPacks(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load packs: %w", err)
-	}
+// MovePackSticker moves a sticker to a 1-based position within a pack
+func MovePackSticker(dataDir string, packName string, stickerID string, position int) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, packName)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", packName)
+		}
 
-	collection, err := LoadCollection(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load collection: %w", err)
-	}
+		moved, err := moveString(pack.StickerIDs, stickerID, position)
+		if err != nil {
+			return err
+		}
+		pack.StickerIDs = moved
+		return nil
+	})
+}
 
-	// Find the pack
-	packIndex := -1
-	for i, pack := range packsData.Packs {
-		if pack.Name == packName {
-			packIndex = i
-			break
+// AddToPack adds stickers to a pack, failing with a *ShortcodeConflict if one would
+// share its shortcode with another sticker in the pack
+func AddToPack(dataDir string, packName string, stickerIDs []string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, packName)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", packName)
 		}
-	}
 
-	if packIndex == -1 {
-		return fmt.Errorf("pack not found: %s", packName)
-	}
+		// Verify all stickers exist and add to pack
+		for _, stickerID := range stickerIDs {
+			// Check if sticker exists in collection
+			var found *Sticker
+			for i := range collection.Stickers {
+				if collection.Stickers[i].ID == stickerID {
+					found = &collection.Stickers[i]
+					break
+				}
+			}
+
+			if found == nil {
+				return fmt.Errorf("sticker not found in collection: %s", stickerID)
+			}
 
-	// Remove stickers from pack
-	for _, stickerID := range stickerIDs {
-		newStickerIDs := []string{}
-		for _, existingID := range packsData.Packs[packIndex].StickerIDs {
-			if existingID != stickerID {
-				newStickerIDs = append(newStickerIDs, existingID)
+			// Check if sticker is already in pack, and that its shortcode is free
+			if !containsString(pack.StickerIDs, stickerID) {
+				if err := checkShortcodeFree(packName, packMembers(collection, pack), stickerID, found.Shortcode()); err != nil {
+					return err
+				}
+				pack.StickerIDs = append(pack.StickerIDs, stickerID)
 			}
 		}
-		packsData.Packs[packIndex].StickerIDs = newStickerIDs
-	}
 
-	// Update sticker's InPacks field
-	for i := range collection.Stickers {
-		for _, stickerID := range stickerIDs {
-			if collection.Stickers[i].ID == stickerID {
-				newInPacks := []string{}
-				for _, packInList := range collection.Stickers[i].InPacks {
-					if packInList != packName {
-						newInPacks = append(newInPacks, packInList)
-					}
+		// Update sticker's InPacks field
+		for i := range collection.Stickers {
+			for _, stickerID := range stickerIDs {
+				if collection.Stickers[i].ID == stickerID && !containsString(collection.Stickers[i].InPacks, packName) {
+					collection.Stickers[i].InPacks = append(collection.Stickers[i].InPacks, packName)
 				}
-				collection.Stickers[i].InPacks = newInPacks
 			}
 		}
-	}
 
-	if err := SaveCollection(dataDir, collection); err != nil {
-		return fmt.Errorf("failed to update collection: %w", err)
-	}
+		return nil
+	})
+}
+
+// RemoveFromPack removes stickers from a pack
+func RemoveFromPack(dataDir string, packName string, stickerIDs []string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, packName)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", packName)
+		}
 
-	return SavePacks(dataDir, packsData)
+		// Remove stickers from pack
+		for _, stickerID := range stickerIDs {
+			pack.StickerIDs = removeString(pack.StickerIDs, stickerID)
+		}
+
+		// Update sticker's InPacks field
+		for i := range collection.Stickers {
+			for _, stickerID := range stickerIDs {
+				if collection.Stickers[i].ID == stickerID {
+					collection.Stickers[i].InPacks = removeString(collection.Stickers[i].InPacks, packName)
+				}
+			}
+		}
+
+		return nil
+	})
 }
 
 // GetPack retrieves a pack by name
@@ -193,83 +197,100 @@ func GetPack(dataDir string, name string) (*Pack, error) {
 	return nil, fmt.Errorf("pack not found: %s", name)
 }
 
-// UpdatePublished records that a pack has been published to a room
-func UpdatePublished(dataDir string, packName string, roomID string, stateKey string) error {
-	packsData, err := LoadPacks(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load packs: %w", err)
-	}
+// PackStickers returns the stickers in a pack, in pack order
+func PackStickers(dataDir string, name string) ([]Sticker, error) {
+	var stickers []Sticker
+	err := View(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, name)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", name)
+		}
 
-	// Find the pack
-	for i, pack := range packsData.Packs {
-		if pack.Name == packName {
-			if packsData.Packs[i].PublishedRooms == nil {
-				packsData.Packs[i].PublishedRooms = make(map[string]string)
+		byID := make(map[string]Sticker, len(collection.Stickers))
+		for _, sticker := range collection.Stickers {
+			byID[sticker.ID] = sticker
+		}
+
+		stickers = make([]Sticker, 0, len(pack.StickerIDs))
+		for _, stickerID := range pack.StickerIDs {
+			sticker, ok := byID[stickerID]
+			if !ok {
+				return fmt.Errorf("sticker not found in collection: %s", stickerID)
 			}
-			packsData.Packs[i].PublishedRooms[roomID] = stateKey
-			return SavePacks(dataDir, packsData)
+			stickers = append(stickers, sticker)
 		}
-	}
 
-	return fmt.Errorf("pack not found: %s", packName)
+		return nil
+	})
+
+	return stickers, err
 }
 
-// SetPackAvatar sets the avatar URL for a pack
-func SetPackAvatar(dataDir string, packName string, avatarURL string) error {
-	packsData, err := LoadPacks(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load packs: %w", err)
-	}
+// UpdatePublished records that a pack has been published to a room
+func UpdatePublished(dataDir string, packName string, roomID string, stateKey string) error {
+	return updatePack(dataDir, packName, func(pack *Pack) {
+		if pack.PublishedRooms == nil {
+			pack.PublishedRooms = make(map[string]string)
+		}
+		pack.PublishedRooms[roomID] = stateKey
+	})
+}
 
-	// Find the pack
-	for i, pack := range packsData.Packs {
-		if pack.Name == packName {
-			packsData.Packs[i].AvatarURL = avatarURL
-			return SavePacks(dataDir, packsData)
+// RemovePublished forgets that a pack was published to a room
+func RemovePublished(dataDir string, packName string, roomID string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, packName)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", packName)
 		}
-	}
+		if _, ok := pack.PublishedRooms[roomID]; !ok {
+			return fmt.Errorf("pack %s is not published to %s", packName, roomID)
+		}
+
+		delete(pack.PublishedRooms, roomID)
+		return nil
+	})
+}
 
-	return fmt.Errorf("pack not found: %s", packName)
+// SetPackAvatar sets the avatar URL for a pack
+func SetPackAvatar(dataDir string, packName string, avatarURL string) error {
+	return updatePack(dataDir, packName, func(pack *Pack) {
+		pack.AvatarURL = avatarURL
+	})
 }
 
 // LoadPacks loads pack definitions from disk
 func LoadPacks(dataDir string) (*PacksData, error) {
-	packsPath := filepath.Join(dataDir, "packs.json")
-
-	// Check if file exists
-	if _, err := os.Stat(packsPath); os.IsNotExist(err) {
-		// Return empty packs data if file doesn't exist
-		return &PacksData{Packs: []Pack{}}, nil
-	}
-
-	data, err := os.ReadFile(packsPath)
+	unlock, err := lockForRead(dataDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read packs file: %w", err)
-	}
-
-	var packsData PacksData
-	if err := json.Unmarshal(data, &packsData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal packs: %w", err)
+		return nil, err
 	}
+	defer unlock()
 
-	return &packsData, nil
+	packsData, _, err := readPacks(dataDir)
+	return packsData, err
 }
 
-// SavePacks saves pack definitions to disk
+// SavePacks saves pack definitions to disk, atomically replacing the previous file
 func SavePacks(dataDir string, packsData *PacksData) error {
 	// Ensure data directory exists
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	packsPath := filepath.Join(dataDir, "packs.json")
-
+	packsData.SchemaVersion = CurrentSchemaVersion
 	data, err := json.MarshalIndent(packsData, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal packs: %w", err)
 	}
 
-	if err := os.WriteFile(packsPath, data, 0644); err != nil {
+	unlock, err := lockDataDir(dataDir, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := writeFileAtomic(filepath.Join(dataDir, packsFile), data, 0644); err != nil {
 		return fmt.Errorf("failed to write packs file: %w", err)
 	}
 
@@ -288,18 +309,79 @@ func ListPacks(dataDir string) ([]Pack, error) {
 
 // SetPackUsage sets the default usage for all stickers in a pack
 func SetPackUsage(dataDir string, packName string, usage []string) error {
-	packsData, err := LoadPacks(dataDir)
-	if err != nil {
-		return fmt.Errorf("failed to load packs: %w", err)
+	return updatePack(dataDir, packName, func(pack *Pack) {
+		pack.Usage = usage
+	})
+}
+
+// SetPersonalPack records which pack is published as the user's personal emotes.
+// There is only one im.ponies.user_emotes event, so any other pack loses the flag.
+func SetPersonalPack(dataDir string, packName string) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		if packName != "" && findPack(packsData, packName) == nil {
+			return fmt.Errorf("pack not found: %s", packName)
+		}
+
+		for i := range packsData.Packs {
+			packsData.Packs[i].PublishedPersonal = packsData.Packs[i].Name == packName
+		}
+		return nil
+	})
+}
+
+// updatePack applies fn to one pack inside a transaction
+func updatePack(dataDir string, packName string, fn func(pack *Pack)) error {
+	return Update(dataDir, func(collection *Collection, packsData *PacksData) error {
+		pack := findPack(packsData, packName)
+		if pack == nil {
+			return fmt.Errorf("pack not found: %s", packName)
+		}
+
+		fn(pack)
+		return nil
+	})
+}
+
+// findPack returns a pointer to the named pack within packsData, or nil
+func findPack(packsData *PacksData, name string) *Pack {
+	for i := range packsData.Packs {
+		if packsData.Packs[i].Name == name {
+			return &packsData.Packs[i]
+		}
+	}
+	return nil
+}
+
+// moveString returns slice with str moved to a 1-based position
+func moveString(slice []string, str string, position int) ([]string, error) {
+	if position < 1 || position > len(slice) {
+		return nil, fmt.Errorf("position must be between 1 and %d", len(slice))
 	}
 
-	// Find the pack
-	for i, pack := range packsData.Packs {
-		if pack.Name == packName {
-			packsData.Packs[i].Usage = usage
-			return SavePacks(dataDir, packsData)
+	from := -1
+	for i, s := range slice {
+		if s == str {
+			from = i
+			break
 		}
 	}
+	if from == -1 {
+		return nil, fmt.Errorf("sticker not in pack: %s", str)
+	}
 
-	return fmt.Errorf("pack not found: %s", packName)
+	moved := make([]string, 0, len(slice))
+	moved = append(moved, slice[:from]...)
+	moved = append(moved, slice[from+1:]...)
+	moved = append(moved[:position-1], append([]string{str}, moved[position-1:]...)...)
+	return moved, nil
+}
+
+// containsString reports whether slice contains str
+func containsString(slice []string, str string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// searchDateFormat is the format of before: and after: dates
+const searchDateFormat = "2006-01-02"
+
+// Search field weights: each term scores for every field it appears in, so a
+// shortcode match ranks above a match buried in alt-text
+const (
+	weightExactName = 10
+	weightName      = 5
+	weightTag       = 4
+	weightAltText   = 3
+	weightBody      = 2
+	weightPack      = 2
+	weightRoom      = 1
+)
+
+// SearchQuery is a parsed sticker search. Every term and filter must match.
+type SearchQuery struct {
+	Terms  []string  // Lowercase words or quoted phrases to find in a sticker's text
+	Pack   string    // Only stickers in this pack, or "unsorted" for stickers in none
+	Tags   []string  // Only stickers with all of these tags
+	Usage  string    // Only stickers usable as "sticker" or "emoticon"
+	Mime   string    // Only stickers whose MIME type contains this, e.g. "gif"
+	Before time.Time // Only stickers collected before this day
+	After  time.Time // Only stickers collected after this day
+}
+
+// SearchResult is a sticker matching a search, with its relevance score
+type SearchResult struct {
+	Sticker Sticker
+	Score   int
+}
+
+// ParseSearchQuery parses a search such as `cat pack:memes tag:happy mime:gif after:2024-01-31 "waving paw"`.
+// Words that aren't a known filter are search terms, so :shortcode: searches still work.
+func ParseSearchQuery(query string) (SearchQuery, error) {
+	var q SearchQuery
+
+	for _, token := range splitSearchQuery(query) {
+		key, value, ok := strings.Cut(token, ":")
+		if ok && value != "" {
+			switch strings.ToLower(key) {
+			case "pack":
+				q.Pack = strings.ToLower(strings.ReplaceAll(value, " ", "-"))
+				continue
+			case "tag":
+				tag, err := NormalizeTag(value)
+				if err != nil {
+					return q, err
+				}
+				q.Tags = append(q.Tags, tag)
+				continue
+			case "usage":
+				usage, err := ParseUsage(value)
+				if err != nil || len(usage) != 1 {
+					return q, fmt.Errorf("invalid usage filter: %s (valid: sticker, emoticon, emoji)", value)
+				}
+				q.Usage = usage[0]
+				continue
+			case "mime":
+				q.Mime = strings.ToLower(value)
+				continue
+			case "before", "after":
+				day, err := time.ParseInLocation(searchDateFormat, value, time.Local)
+				if err != nil {
+					return q, fmt.Errorf("invalid date in %s - use YYYY-MM-DD", token)
+				}
+				if strings.ToLower(key) == "before" {
+					q.Before = day
+				} else {
+					q.After = day.AddDate(0, 0, 1)
+				}
+				continue
+			}
+		}
+
+		q.Terms = append(q.Terms, strings.ToLower(token))
+	}
+
+	return q, nil
+}
+
+// IsEmpty reports whether the query has no terms or filters
+func (q SearchQuery) IsEmpty() bool {
+	return len(q.Terms) == 0 && q.Pack == "" && len(q.Tags) == 0 && q.Usage == "" && q.Mime == "" &&
+		q.Before.IsZero() && q.After.IsZero()
+}
+
+// Search finds the stickers matching a query, best match first. Stickers that only
+// match filters are listed newest first.
+func Search(store Store, query SearchQuery) ([]SearchResult, error) {
+	stickers, err := store.ListStickers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection: %w", err)
+	}
+	packs, err := store.ListPacks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packs: %w", err)
+	}
+
+	packsByName := make(map[string]Pack, len(packs))
+	for _, pack := range packs {
+		packsByName[pack.Name] = pack
+	}
+
+	var results []SearchResult
+	for _, sticker := range stickers {
+		if !query.matchesFilters(sticker, packsByName) {
+			continue
+		}
+		score, ok := query.score(sticker, packsByName)
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{Sticker: sticker, Score: score})
+	}
+
+	slices.SortStableFunc(results, func(a, b SearchResult) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return b.Sticker.CollectedAt.Compare(a.Sticker.CollectedAt)
+	})
+
+	return results, nil
+}
+
+// matchesFilters checks a sticker against the query's filters
+func (q SearchQuery) matchesFilters(sticker Sticker, packs map[string]Pack) bool {
+	switch {
+	case q.Pack == "unsorted" && len(sticker.InPacks) > 0:
+		return false
+	case q.Pack != "" && q.Pack != "unsorted" && !slices.Contains(sticker.InPacks, q.Pack):
+		return false
+	case !(TagQuery{Include: q.Tags}).Matches(sticker):
+		return false
+	case q.Mime != "" && !strings.Contains(strings.ToLower(sticker.MimeType), q.Mime):
+		return false
+	case !q.Before.IsZero() && !sticker.CollectedAt.Before(q.Before):
+		return false
+	case !q.After.IsZero() && sticker.CollectedAt.Before(q.After):
+		return false
+	case q.Usage != "" && !slices.Contains(effectiveUsage(sticker, packs), q.Usage):
+		return false
+	}
+	return true
+}
+
+// score adds up where each term appears in a sticker's text. Tags only count when
+// a term is the whole tag. It reports false if any term doesn't appear at all.
+func (q SearchQuery) score(sticker Sticker, packs map[string]Pack) (int, bool) {
+	name := strings.ToLower(sticker.Name)
+	altText := strings.ToLower(sticker.GeneratedAltText)
+	for _, translated := range sticker.AltTexts {
+		altText += "\n" + strings.ToLower(translated)
+	}
+	body := strings.ToLower(sticker.OriginalBody)
+	room := strings.ToLower(sticker.SourceRoom)
+	tags := " " + strings.Join(sticker.Tags, " ") + " "
+
+	var packText strings.Builder
+	for _, packName := range sticker.InPacks {
+		packText.WriteString(packName + " " + strings.ToLower(packs[packName].DisplayName) + " ")
+	}
+
+	total := 0
+	for _, term := range q.Terms {
+		shortcode := strings.Trim(term, ":")
+		score := 0
+		if name == shortcode {
+			score += weightExactName
+		} else if strings.Contains(name, shortcode) {
+			score += weightName
+		}
+		if strings.Contains(tags, " "+strings.TrimPrefix(term, "#")+" ") {
+			score += weightTag
+		}
+		if strings.Contains(altText, term) {
+			score += weightAltText
+		}
+		if strings.Contains(body, term) {
+			score += weightBody
+		}
+		if strings.Contains(packText.String(), term) {
+			score += weightPack
+		}
+		if strings.Contains(room, term) {
+			score += weightRoom
+		}
+
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+
+	return total, true
+}
+
+// effectiveUsage returns how a sticker can be used: its own override, otherwise
+// whatever its packs allow (both, for packs without a default or unsorted stickers)
+func effectiveUsage(sticker Sticker, packs map[string]Pack) []string {
+	if len(sticker.Usage) > 0 {
+		return sticker.Usage
+	}
+
+	both := []string{"sticker", "emoticon"}
+	if len(sticker.InPacks) == 0 {
+		return both
+	}
+
+	var usage []string
+	for _, packName := range sticker.InPacks {
+		packUsage := packs[packName].Usage
+		if len(packUsage) == 0 {
+			return both
+		}
+		for _, u := range packUsage {
+			if !slices.Contains(usage, u) {
+				usage = append(usage, u)
+			}
+		}
+	}
+	return usage
+}
+
+// splitSearchQuery splits a query on whitespace, keeping "quoted phrases" together
+func splitSearchQuery(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			if !quoted {
+				flush()
+			}
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
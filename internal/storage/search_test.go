@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseSearchQuery verifies terms, phrases and filters are parsed
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery(`Cat "waving paw" pack:Big Cats tag:#Happy mime:GIF usage:emoji before:2025-02-01 after:2025-01-01 :happy:`)
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+
+	expectedTerms := []string{"cat", "waving paw", "cats", ":happy:"}
+	if len(q.Terms) != len(expectedTerms) {
+		t.Fatalf("Expected terms %v, got %v", expectedTerms, q.Terms)
+	}
+	for i, term := range expectedTerms {
+		if q.Terms[i] != term {
+			t.Errorf("Term %d: expected %q, got %q", i, term, q.Terms[i])
+		}
+	}
+	if len(q.Tags) != 1 || q.Tags[0] != "happy" {
+		t.Errorf("Expected tag filter [happy], got %v", q.Tags)
+	}
+	if q.Pack != "big" || q.Mime != "gif" || q.Usage != "emoticon" {
+		t.Errorf("Unexpected filters: %+v", q)
+	}
+	if !q.Before.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected before date: %s", q.Before)
+	}
+	if !q.After.Equal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected after to start the next day, got %s", q.After)
+	}
+
+	for _, invalid := range []string{"before:yesterday", "usage:both", "usage:loud", "tag:no!"} {
+		if _, err := ParseSearchQuery(invalid); err == nil {
+			t.Errorf("Expected error for %q", invalid)
+		}
+	}
+
+	if q, _ := ParseSearchQuery("   "); !q.IsEmpty() {
+		t.Error("Expected blank query to be empty")
+	}
+}
+
+// TestSearch verifies ranking and filters across backends
+func TestSearch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		now := time.Now()
+
+		cat := testSticker("cat")
+		cat.Name = "cat"
+		cat.GeneratedAltText = "An orange cat waving a paw"
+		cat.CollectedAt = now.AddDate(0, 0, -10)
+
+		catGif := testSticker("catgif")
+		catGif.Name = "dancing"
+		catGif.GeneratedAltText = "A cat dancing"
+		catGif.MimeType = "image/gif"
+		catGif.CollectedAt = now.AddDate(0, 0, -1)
+
+		dog := testSticker("dog")
+		dog.Name = "dog"
+		dog.GeneratedAltText = "A dog waving"
+		dog.OriginalBody = "doggo.png"
+		dog.SourceRoom = "!pets:example.org"
+		dog.Usage = []string{"sticker"}
+		dog.Tags = []string{"pet", "waving"}
+		dog.CollectedAt = now
+
+		for _, s := range []Sticker{cat, catGif, dog} {
+			if err := store.AddSticker(s); err != nil {
+				t.Fatalf("Failed to add sticker: %v", err)
+			}
+		}
+		if err := store.CreatePack("animals", "Furry Friends", ""); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+		if err := store.AddToPack("animals", []string{"cat"}); err != nil {
+			t.Fatalf("Failed to add to pack: %v", err)
+		}
+
+		search := func(query string) []string {
+			t.Helper()
+			q, err := ParseSearchQuery(query)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", query, err)
+			}
+			results, err := Search(store, q)
+			if err != nil {
+				t.Fatalf("Failed to search %q: %v", query, err)
+			}
+			ids := make([]string, len(results))
+			for i, r := range results {
+				ids[i] = r.Sticker.ID
+			}
+			return ids
+		}
+
+		tests := []struct {
+			query    string
+			expected []string
+		}{
+			{"cat", []string{"cat", "catgif"}},         // Shortcode match ranks first
+			{"waving", []string{"dog", "cat"}},         // Equal scores, newest first
+			{`"waving a paw"`, []string{"cat"}},        // Phrase
+			{"cat waving", []string{"cat"}},            // Every term must match
+			{"furry", []string{"cat"}},                 // Pack display name
+			{"doggo", []string{"dog"}},                 // Original body
+			{"pets", []string{"dog"}},                  // Source room
+			{"pet", []string{"dog"}},                   // Whole tag
+			{"tag:pet", []string{"dog"}},               // Tag filter
+			{"pack:animals", []string{"cat"}},          // Filter only
+			{"pack:unsorted cat", []string{"catgif"}},  // Unsorted filter
+			{"mime:gif", []string{"catgif"}},           // MIME filter
+			{"usage:emoticon waving", []string{"cat"}}, // Dog is sticker-only
+			{"after:" + now.AddDate(0, 0, -3).Format("2006-01-02"), []string{"dog", "catgif"}},
+			{"before:" + now.AddDate(0, 0, -3).Format("2006-01-02"), []string{"cat"}},
+			{"fish", []string{}},
+		}
+
+		for _, tt := range tests {
+			got := search(tt.query)
+			if len(got) != len(tt.expected) {
+				t.Errorf("%q: expected %v, got %v", tt.query, tt.expected, got)
+				continue
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("%q: expected %v, got %v", tt.query, tt.expected, got)
+					break
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShortcodeConflict is a shortcode used by more than one sticker in a pack. Published
+// packs are keyed by shortcode, so only one of them would be published.
+type ShortcodeConflict struct {
+	Pack       string
+	Shortcode  string
+	StickerIDs []string // Stickers using the shortcode in pack order, any being added or renamed last
+}
+
+// Error describes the conflict
+func (c *ShortcodeConflict) Error() string {
+	ids := c.StickerIDs
+	return fmt.Sprintf("stickers %s and %s can't share shortcode :%s: in pack %s",
+		strings.Join(ids[:len(ids)-1], ", "), ids[len(ids)-1], c.Shortcode, c.Pack)
+}
+
+// FindShortcodeConflicts returns the shortcodes shared by more than one of a pack's
+// stickers, in the order they first appear
+func FindShortcodeConflicts(packName string, stickers []Sticker) []ShortcodeConflict {
+	users := make(map[string][]string)
+	var order []string
+	for _, sticker := range stickers {
+		shortcode := sticker.Shortcode()
+		if _, seen := users[shortcode]; !seen {
+			order = append(order, shortcode)
+		}
+		if !containsString(users[shortcode], sticker.ID) {
+			users[shortcode] = append(users[shortcode], sticker.ID)
+		}
+	}
+
+	var conflicts []ShortcodeConflict
+	for _, shortcode := range order {
+		if len(users[shortcode]) > 1 {
+			conflicts = append(conflicts, ShortcodeConflict{Pack: packName, Shortcode: shortcode, StickerIDs: users[shortcode]})
+		}
+	}
+	return conflicts
+}
+
+// checkShortcodeFree returns a conflict if a sticker other than stickerID in a
+// pack's members already uses shortcode
+func checkShortcodeFree(packName string, members []Sticker, stickerID string, shortcode string) error {
+	for _, member := range members {
+		if member.ID != stickerID && member.Shortcode() == shortcode {
+			return &ShortcodeConflict{Pack: packName, Shortcode: shortcode, StickerIDs: []string{member.ID, stickerID}}
+		}
+	}
+	return nil
+}
+
+// packMembers returns the stickers in a pack, in pack order
+func packMembers(collection *Collection, pack *Pack) []Sticker {
+	members := make([]Sticker, 0, len(pack.StickerIDs))
+	for _, stickerID := range pack.StickerIDs {
+		for _, sticker := range collection.Stickers {
+			if sticker.ID == stickerID {
+				members = append(members, sticker)
+				break
+			}
+		}
+	}
+	return members
+}
@@ -0,0 +1,1002 @@
+//go:build sqlite
+
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver
+)
+
+// sqliteFile is the database file name inside the data directory
+const sqliteFile = "stickerbook.db"
+
+// sqliteMigrations are applied in order; PRAGMA user_version records how many have run
+var sqliteMigrations = []string{
+	`CREATE TABLE stickers (
+		id                 TEXT PRIMARY KEY,
+		name               TEXT NOT NULL DEFAULT '',
+		collected_at       TIMESTAMP NOT NULL,
+		source_room        TEXT NOT NULL DEFAULT '',
+		source_event       TEXT NOT NULL DEFAULT '',
+		source_mxc         TEXT NOT NULL DEFAULT '',
+		local_mxc          TEXT NOT NULL DEFAULT '',
+		mime_type          TEXT NOT NULL DEFAULT '',
+		width              INTEGER NOT NULL DEFAULT 0,
+		height             INTEGER NOT NULL DEFAULT 0,
+		size_bytes         INTEGER NOT NULL DEFAULT 0,
+		original_body      TEXT NOT NULL DEFAULT '',
+		generated_alt_text TEXT NOT NULL DEFAULT '',
+		usage              TEXT
+	);
+	CREATE INDEX idx_stickers_name ON stickers(name);
+
+	CREATE TABLE packs (
+		name         TEXT PRIMARY KEY,
+		display_name TEXT NOT NULL DEFAULT '',
+		avatar_url   TEXT NOT NULL DEFAULT '',
+		attribution  TEXT NOT NULL DEFAULT '',
+		usage        TEXT
+	);
+
+	CREATE TABLE pack_stickers (
+		pack_name  TEXT NOT NULL REFERENCES packs(name) ON DELETE CASCADE ON UPDATE CASCADE,
+		sticker_id TEXT NOT NULL REFERENCES stickers(id) ON DELETE CASCADE,
+		position   INTEGER NOT NULL,
+		PRIMARY KEY (pack_name, sticker_id)
+	);
+	CREATE INDEX idx_pack_stickers_sticker ON pack_stickers(sticker_id);
+	CREATE INDEX idx_pack_stickers_position ON pack_stickers(pack_name, position);
+
+	CREATE TABLE pack_rooms (
+		pack_name TEXT NOT NULL REFERENCES packs(name) ON DELETE CASCADE ON UPDATE CASCADE,
+		room_id   TEXT NOT NULL,
+		state_key TEXT NOT NULL,
+		PRIMARY KEY (pack_name, room_id)
+	);`,
+	`ALTER TABLE packs ADD COLUMN published_personal INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE stickers ADD COLUMN collected_by TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE sticker_tags (
+		sticker_id TEXT NOT NULL REFERENCES stickers(id) ON DELETE CASCADE,
+		tag        TEXT NOT NULL,
+		PRIMARY KEY (sticker_id, tag)
+	);
+	CREATE INDEX idx_sticker_tags_tag ON sticker_tags(tag);`,
+	`ALTER TABLE stickers ADD COLUMN alt_texts TEXT;`,
+	`ALTER TABLE stickers ADD COLUMN alt_text_by TEXT NOT NULL DEFAULT '';
+	ALTER TABLE stickers ADD COLUMN alt_text_model TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE stickers ADD COLUMN suggested_name TEXT NOT NULL DEFAULT '';`,
+}
+
+// stickerColumns is the column list matching scanSticker
+const stickerColumns = `id, name, collected_at, source_room, source_event, source_mxc, local_mxc,
+	mime_type, width, height, size_bytes, original_body, generated_alt_text, usage, collected_by, alt_texts,
+	alt_text_by, alt_text_model, suggested_name`
+
+// packColumns is the column list matching queryPacks
+const packColumns = `name, display_name, avatar_url, attribution, usage, published_personal`
+
+// SQLiteStore is a Store backed by a SQLite database in the data directory.
+// Stickers are indexed by ID and shortcode, and pack membership and tags are indexed
+// in both directions, so lookups don't need to read the whole collection.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// OpenSQLite opens (or creates) the SQLite store in dataDir.
+// A newly created database imports any existing collection.json and packs.json.
+func OpenSQLite(dataDir string) (Store, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	dbPath := filepath.Join(dataDir, sqliteFile)
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// A single connection serialises writers and keeps foreign key pragmas in effect
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStore{db: db}
+	fresh, err := s.migrate()
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if fresh {
+		if err := s.importJSON(dataDir); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to import JSON data: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// migrate applies pending schema migrations and reports whether the database was new
+func (s *SQLiteStore) migrate() (bool, error) {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if version > len(sqliteMigrations) {
+		return false, fmt.Errorf("database schema version %d is newer than supported version %d", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		err := s.withTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+			return err
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to apply database migration %d: %w", i+1, err)
+		}
+	}
+
+	return version == 0, nil
+}
+
+// importJSON copies an existing JSON collection and packs into the database
+func (s *SQLiteStore) importJSON(dataDir string) error {
+	collection, err := LoadCollection(dataDir)
+	if err != nil {
+		return err
+	}
+
+	packsData, err := LoadPacks(dataDir)
+	if err != nil {
+		return err
+	}
+
+	return s.withTx(func(tx *sql.Tx) error {
+		for _, sticker := range collection.Stickers {
+			if err := upsertSticker(tx, sticker); err != nil {
+				return err
+			}
+		}
+
+		for _, pack := range packsData.Packs {
+			usage, err := encodeUsage(pack.Usage)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO packs (name, display_name, avatar_url, attribution, usage, published_personal) VALUES (?, ?, ?, ?, ?, ?)`,
+				pack.Name, pack.DisplayName, pack.AvatarURL, pack.Attribution, usage, pack.PublishedPersonal); err != nil {
+				return fmt.Errorf("failed to import pack %s: %w", pack.Name, err)
+			}
+			if err := addToPack(tx, pack.Name, pack.StickerIDs); err != nil {
+				return fmt.Errorf("failed to import pack %s: %w", pack.Name, err)
+			}
+			for roomID, stateKey := range pack.PublishedRooms {
+				if _, err := tx.Exec(`INSERT INTO pack_rooms (pack_name, room_id, state_key) VALUES (?, ?, ?)`,
+					pack.Name, roomID, stateKey); err != nil {
+					return fmt.Errorf("failed to import pack %s: %w", pack.Name, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// withTx runs fn inside a transaction, committing on success and rolling back on error
+func (s *SQLiteStore) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddSticker adds a new sticker to the collection, replacing one with the same ID but
+// keeping the packs it's in
+func (s *SQLiteStore) AddSticker(sticker Sticker) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		return upsertSticker(tx, sticker)
+	})
+}
+
+// GetSticker retrieves a sticker by ID
+func (s *SQLiteStore) GetSticker(id string) (*Sticker, error) {
+	row := s.db.QueryRow(`SELECT `+stickerColumns+` FROM stickers WHERE id = ?`, id)
+	sticker, err := scanSticker(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrStickerNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sticker: %w", err)
+	}
+
+	if sticker.InPacks, err = stickerPacks(s.db, id); err != nil {
+		return nil, err
+	}
+	if sticker.Tags, err = stickerTags(s.db, id); err != nil {
+		return nil, err
+	}
+
+	return sticker, nil
+}
+
+// ListStickers returns all collected stickers in collection order
+func (s *SQLiteStore) ListStickers() ([]Sticker, error) {
+	return s.queryStickers(`SELECT ` + stickerColumns + ` FROM stickers ORDER BY rowid`)
+}
+
+// ListUnsorted returns stickers that are not in any pack
+func (s *SQLiteStore) ListUnsorted() ([]Sticker, error) {
+	return s.queryStickers(`SELECT ` + stickerColumns + ` FROM stickers
+		WHERE NOT EXISTS (SELECT 1 FROM pack_stickers WHERE pack_stickers.sticker_id = stickers.id)
+		ORDER BY rowid`)
+}
+
+// UpdateAltText replaces a sticker's alt-text in every language and records who wrote it
+func (s *SQLiteStore) UpdateAltText(id string, altText AltText) error {
+	languages, err := encodeAltTexts(altText.Languages)
+	if err != nil {
+		return err
+	}
+	return s.updateSticker(id, `UPDATE stickers SET generated_alt_text = ?, alt_texts = ?, alt_text_by = ?, alt_text_model = ? WHERE id = ?`,
+		altText.Text, languages, altText.By, altText.Model, id)
+}
+
+// SetStickerUsage sets the usage types for a specific sticker
+func (s *SQLiteStore) SetStickerUsage(id string, usage []string) error {
+	encoded, err := encodeUsage(usage)
+	if err != nil {
+		return err
+	}
+	return s.updateSticker(id, `UPDATE stickers SET usage = ? WHERE id = ?`, encoded, id)
+}
+
+// SetStickerName sets the shortcode name for a specific sticker, dropping any
+// suggested one. It fails with a *ShortcodeConflict if another sticker in one of its
+// packs already uses the name.
+func (s *SQLiteStore) SetStickerName(id string, name string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := stickerExists(tx, id); err != nil {
+			return err
+		}
+		packNames, err := stickerPacks(tx, id)
+		if err != nil {
+			return fmt.Errorf("failed to load sticker packs: %w", err)
+		}
+		for _, packName := range packNames {
+			if err := checkPackShortcode(tx, packName, id, name); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE stickers SET name = ?, suggested_name = '' WHERE id = ?`, name, id); err != nil {
+			return fmt.Errorf("failed to update sticker: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteSticker removes a sticker from the collection and all packs
+func (s *SQLiteStore) DeleteSticker(id string) error {
+	// Pack membership is removed by the ON DELETE CASCADE foreign key
+	return s.updateSticker(id, `DELETE FROM stickers WHERE id = ?`, id)
+}
+
+// TagSticker adds tags to a sticker, ignoring ones it already has
+func (s *SQLiteStore) TagSticker(id string, tags []string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := stickerExists(tx, id); err != nil {
+			return err
+		}
+		return addTags(tx, id, tags)
+	})
+}
+
+// UntagSticker removes tags from a sticker
+func (s *SQLiteStore) UntagSticker(id string, tags []string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := stickerExists(tx, id); err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if _, err := tx.Exec(`DELETE FROM sticker_tags WHERE sticker_id = ? AND tag = ?`, id, tag); err != nil {
+				return fmt.Errorf("failed to remove tag: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListTags returns every tag in use with its sticker count, sorted by tag
+func (s *SQLiteStore) ListTags() ([]TagCount, error) {
+	rows, err := s.db.Query(`SELECT tag, COUNT(*) FROM sticker_tags GROUP BY tag ORDER BY tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to load tags: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// TaggedStickers returns the stickers matching a tag query, in collection order
+func (s *SQLiteStore) TaggedStickers(query TagQuery) ([]Sticker, error) {
+	var where []string
+	var args []any
+	for _, tag := range query.Include {
+		where = append(where, `EXISTS (SELECT 1 FROM sticker_tags WHERE sticker_tags.sticker_id = stickers.id AND tag = ?)`)
+		args = append(args, tag)
+	}
+	for _, tag := range query.Exclude {
+		where = append(where, `NOT EXISTS (SELECT 1 FROM sticker_tags WHERE sticker_tags.sticker_id = stickers.id AND tag = ?)`)
+		args = append(args, tag)
+	}
+	if len(where) == 0 {
+		where = append(where, "1")
+	}
+
+	return s.queryStickers(`SELECT `+stickerColumns+` FROM stickers
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY rowid`, args...)
+}
+
+// CreatePack creates a new empty pack with author attribution
+func (s *SQLiteStore) CreatePack(name string, displayName string, attribution string) error {
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM packs WHERE name = ?)`, name).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to load packs: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("pack already exists: %s", name)
+	}
+
+	if _, err := s.db.Exec(`INSERT INTO packs (name, display_name, attribution) VALUES (?, ?, ?)`,
+		name, displayName, attribution); err != nil {
+		return fmt.Errorf("failed to create pack: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePack removes a pack, leaving its stickers in the collection
+func (s *SQLiteStore) DeletePack(name string) error {
+	// Membership and published rooms are removed by the ON DELETE CASCADE foreign keys
+	return s.updatePack(name, `DELETE FROM packs WHERE name = ?`, name)
+}
+
+// RenamePack changes a pack's name, updating every sticker that references it
+func (s *SQLiteStore) RenamePack(oldName string, newName string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := packExists(tx, oldName); err != nil {
+			return err
+		}
+		if err := packExists(tx, newName); err == nil {
+			return fmt.Errorf("pack already exists: %s", newName)
+		}
+
+		// Membership and published rooms follow via ON UPDATE CASCADE
+		if _, err := tx.Exec(`UPDATE packs SET name = ? WHERE name = ?`, newName, oldName); err != nil {
+			return fmt.Errorf("failed to rename pack: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetPackDisplayName sets the user-facing name of a pack
+func (s *SQLiteStore) SetPackDisplayName(name string, displayName string) error {
+	return s.updatePack(name, `UPDATE packs SET display_name = ? WHERE name = ?`, displayName, name)
+}
+
+// MovePackSticker moves a sticker to a 1-based position within a pack
+func (s *SQLiteStore) MovePackSticker(packName string, stickerID string, position int) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := packExists(tx, packName); err != nil {
+			return err
+		}
+
+		ids, err := packStickerIDs(tx, packName)
+		if err != nil {
+			return err
+		}
+		moved, err := moveString(ids, stickerID, position)
+		if err != nil {
+			return err
+		}
+
+		// Renumber the whole pack; packs are small enough that this is simplest
+		for i, id := range moved {
+			if _, err := tx.Exec(`UPDATE pack_stickers SET position = ? WHERE pack_name = ? AND sticker_id = ?`,
+				i, packName, id); err != nil {
+				return fmt.Errorf("failed to reorder pack: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetPack retrieves a pack by name
+func (s *SQLiteStore) GetPack(name string) (*Pack, error) {
+	packs, err := s.queryPacks(`SELECT `+packColumns+` FROM packs WHERE name = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(packs) == 0 {
+		return nil, fmt.Errorf("pack not found: %s", name)
+	}
+
+	return &packs[0], nil
+}
+
+// ListPacks returns all packs
+func (s *SQLiteStore) ListPacks() ([]Pack, error) {
+	return s.queryPacks(`SELECT ` + packColumns + ` FROM packs ORDER BY rowid`)
+}
+
+// PackStickers returns the stickers in a pack, in pack order
+func (s *SQLiteStore) PackStickers(name string) ([]Sticker, error) {
+	if err := packExists(s.db, name); err != nil {
+		return nil, err
+	}
+
+	return s.queryStickers(`SELECT `+prefixColumns("stickers", stickerColumns)+` FROM stickers
+		JOIN pack_stickers ON pack_stickers.sticker_id = stickers.id
+		WHERE pack_stickers.pack_name = ?
+		ORDER BY pack_stickers.position`, name)
+}
+
+// AddToPack adds stickers to a pack, failing with a *ShortcodeConflict if one would
+// share its shortcode with another sticker in the pack
+func (s *SQLiteStore) AddToPack(packName string, stickerIDs []string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := packExists(tx, packName); err != nil {
+			return err
+		}
+		for _, stickerID := range stickerIDs {
+			if err := checkNewPackSticker(tx, packName, stickerID); err != nil {
+				return err
+			}
+			if err := addToPack(tx, packName, []string{stickerID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveFromPack removes stickers from a pack
+func (s *SQLiteStore) RemoveFromPack(packName string, stickerIDs []string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := packExists(tx, packName); err != nil {
+			return err
+		}
+		for _, stickerID := range stickerIDs {
+			if _, err := tx.Exec(`DELETE FROM pack_stickers WHERE pack_name = ? AND sticker_id = ?`, packName, stickerID); err != nil {
+				return fmt.Errorf("failed to remove sticker from pack: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdatePublished records that a pack has been published to a room
+func (s *SQLiteStore) UpdatePublished(packName string, roomID string, stateKey string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := packExists(tx, packName); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT INTO pack_rooms (pack_name, room_id, state_key) VALUES (?, ?, ?)
+			ON CONFLICT (pack_name, room_id) DO UPDATE SET state_key = excluded.state_key`,
+			packName, roomID, stateKey)
+		return err
+	})
+}
+
+// RemovePublished forgets that a pack was published to a room
+func (s *SQLiteStore) RemovePublished(packName string, roomID string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if err := packExists(tx, packName); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`DELETE FROM pack_rooms WHERE pack_name = ? AND room_id = ?`, packName, roomID)
+		if err != nil {
+			return fmt.Errorf("failed to update published rooms: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("pack %s is not published to %s", packName, roomID)
+		}
+		return nil
+	})
+}
+
+// SetPackAvatar sets the avatar URL for a pack
+func (s *SQLiteStore) SetPackAvatar(packName string, avatarURL string) error {
+	return s.updatePack(packName, `UPDATE packs SET avatar_url = ? WHERE name = ?`, avatarURL, packName)
+}
+
+// SetPackUsage sets the default usage for all stickers in a pack
+func (s *SQLiteStore) SetPackUsage(packName string, usage []string) error {
+	encoded, err := encodeUsage(usage)
+	if err != nil {
+		return err
+	}
+	return s.updatePack(packName, `UPDATE packs SET usage = ? WHERE name = ?`, encoded, packName)
+}
+
+// SetPersonalPack records which pack is published as the user's personal emotes
+func (s *SQLiteStore) SetPersonalPack(packName string) error {
+	return s.withTx(func(tx *sql.Tx) error {
+		if packName != "" {
+			if err := packExists(tx, packName); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`UPDATE packs SET published_personal = (name = ?)`, packName); err != nil {
+			return fmt.Errorf("failed to update pack: %w", err)
+		}
+		return nil
+	})
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// updateSticker runs a single-row statement and reports a missing sticker
+func (s *SQLiteStore) updateSticker(id string, query string, args ...any) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update sticker: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrStickerNotFound, id)
+	}
+	return nil
+}
+
+// updatePack runs a single-row statement and reports a missing pack
+func (s *SQLiteStore) updatePack(packName string, query string, args ...any) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update pack: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("pack not found: %s", packName)
+	}
+	return nil
+}
+
+// queryStickers runs a sticker query and fills in pack membership and tags
+func (s *SQLiteStore) queryStickers(query string, args ...any) ([]Sticker, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stickers: %w", err)
+	}
+
+	// Rows are closed before the membership query since the pool has one connection
+	stickers := []Sticker{}
+	for rows.Next() {
+		sticker, err := scanSticker(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to load stickers: %w", err)
+		}
+		stickers = append(stickers, *sticker)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load stickers: %w", err)
+	}
+
+	// Load all memberships in one query rather than one per sticker
+	memberships, err := allMemberships(s.db)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := allTags(s.db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stickers {
+		stickers[i].InPacks = memberships[stickers[i].ID]
+		if stickers[i].InPacks == nil {
+			stickers[i].InPacks = []string{}
+		}
+		stickers[i].Tags = tags[stickers[i].ID]
+	}
+
+	return stickers, nil
+}
+
+// queryPacks runs a pack query and fills in sticker IDs and published rooms
+func (s *SQLiteStore) queryPacks(query string, args ...any) ([]Pack, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packs: %w", err)
+	}
+
+	packs := []Pack{}
+	for rows.Next() {
+		var pack Pack
+		var usage sql.NullString
+		if err := rows.Scan(&pack.Name, &pack.DisplayName, &pack.AvatarURL, &pack.Attribution, &usage, &pack.PublishedPersonal); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to load packs: %w", err)
+		}
+		if pack.Usage, err = decodeUsage(usage); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		packs = append(packs, pack)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load packs: %w", err)
+	}
+
+	for i := range packs {
+		if packs[i].StickerIDs, err = packStickerIDs(s.db, packs[i].Name); err != nil {
+			return nil, err
+		}
+		if packs[i].PublishedRooms, err = packRooms(s.db, packs[i].Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return packs, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSticker reads a sticker row selected with stickerColumns
+func scanSticker(row rowScanner) (*Sticker, error) {
+	var sticker Sticker
+	var usage, altTexts sql.NullString
+	err := row.Scan(&sticker.ID, &sticker.Name, &sticker.CollectedAt, &sticker.SourceRoom, &sticker.SourceEvent,
+		&sticker.SourceMXC, &sticker.LocalMXC, &sticker.MimeType, &sticker.Width, &sticker.Height,
+		&sticker.SizeBytes, &sticker.OriginalBody, &sticker.GeneratedAltText, &usage, &sticker.CollectedBy, &altTexts,
+		&sticker.AltTextBy, &sticker.AltTextModel, &sticker.SuggestedName)
+	if err != nil {
+		return nil, err
+	}
+
+	if sticker.Usage, err = decodeUsage(usage); err != nil {
+		return nil, err
+	}
+	if sticker.AltTexts, err = decodeAltTexts(altTexts); err != nil {
+		return nil, err
+	}
+
+	return &sticker, nil
+}
+
+// upsertSticker inserts a sticker or replaces the fields and tags of an existing one,
+// keeping its position in the collection and its pack membership
+func upsertSticker(q queryer, sticker Sticker) error {
+	usage, err := encodeUsage(sticker.Usage)
+	if err != nil {
+		return err
+	}
+	altTexts, err := encodeAltTexts(sticker.AltTexts)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`INSERT INTO stickers (`+stickerColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			collected_at = excluded.collected_at,
+			source_room = excluded.source_room,
+			source_event = excluded.source_event,
+			source_mxc = excluded.source_mxc,
+			local_mxc = excluded.local_mxc,
+			mime_type = excluded.mime_type,
+			width = excluded.width,
+			height = excluded.height,
+			size_bytes = excluded.size_bytes,
+			original_body = excluded.original_body,
+			generated_alt_text = excluded.generated_alt_text,
+			usage = excluded.usage,
+			collected_by = excluded.collected_by,
+			alt_texts = excluded.alt_texts,
+			alt_text_by = excluded.alt_text_by,
+			alt_text_model = excluded.alt_text_model,
+			suggested_name = excluded.suggested_name`,
+		sticker.ID, sticker.Name, sticker.CollectedAt, sticker.SourceRoom, sticker.SourceEvent,
+		sticker.SourceMXC, sticker.LocalMXC, sticker.MimeType, sticker.Width, sticker.Height,
+		sticker.SizeBytes, sticker.OriginalBody, sticker.GeneratedAltText, usage, sticker.CollectedBy, altTexts,
+		sticker.AltTextBy, sticker.AltTextModel, sticker.SuggestedName)
+	if err != nil {
+		return fmt.Errorf("failed to save sticker: %w", err)
+	}
+
+	if _, err := q.Exec(`DELETE FROM sticker_tags WHERE sticker_id = ?`, sticker.ID); err != nil {
+		return fmt.Errorf("failed to save sticker tags: %w", err)
+	}
+	return addTags(q, sticker.ID, sticker.Tags)
+}
+
+// addTags tags a sticker, skipping tags it already has
+func addTags(q queryer, stickerID string, tags []string) error {
+	for _, tag := range tags {
+		_, err := q.Exec(`INSERT INTO sticker_tags (sticker_id, tag) VALUES (?, ?)
+			ON CONFLICT (sticker_id, tag) DO NOTHING`, stickerID, tag)
+		if err != nil {
+			return fmt.Errorf("failed to tag sticker: %w", err)
+		}
+	}
+	return nil
+}
+
+// stickerExists returns an ErrStickerNotFound error if the sticker is missing
+func stickerExists(q queryer, stickerID string) error {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM stickers WHERE id = ?)`, stickerID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to load sticker: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrStickerNotFound, stickerID)
+	}
+	return nil
+}
+
+// addToPack appends stickers to the end of a pack, skipping ones already present
+func addToPack(q queryer, packName string, stickerIDs []string) error {
+	for _, stickerID := range stickerIDs {
+		var exists bool
+		if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM stickers WHERE id = ?)`, stickerID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to load sticker: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("sticker not found in collection: %s", stickerID)
+		}
+
+		_, err := q.Exec(`INSERT INTO pack_stickers (pack_name, sticker_id, position)
+			SELECT ?, ?, COALESCE(MAX(position), -1) + 1 FROM pack_stickers WHERE pack_name = ?
+			ON CONFLICT (pack_name, sticker_id) DO NOTHING`,
+			packName, stickerID, packName)
+		if err != nil {
+			return fmt.Errorf("failed to add sticker to pack: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkNewPackSticker returns a conflict if a sticker that isn't in a pack yet would
+// share its shortcode with one that is. Missing stickers are left for addToPack to report.
+func checkNewPackSticker(q queryer, packName string, stickerID string) error {
+	var shortcode string
+	var inPack bool
+	err := q.QueryRow(`SELECT COALESCE(NULLIF(name, ''), id),
+		EXISTS (SELECT 1 FROM pack_stickers WHERE pack_name = ? AND sticker_id = ?)
+		FROM stickers WHERE id = ?`, packName, stickerID, stickerID).Scan(&shortcode, &inPack)
+	if errors.Is(err, sql.ErrNoRows) || inPack {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load sticker: %w", err)
+	}
+
+	return checkPackShortcode(q, packName, stickerID, shortcode)
+}
+
+// checkPackShortcode returns a conflict if a sticker in a pack other than stickerID
+// already uses shortcode
+func checkPackShortcode(q queryer, packName string, stickerID string, shortcode string) error {
+	var other string
+	err := q.QueryRow(`SELECT stickers.id FROM stickers
+		JOIN pack_stickers ON pack_stickers.sticker_id = stickers.id
+		WHERE pack_stickers.pack_name = ? AND stickers.id != ? AND COALESCE(NULLIF(stickers.name, ''), stickers.id) = ?
+		ORDER BY pack_stickers.position LIMIT 1`, packName, stickerID, shortcode).Scan(&other)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check shortcode: %w", err)
+	}
+
+	return &ShortcodeConflict{Pack: packName, Shortcode: shortcode, StickerIDs: []string{other, stickerID}}
+}
+
+// packExists returns a "pack not found" error if the pack is missing
+func packExists(q queryer, packName string) error {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM packs WHERE name = ?)`, packName).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to load packs: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("pack not found: %s", packName)
+	}
+	return nil
+}
+
+// stickerPacks returns the names of packs containing a sticker, in the order it was added
+func stickerPacks(q queryer, stickerID string) ([]string, error) {
+	return queryStrings(q, `SELECT pack_name FROM pack_stickers WHERE sticker_id = ? ORDER BY rowid`, stickerID)
+}
+
+// stickerTags returns a sticker's tags in the order they were added, or nil if it has none
+func stickerTags(q queryer, stickerID string) ([]string, error) {
+	tags, err := queryStrings(q, `SELECT tag FROM sticker_tags WHERE sticker_id = ? ORDER BY rowid`, stickerID)
+	if len(tags) == 0 {
+		return nil, err
+	}
+	return tags, err
+}
+
+// packStickerIDs returns the sticker IDs in a pack, in pack order
+func packStickerIDs(q queryer, packName string) ([]string, error) {
+	return queryStrings(q, `SELECT sticker_id FROM pack_stickers WHERE pack_name = ? ORDER BY position`, packName)
+}
+
+// packRooms returns the room ID -> state key map for a pack
+func packRooms(q queryer, packName string) (map[string]string, error) {
+	rows, err := q.Query(`SELECT room_id, state_key FROM pack_rooms WHERE pack_name = ?`, packName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load published rooms: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	rooms := make(map[string]string)
+	for rows.Next() {
+		var roomID, stateKey string
+		if err := rows.Scan(&roomID, &stateKey); err != nil {
+			return nil, fmt.Errorf("failed to load published rooms: %w", err)
+		}
+		rooms[roomID] = stateKey
+	}
+
+	return rooms, rows.Err()
+}
+
+// allMemberships returns sticker ID -> pack names for every sticker in a pack
+func allMemberships(q queryer) (map[string][]string, error) {
+	rows, err := q.Query(`SELECT sticker_id, pack_name FROM pack_stickers ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pack membership: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	memberships := make(map[string][]string)
+	for rows.Next() {
+		var stickerID, packName string
+		if err := rows.Scan(&stickerID, &packName); err != nil {
+			return nil, fmt.Errorf("failed to load pack membership: %w", err)
+		}
+		memberships[stickerID] = append(memberships[stickerID], packName)
+	}
+
+	return memberships, rows.Err()
+}
+
+// allTags returns sticker ID -> tags for every tagged sticker
+func allTags(q queryer) (map[string][]string, error) {
+	rows, err := q.Query(`SELECT sticker_id, tag FROM sticker_tags ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var stickerID, tag string
+		if err := rows.Scan(&stickerID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to load tags: %w", err)
+		}
+		tags[stickerID] = append(tags[stickerID], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// queryStrings runs a single-column query
+func queryStrings(q queryer, query string, args ...any) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query database: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to query database: %w", err)
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// prefixColumns qualifies every column in a column list with a table name
+func prefixColumns(table string, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, part := range parts {
+		parts[i] = table + "." + strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// encodeUsage stores a usage list as JSON, with nil meaning "not set"
+func encodeUsage(usage []string) (sql.NullString, error) {
+	if len(usage) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode usage: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// decodeUsage reverses encodeUsage
+func decodeUsage(value sql.NullString) ([]string, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var usage []string
+	if err := json.Unmarshal([]byte(value.String), &usage); err != nil {
+		return nil, fmt.Errorf("failed to decode usage: %w", err)
+	}
+	return usage, nil
+}
+
+// encodeAltTexts stores a language -> alt-text map as JSON, with nil meaning none
+func encodeAltTexts(altTexts map[string]string) (sql.NullString, error) {
+	if len(altTexts) == 0 {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(altTexts)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode alt-texts: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// decodeAltTexts reverses encodeAltTexts
+func decodeAltTexts(value sql.NullString) (map[string]string, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var altTexts map[string]string
+	if err := json.Unmarshal([]byte(value.String), &altTexts); err != nil {
+		return nil, fmt.Errorf("failed to decode alt-texts: %w", err)
+	}
+	return altTexts, nil
+}
@@ -0,0 +1,11 @@
+//go:build !sqlite
+
+package storage
+
+import "fmt"
+
+// OpenSQLite reports that this binary was built without the SQLite backend.
+// Build with -tags sqlite (and CGO_ENABLED=1) to enable it.
+func OpenSQLite(dataDir string) (Store, error) {
+	return nil, fmt.Errorf("SQLite storage is not available in this build (rebuild with -tags sqlite)")
+}
@@ -0,0 +1,55 @@
+//go:build sqlite
+
+package storage
+
+import (
+	"os"
+	"testing"
+)
+
+func init() {
+	storeBackends[BackendSQLite] = func(t *testing.T, dataDir string) Store {
+		t.Helper()
+		store, err := OpenSQLite(dataDir)
+		if err != nil {
+			t.Fatalf("Failed to open SQLite store: %v", err)
+		}
+		return store
+	}
+}
+
+// TestOpenSQLite_ImportsJSON verifies a new database picks up existing JSON data
+func TestOpenSQLite_ImportsJSON(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := AddSticker(tmpDir, testSticker("sha256:abc123")); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+	if err := CreatePack(tmpDir, "favourites", "My Favourites"); err != nil {
+		t.Fatalf("Failed to create pack: %v", err)
+	}
+	if err := AddToPack(tmpDir, "favourites", []string{"sha256:abc123"}); err != nil {
+		t.Fatalf("Failed to add to pack: %v", err)
+	}
+	if err := UpdatePublished(tmpDir, "favourites", "!room:matrix.org", "favourites"); err != nil {
+		t.Fatalf("Failed to update published: %v", err)
+	}
+
+	store, err := OpenSQLite(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	pack, err := store.GetPack("favourites")
+	if err != nil {
+		t.Fatalf("Failed to get imported pack: %v", err)
+	}
+	if len(pack.StickerIDs) != 1 || pack.StickerIDs[0] != "sha256:abc123" {
+		t.Errorf("Expected imported pack to contain sticker, got %v", pack.StickerIDs)
+	}
+	if pack.PublishedRooms["!room:matrix.org"] != "favourites" {
+		t.Errorf("Expected imported published rooms, got %v", pack.PublishedRooms)
+	}
+}
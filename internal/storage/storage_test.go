@@ -93,7 +93,7 @@ func TestUpdateAltText_NotFound(t *testing.T) {
 	tmpDir := setupTestDir(t)
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 
-	err := UpdateAltText(tmpDir, "sha256:doesnotexist", "new text")
+	err := UpdateAltText(tmpDir, "sha256:doesnotexist", AltText{Text: "new text", By: AltTextByHuman})
 	if err == nil {
 		t.Error("Expected error when updating non-existent sticker")
 	}
@@ -110,7 +110,7 @@ func TestUpdateAltText_Success(t *testing.T) {
 		t.Fatalf("Failed to add sticker: %v", err)
 	}
 
-	if err := UpdateAltText(tmpDir, "sha256:abc123", "updated"); err != nil {
+	if err := UpdateAltText(tmpDir, "sha256:abc123", AltText{Text: "updated", By: AltTextByHuman}); err != nil {
 		t.Fatalf("Failed to update alt-text: %v", err)
 	}
 
@@ -122,6 +122,9 @@ func TestUpdateAltText_Success(t *testing.T) {
 	if retrieved.GeneratedAltText != "updated" {
 		t.Errorf("Expected alt-text 'updated', got %s", retrieved.GeneratedAltText)
 	}
+	if !retrieved.HumanAltText() {
+		t.Errorf("Expected alt-text to be marked human-written, got %q", retrieved.AltTextBy)
+	}
 }
 
 // TestCreatePack_Success verifies pack creation
@@ -406,12 +409,3 @@ func testSticker(id string) Sticker {
 		InPacks:          []string{},
 	}
 }
-
-func containsString(slice []string, str string) bool {
-	for _, s := range slice {
-		if s == str {
-			return true
-		}
-	}
-	return false
-}
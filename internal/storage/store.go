@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Storage backend names accepted by Open
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// ErrStickerNotFound is wrapped by the errors for a sticker ID that isn't in the collection
+var ErrStickerNotFound = errors.New("sticker not found")
+
+// Store is the persistence interface for the sticker collection and pack definitions.
+// Implementations keep Sticker.InPacks and Pack.StickerIDs consistent with each other.
+type Store interface {
+	// AddSticker adds a sticker to the collection, replacing any sticker with the same ID
+	// but keeping the packs it's in
+	AddSticker(sticker Sticker) error
+	// GetSticker retrieves a sticker by ID, failing with ErrStickerNotFound if there is none
+	GetSticker(id string) (*Sticker, error)
+	// ListStickers returns all collected stickers in collection order
+	ListStickers() ([]Sticker, error)
+	// ListUnsorted returns stickers that are not in any pack
+	ListUnsorted() ([]Sticker, error)
+	// UpdateAltText replaces a sticker's alt-text in every language and records who wrote it
+	UpdateAltText(id string, altText AltText) error
+	// SetStickerUsage sets the usage types for a sticker (nil clears the override)
+	SetStickerUsage(id string, usage []string) error
+	// SetStickerName sets the shortcode name for a sticker, dropping any suggested one.
+	// It fails with a *ShortcodeConflict if another sticker in one of its packs uses it.
+	SetStickerName(id string, name string) error
+	// DeleteSticker removes a sticker from the collection and all packs
+	DeleteSticker(id string) error
+	// TagSticker adds normalized tags to a sticker, ignoring ones it already has
+	TagSticker(id string, tags []string) error
+	// UntagSticker removes tags from a sticker
+	UntagSticker(id string, tags []string) error
+	// ListTags returns every tag in use with its sticker count, sorted by tag
+	ListTags() ([]TagCount, error)
+	// TaggedStickers returns the stickers matching a tag query, in collection order
+	TaggedStickers(query TagQuery) ([]Sticker, error)
+
+	// CreatePack creates a new empty pack
+	CreatePack(name string, displayName string, attribution string) error
+	// DeletePack removes a pack, leaving its stickers in the collection
+	DeletePack(name string) error
+	// RenamePack changes a pack's name, updating every sticker that references it
+	RenamePack(oldName string, newName string) error
+	// SetPackDisplayName sets the user-facing name of a pack
+	SetPackDisplayName(name string, displayName string) error
+	// MovePackSticker moves a sticker to a 1-based position within a pack
+	MovePackSticker(packName string, stickerID string, position int) error
+	// GetPack retrieves a pack by name
+	GetPack(name string) (*Pack, error)
+	// ListPacks returns all packs
+	ListPacks() ([]Pack, error)
+	// PackStickers returns the stickers in a pack, in pack order
+	PackStickers(name string) ([]Sticker, error)
+	// AddToPack adds stickers to a pack. It fails with a *ShortcodeConflict if one
+	// would share its shortcode with another sticker in the pack.
+	AddToPack(packName string, stickerIDs []string) error
+	// RemoveFromPack removes stickers from a pack
+	RemoveFromPack(packName string, stickerIDs []string) error
+	// UpdatePublished records that a pack has been published to a room
+	UpdatePublished(packName string, roomID string, stateKey string) error
+	// RemovePublished forgets that a pack was published to a room
+	RemovePublished(packName string, roomID string) error
+	// SetPackAvatar sets the avatar URL for a pack
+	SetPackAvatar(packName string, avatarURL string) error
+	// SetPackUsage sets the default usage for all stickers in a pack (nil clears it)
+	SetPackUsage(packName string, usage []string) error
+	// SetPersonalPack records which pack is published as the user's personal emotes,
+	// clearing the flag on any other pack (an empty name clears it everywhere)
+	SetPersonalPack(packName string) error
+
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// Open opens the store for the given backend in dataDir.
+// An empty backend selects the JSON files, which are upgraded to the current schema first.
+func Open(backend string, dataDir string) (Store, error) {
+	switch backend {
+	case "", BackendJSON:
+		if _, err := Migrate(dataDir, false); err != nil {
+			return nil, fmt.Errorf("failed to migrate data files: %w", err)
+		}
+		return NewJSONStore(dataDir), nil
+	case BackendSQLite:
+		return OpenSQLite(dataDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s (valid: %s, %s)", backend, BackendJSON, BackendSQLite)
+	}
+}
@@ -0,0 +1,617 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// storeBackends lists the Store implementations exercised by the conformance tests.
+// Optional backends register themselves from build-tagged test files.
+var storeBackends = map[string]func(t *testing.T, dataDir string) Store{
+	BackendJSON: func(t *testing.T, dataDir string) Store {
+		return NewJSONStore(dataDir)
+	},
+}
+
+// forEachBackend runs a test against a fresh store for every backend
+func forEachBackend(t *testing.T, fn func(t *testing.T, store Store)) {
+	t.Helper()
+	for name, open := range storeBackends {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := setupTestDir(t)
+			defer func() { _ = os.RemoveAll(tmpDir) }()
+
+			store := open(t, tmpDir)
+			defer func() { _ = store.Close() }()
+
+			fn(t, store)
+		})
+	}
+}
+
+// TestOpen_UnknownBackend verifies an unknown backend is rejected
+func TestOpen_UnknownBackend(t *testing.T) {
+	if _, err := Open("leveldb", t.TempDir()); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+// TestOpen_DefaultsToJSON verifies an empty backend selects the JSON store
+func TestOpen_DefaultsToJSON(t *testing.T) {
+	store, err := Open("", t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if _, ok := store.(*JSONStore); !ok {
+		t.Errorf("Expected *JSONStore, got %T", store)
+	}
+}
+
+// TestStore_StickerLifecycle verifies add, get, rename, usage and delete
+func TestStore_StickerLifecycle(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		sticker := testSticker("sha256:abc123")
+		if err := store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+
+		if err := store.SetStickerName("sha256:abc123", "happy_cat"); err != nil {
+			t.Fatalf("Failed to set name: %v", err)
+		}
+		if err := store.SetStickerUsage("sha256:abc123", []string{"emoticon"}); err != nil {
+			t.Fatalf("Failed to set usage: %v", err)
+		}
+		altText := AltText{Text: "A happy cat", Languages: map[string]string{"en": "A happy cat"}, By: AltTextByModel, Model: "llava"}
+		if err := store.UpdateAltText("sha256:abc123", altText); err != nil {
+			t.Fatalf("Failed to update alt-text: %v", err)
+		}
+
+		retrieved, err := store.GetSticker("sha256:abc123")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if retrieved.Name != "happy_cat" {
+			t.Errorf("Expected name happy_cat, got %s", retrieved.Name)
+		}
+		if len(retrieved.Usage) != 1 || retrieved.Usage[0] != "emoticon" {
+			t.Errorf("Expected usage [emoticon], got %v", retrieved.Usage)
+		}
+		if retrieved.GeneratedAltText != "A happy cat" {
+			t.Errorf("Expected updated alt-text, got %s", retrieved.GeneratedAltText)
+		}
+		if retrieved.AltTexts["en"] != "A happy cat" || retrieved.AltTextBy != AltTextByModel || retrieved.AltTextModel != "llava" {
+			t.Errorf("Expected alt-text languages and provenance, got %v, %q, %q", retrieved.AltTexts, retrieved.AltTextBy, retrieved.AltTextModel)
+		}
+		if !retrieved.CollectedAt.Equal(sticker.CollectedAt) {
+			t.Errorf("Expected collected_at %v, got %v", sticker.CollectedAt, retrieved.CollectedAt)
+		}
+
+		if err := store.DeleteSticker("sha256:abc123"); err != nil {
+			t.Fatalf("Failed to delete sticker: %v", err)
+		}
+		if _, err := store.GetSticker("sha256:abc123"); !errors.Is(err, ErrStickerNotFound) {
+			t.Errorf("Expected ErrStickerNotFound getting deleted sticker, got %v", err)
+		}
+		if err := store.SetStickerName("sha256:abc123", "gone"); !errors.Is(err, ErrStickerNotFound) {
+			t.Errorf("Expected ErrStickerNotFound renaming deleted sticker, got %v", err)
+		}
+	})
+}
+
+// TestStore_AddStickerKeepsPacks verifies replacing a sticker leaves it in its packs
+func TestStore_AddStickerKeepsPacks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		if err := store.AddSticker(testSticker("sha256:abc123")); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+		if err := store.CreatePack("cats", "Cats", ""); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+		if err := store.AddToPack("cats", []string{"sha256:abc123"}); err != nil {
+			t.Fatalf("Failed to add to pack: %v", err)
+		}
+
+		replacement := testSticker("sha256:abc123")
+		replacement.GeneratedAltText = "updated version"
+		if err := store.AddSticker(replacement); err != nil {
+			t.Fatalf("Failed to replace sticker: %v", err)
+		}
+
+		retrieved, err := store.GetSticker("sha256:abc123")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if retrieved.GeneratedAltText != "updated version" {
+			t.Errorf("Expected updated alt-text, got %s", retrieved.GeneratedAltText)
+		}
+		if len(retrieved.InPacks) != 1 || retrieved.InPacks[0] != "cats" {
+			t.Errorf("Expected sticker still in [cats], got %v", retrieved.InPacks)
+		}
+		pack, err := store.GetPack("cats")
+		if err != nil {
+			t.Fatalf("Failed to get pack: %v", err)
+		}
+		if len(pack.StickerIDs) != 1 || pack.StickerIDs[0] != "sha256:abc123" {
+			t.Errorf("Expected pack to still list the sticker, got %v", pack.StickerIDs)
+		}
+	})
+}
+
+// TestStore_PackMembership verifies pack order, InPacks and unsorted listing stay consistent
+func TestStore_PackMembership(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		for _, id := range []string{"sha256:one", "sha256:two", "sha256:three"} {
+			if err := store.AddSticker(testSticker(id)); err != nil {
+				t.Fatalf("Failed to add sticker: %v", err)
+			}
+		}
+
+		if err := store.CreatePack("favourites", "My Favourites", "@test:matrix.org"); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+		if err := store.CreatePack("favourites", "Again", ""); err == nil {
+			t.Error("Expected error creating duplicate pack")
+		}
+
+		if err := store.AddToPack("favourites", []string{"sha256:two", "sha256:one", "sha256:two"}); err != nil {
+			t.Fatalf("Failed to add to pack: %v", err)
+		}
+		if err := store.AddToPack("favourites", []string{"sha256:missing"}); err == nil {
+			t.Error("Expected error adding missing sticker")
+		}
+
+		stickers, err := store.PackStickers("favourites")
+		if err != nil {
+			t.Fatalf("Failed to get pack stickers: %v", err)
+		}
+		if len(stickers) != 2 || stickers[0].ID != "sha256:two" || stickers[1].ID != "sha256:one" {
+			t.Errorf("Expected [two one] in pack order, got %v", stickers)
+		}
+
+		sticker, err := store.GetSticker("sha256:two")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if !containsString(sticker.InPacks, "favourites") {
+			t.Error("Expected sticker to record pack membership")
+		}
+
+		unsorted, err := store.ListUnsorted()
+		if err != nil {
+			t.Fatalf("Failed to list unsorted: %v", err)
+		}
+		if len(unsorted) != 1 || unsorted[0].ID != "sha256:three" {
+			t.Errorf("Expected only sticker three unsorted, got %v", unsorted)
+		}
+
+		// Deleting a sticker removes it from its packs
+		if err := store.DeleteSticker("sha256:two"); err != nil {
+			t.Fatalf("Failed to delete sticker: %v", err)
+		}
+		pack, err := store.GetPack("favourites")
+		if err != nil {
+			t.Fatalf("Failed to get pack: %v", err)
+		}
+		if len(pack.StickerIDs) != 1 || pack.StickerIDs[0] != "sha256:one" {
+			t.Errorf("Expected pack to contain only sticker one, got %v", pack.StickerIDs)
+		}
+
+		if err := store.RemoveFromPack("favourites", []string{"sha256:one"}); err != nil {
+			t.Fatalf("Failed to remove from pack: %v", err)
+		}
+		sticker, err = store.GetSticker("sha256:one")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if len(sticker.InPacks) != 0 {
+			t.Errorf("Expected sticker to be unsorted after removal, got %v", sticker.InPacks)
+		}
+	})
+}
+
+// TestStore_PackMetadata verifies avatar, usage and published room tracking
+func TestStore_PackMetadata(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		if err := store.CreatePack("favourites", "My Favourites", ""); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+
+		if err := store.SetPackAvatar("favourites", "mxc://matrix.org/avatar"); err != nil {
+			t.Fatalf("Failed to set avatar: %v", err)
+		}
+		if err := store.SetPackUsage("favourites", []string{"sticker"}); err != nil {
+			t.Fatalf("Failed to set usage: %v", err)
+		}
+		if err := store.UpdatePublished("favourites", "!room:matrix.org", "favourites"); err != nil {
+			t.Fatalf("Failed to update published: %v", err)
+		}
+		if err := store.SetPackAvatar("missing", "mxc://matrix.org/avatar"); err == nil {
+			t.Error("Expected error setting avatar on missing pack")
+		}
+
+		packs, err := store.ListPacks()
+		if err != nil {
+			t.Fatalf("Failed to list packs: %v", err)
+		}
+		if len(packs) != 1 {
+			t.Fatalf("Expected 1 pack, got %d", len(packs))
+		}
+
+		pack := packs[0]
+		if pack.AvatarURL != "mxc://matrix.org/avatar" {
+			t.Errorf("Expected avatar to be set, got %s", pack.AvatarURL)
+		}
+		if len(pack.Usage) != 1 || pack.Usage[0] != "sticker" {
+			t.Errorf("Expected usage [sticker], got %v", pack.Usage)
+		}
+		if pack.PublishedRooms["!room:matrix.org"] != "favourites" {
+			t.Errorf("Expected published room to be recorded, got %v", pack.PublishedRooms)
+		}
+
+		if err := store.RemovePublished("favourites", "!room:matrix.org"); err != nil {
+			t.Fatalf("Failed to remove published room: %v", err)
+		}
+		if err := store.RemovePublished("favourites", "!room:matrix.org"); err == nil {
+			t.Error("Expected error removing a room the pack isn't published to")
+		}
+		updated, err := store.GetPack("favourites")
+		if err != nil {
+			t.Fatalf("Failed to get pack: %v", err)
+		}
+		if len(updated.PublishedRooms) != 0 {
+			t.Errorf("Expected no published rooms, got %v", updated.PublishedRooms)
+		}
+	})
+}
+
+// TestStore_PersonalPack verifies only one pack is marked as the personal emotes
+func TestStore_PersonalPack(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		for _, name := range []string{"cats", "dogs"} {
+			if err := store.CreatePack(name, name, ""); err != nil {
+				t.Fatalf("Failed to create pack: %v", err)
+			}
+		}
+
+		if err := store.SetPersonalPack("cats"); err != nil {
+			t.Fatalf("Failed to set personal pack: %v", err)
+		}
+		if err := store.SetPersonalPack("dogs"); err != nil {
+			t.Fatalf("Failed to set personal pack: %v", err)
+		}
+		if err := store.SetPersonalPack("missing"); err == nil {
+			t.Error("Expected error setting missing pack as personal")
+		}
+
+		cats, _ := store.GetPack("cats")
+		dogs, _ := store.GetPack("dogs")
+		if cats.PublishedPersonal || !dogs.PublishedPersonal {
+			t.Errorf("Expected only dogs to be personal, got cats=%v dogs=%v", cats.PublishedPersonal, dogs.PublishedPersonal)
+		}
+
+		if err := store.SetPersonalPack(""); err != nil {
+			t.Fatalf("Failed to clear personal pack: %v", err)
+		}
+		dogs, _ = store.GetPack("dogs")
+		if dogs.PublishedPersonal {
+			t.Error("Expected personal flag to be cleared")
+		}
+	})
+}
+
+// TestStore_PackRenameDelete verifies rename follows through to stickers and delete leaves them unsorted
+func TestStore_PackRenameDelete(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		if err := store.AddSticker(testSticker("sha256:abc123")); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+		for _, name := range []string{"cats", "dogs"} {
+			if err := store.CreatePack(name, name, ""); err != nil {
+				t.Fatalf("Failed to create pack: %v", err)
+			}
+		}
+		if err := store.AddToPack("cats", []string{"sha256:abc123"}); err != nil {
+			t.Fatalf("Failed to add to pack: %v", err)
+		}
+		if err := store.UpdatePublished("cats", "!room:matrix.org", "cats"); err != nil {
+			t.Fatalf("Failed to update published: %v", err)
+		}
+
+		if err := store.RenamePack("cats", "dogs"); err == nil {
+			t.Error("Expected error renaming onto an existing pack")
+		}
+		if err := store.RenamePack("missing", "birds"); err == nil {
+			t.Error("Expected error renaming a missing pack")
+		}
+		if err := store.RenamePack("cats", "kittens"); err != nil {
+			t.Fatalf("Failed to rename pack: %v", err)
+		}
+
+		pack, err := store.GetPack("kittens")
+		if err != nil {
+			t.Fatalf("Failed to get renamed pack: %v", err)
+		}
+		if len(pack.StickerIDs) != 1 || pack.PublishedRooms["!room:matrix.org"] != "cats" {
+			t.Errorf("Expected stickers and published state key to survive rename, got %+v", pack)
+		}
+		sticker, _ := store.GetSticker("sha256:abc123")
+		if len(sticker.InPacks) != 1 || sticker.InPacks[0] != "kittens" {
+			t.Errorf("Expected InPacks [kittens], got %v", sticker.InPacks)
+		}
+
+		if err := store.SetPackDisplayName("kittens", "Kittens!"); err != nil {
+			t.Fatalf("Failed to set display name: %v", err)
+		}
+		if pack, _ := store.GetPack("kittens"); pack.DisplayName != "Kittens!" {
+			t.Errorf("Expected display name to be set, got %s", pack.DisplayName)
+		}
+
+		if err := store.DeletePack("kittens"); err != nil {
+			t.Fatalf("Failed to delete pack: %v", err)
+		}
+		if err := store.DeletePack("kittens"); err == nil {
+			t.Error("Expected error deleting a missing pack")
+		}
+		unsorted, err := store.ListUnsorted()
+		if err != nil {
+			t.Fatalf("Failed to list unsorted: %v", err)
+		}
+		if len(unsorted) != 1 {
+			t.Errorf("Expected sticker to become unsorted, got %d unsorted", len(unsorted))
+		}
+	})
+}
+
+// TestStore_MovePackSticker verifies stickers can be reordered within a pack
+func TestStore_MovePackSticker(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		ids := []string{"sha256:a", "sha256:b", "sha256:c"}
+		for _, id := range ids {
+			if err := store.AddSticker(testSticker(id)); err != nil {
+				t.Fatalf("Failed to add sticker: %v", err)
+			}
+		}
+		if err := store.CreatePack("cats", "Cats", ""); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+		if err := store.AddToPack("cats", ids); err != nil {
+			t.Fatalf("Failed to add to pack: %v", err)
+		}
+
+		if err := store.MovePackSticker("cats", "sha256:c", 1); err != nil {
+			t.Fatalf("Failed to move sticker: %v", err)
+		}
+		if err := store.MovePackSticker("cats", "sha256:a", 3); err != nil {
+			t.Fatalf("Failed to move sticker: %v", err)
+		}
+		if err := store.MovePackSticker("cats", "sha256:a", 4); err == nil {
+			t.Error("Expected error for out-of-range position")
+		}
+		if err := store.MovePackSticker("cats", "sha256:missing", 1); err == nil {
+			t.Error("Expected error moving a sticker not in the pack")
+		}
+
+		stickers, err := store.PackStickers("cats")
+		if err != nil {
+			t.Fatalf("Failed to load pack stickers: %v", err)
+		}
+		var order []string
+		for _, sticker := range stickers {
+			order = append(order, sticker.ID)
+		}
+		expected := []string{"sha256:c", "sha256:b", "sha256:a"}
+		for i := range expected {
+			if i >= len(order) || order[i] != expected[i] {
+				t.Fatalf("Expected order %v, got %v", expected, order)
+			}
+		}
+	})
+}
+
+// TestStore_AltTexts verifies alt-text in each language survives saving and loading
+func TestStore_AltTexts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		sticker := testSticker("sha256:cat")
+		sticker.AltTexts = map[string]string{"en": "A happy cat", "de": "Eine fröhliche Katze"}
+		if err := store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+		if err := store.AddSticker(testSticker("sha256:dog")); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+
+		retrieved, err := store.GetSticker("sha256:cat")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if len(retrieved.AltTexts) != 2 || retrieved.AltTexts["de"] != "Eine fröhliche Katze" {
+			t.Errorf("Expected both alt-texts, got %v", retrieved.AltTexts)
+		}
+
+		stickers, err := store.ListStickers()
+		if err != nil {
+			t.Fatalf("Failed to list stickers: %v", err)
+		}
+		if len(stickers) != 2 || stickers[0].AltTexts["en"] != "A happy cat" || stickers[1].AltTexts != nil {
+			t.Errorf("Expected alt-texts only on the first sticker, got %+v", stickers)
+		}
+	})
+}
+
+// TestStore_SuggestedName verifies a suggested shortcode is saved and dropped once
+// the sticker is named
+func TestStore_SuggestedName(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		sticker := testSticker("sha256:cat")
+		sticker.SuggestedName = "happy_cat"
+		if err := store.AddSticker(sticker); err != nil {
+			t.Fatalf("Failed to add sticker: %v", err)
+		}
+
+		retrieved, err := store.GetSticker("sha256:cat")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if retrieved.SuggestedName != "happy_cat" {
+			t.Errorf("Expected suggested name happy_cat, got %q", retrieved.SuggestedName)
+		}
+
+		if err := store.SetStickerName("sha256:cat", "grumpy_cat"); err != nil {
+			t.Fatalf("Failed to set name: %v", err)
+		}
+		retrieved, err = store.GetSticker("sha256:cat")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if retrieved.Name != "grumpy_cat" || retrieved.SuggestedName != "" {
+			t.Errorf("Expected name grumpy_cat and no suggestion, got %q and %q", retrieved.Name, retrieved.SuggestedName)
+		}
+	})
+}
+
+// TestStore_ShortcodeConflicts verifies a pack can't hold two stickers with the same
+// shortcode, whether they're added to it or renamed
+func TestStore_ShortcodeConflicts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		for _, sticker := range []Sticker{
+			{ID: "sha256:one", Name: "cat"},
+			{ID: "sha256:two", Name: "cat"},
+			{ID: "sha256:three", Name: "dog"},
+		} {
+			if err := store.AddSticker(sticker); err != nil {
+				t.Fatalf("Failed to add sticker: %v", err)
+			}
+		}
+		if err := store.CreatePack("pets", "Pets", ""); err != nil {
+			t.Fatalf("Failed to create pack: %v", err)
+		}
+
+		var conflict *ShortcodeConflict
+		err := store.AddToPack("pets", []string{"sha256:one", "sha256:two"})
+		if !errors.As(err, &conflict) || conflict.Shortcode != "cat" || conflict.Pack != "pets" {
+			t.Fatalf("Expected a shortcode conflict adding both cats, got %v", err)
+		}
+		if stickers, _ := store.PackStickers("pets"); len(stickers) != 0 {
+			t.Errorf("Expected a failed add to leave the pack empty, got %d stickers", len(stickers))
+		}
+
+		if err := store.AddToPack("pets", []string{"sha256:one", "sha256:three"}); err != nil {
+			t.Fatalf("Failed to add to pack: %v", err)
+		}
+		if err := store.AddToPack("pets", []string{"sha256:one"}); err != nil {
+			t.Errorf("Expected re-adding a sticker to be allowed, got %v", err)
+		}
+		if err := store.AddToPack("pets", []string{"sha256:two"}); !errors.As(err, &conflict) {
+			t.Errorf("Expected a shortcode conflict adding the second cat, got %v", err)
+		}
+
+		// Renaming checks the sticker's packs, but not the rest of the collection
+		if err := store.SetStickerName("sha256:three", "cat"); !errors.As(err, &conflict) || conflict.StickerIDs[0] != "sha256:one" {
+			t.Errorf("Expected a shortcode conflict renaming the dog, got %v", err)
+		}
+		if err := store.SetStickerName("sha256:one", "cat"); err != nil {
+			t.Errorf("Expected renaming a sticker to its own name to be allowed, got %v", err)
+		}
+		if err := store.SetStickerName("sha256:two", "dog"); err != nil {
+			t.Errorf("Expected renaming an unsorted sticker to be allowed, got %v", err)
+		}
+	})
+}
+
+// TestFindShortcodeConflicts verifies shared shortcodes are found in pack order,
+// with unnamed stickers using their ID
+func TestFindShortcodeConflicts(t *testing.T) {
+	stickers := []Sticker{
+		{ID: "a", Name: "dog"},
+		{ID: "b", Name: "cat"},
+		{ID: "c", Name: "dog"},
+		{ID: "d", Name: "cat"},
+		{ID: "e", Name: "dog"},
+		{ID: "f"},
+		{ID: "g", Name: "f"},
+	}
+
+	conflicts := FindShortcodeConflicts("pets", stickers)
+	if len(conflicts) != 3 {
+		t.Fatalf("Expected 3 conflicts, got %+v", conflicts)
+	}
+	if conflicts[0].Shortcode != "dog" || len(conflicts[0].StickerIDs) != 3 || conflicts[1].Shortcode != "cat" || conflicts[2].Shortcode != "f" {
+		t.Errorf("Unexpected conflicts: %+v", conflicts)
+	}
+
+	if conflicts := FindShortcodeConflicts("pets", stickers[:2]); conflicts != nil {
+		t.Errorf("Expected no conflicts, got %+v", conflicts)
+	}
+}
+
+// TestStore_Tags verifies tagging, the tag index and tag queries
+func TestStore_Tags(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, store Store) {
+		cat := testSticker("sha256:cat")
+		cat.Tags = []string{"cat", "happy"}
+		dog := testSticker("sha256:dog")
+		for _, s := range []Sticker{cat, dog} {
+			if err := store.AddSticker(s); err != nil {
+				t.Fatalf("Failed to add sticker: %v", err)
+			}
+		}
+
+		if err := store.TagSticker("sha256:dog", []string{"dog", "happy", "dog"}); err != nil {
+			t.Fatalf("Failed to tag sticker: %v", err)
+		}
+		if err := store.TagSticker("sha256:missing", []string{"cat"}); err == nil {
+			t.Error("Expected error tagging missing sticker")
+		}
+
+		retrieved, err := store.GetSticker("sha256:dog")
+		if err != nil {
+			t.Fatalf("Failed to get sticker: %v", err)
+		}
+		if len(retrieved.Tags) != 2 || retrieved.Tags[0] != "dog" || retrieved.Tags[1] != "happy" {
+			t.Errorf("Expected tags [dog happy], got %v", retrieved.Tags)
+		}
+
+		tags, err := store.ListTags()
+		if err != nil {
+			t.Fatalf("Failed to list tags: %v", err)
+		}
+		expected := []TagCount{{"cat", 1}, {"dog", 1}, {"happy", 2}}
+		if len(tags) != len(expected) {
+			t.Fatalf("Expected tags %v, got %v", expected, tags)
+		}
+		for i := range expected {
+			if tags[i] != expected[i] {
+				t.Errorf("Expected tags %v, got %v", expected, tags)
+				break
+			}
+		}
+
+		tagged, err := store.TaggedStickers(TagQuery{Include: []string{"happy"}, Exclude: []string{"cat"}})
+		if err != nil {
+			t.Fatalf("Failed to query tags: %v", err)
+		}
+		if len(tagged) != 1 || tagged[0].ID != "sha256:dog" {
+			t.Errorf("Expected only the dog, got %v", tagged)
+		}
+
+		if err := store.UntagSticker("sha256:cat", []string{"cat", "happy"}); err != nil {
+			t.Fatalf("Failed to untag sticker: %v", err)
+		}
+		tagged, err = store.TaggedStickers(TagQuery{Include: []string{"happy"}})
+		if err != nil {
+			t.Fatalf("Failed to query tags: %v", err)
+		}
+		if len(tagged) != 1 || tagged[0].ID != "sha256:dog" {
+			t.Errorf("Expected untagged cat to drop out, got %v", tagged)
+		}
+
+		// Deleting a sticker drops it from the index
+		if err := store.DeleteSticker("sha256:dog"); err != nil {
+			t.Fatalf("Failed to delete sticker: %v", err)
+		}
+		if tags, _ := store.ListTags(); len(tags) != 0 {
+			t.Errorf("Expected empty tag index, got %v", tags)
+		}
+	})
+}
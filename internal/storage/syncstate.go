@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// syncStateFile holds the bot's sync position, separate from the collection and config
+const syncStateFile = "sync.json"
+
+// SyncState is where the bot's Matrix sync left off
+type SyncState struct {
+	NextBatch  string `json:"next_batch"`            // Token of the last fully processed sync
+	FilterID   string `json:"filter_id,omitempty"`   // Server-side sync filter
+	FilterHash string `json:"filter_hash,omitempty"` // Hash of the filter definition FilterID was created from
+}
+
+// LoadSyncState reads sync.json, returning an empty state if it doesn't exist yet
+func LoadSyncState(dataDir string) (*SyncState, error) {
+	state := &SyncState{}
+
+	data, err := os.ReadFile(filepath.Join(dataDir, syncStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", syncStateFile, err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", syncStateFile, err)
+	}
+
+	return state, nil
+}
+
+// SaveSyncState atomically replaces sync.json
+func SaveSyncState(dataDir string, state *SyncState) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+
+	return writeFileAtomic(filepath.Join(dataDir, syncStateFile), data, 0644)
+}
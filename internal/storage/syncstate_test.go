@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"os"
+	"testing"
+)
+
+// TestSyncState_RoundTrip verifies sync state starts empty and survives a save
+func TestSyncState_RoundTrip(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	state, err := LoadSyncState(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load missing sync state: %v", err)
+	}
+	if state.NextBatch != "" || state.FilterID != "" {
+		t.Errorf("Expected empty sync state, got %+v", state)
+	}
+
+	state.NextBatch = "s123_456"
+	state.FilterID = "filter1"
+	state.FilterHash = "abc"
+	if err := SaveSyncState(tmpDir, state); err != nil {
+		t.Fatalf("Failed to save sync state: %v", err)
+	}
+
+	loaded, err := LoadSyncState(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load sync state: %v", err)
+	}
+	if *loaded != *state {
+		t.Errorf("Expected %+v, got %+v", state, loaded)
+	}
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxTagLength is the longest tag accepted, in characters
+const maxTagLength = 32
+
+// TagCount is a tag in use and how many stickers have it
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// TagQuery selects stickers by tag: every Include tag must be present and no
+// Exclude tag may be
+type TagQuery struct {
+	Include []string
+	Exclude []string
+}
+
+// NormalizeTag lowercases a tag and strips a leading '#', checking it only contains
+// letters, numbers, underscores and hyphens
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" {
+		return "", fmt.Errorf("tag cannot be empty")
+	}
+	if len([]rune(tag)) > maxTagLength {
+		return "", fmt.Errorf("tag too long (max %d characters): %s", maxTagLength, tag)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return "", fmt.Errorf("invalid tag: %s (use letters, numbers, underscores and hyphens)", tag)
+		}
+	}
+	return tag, nil
+}
+
+// ParseTags normalizes a list of tags given as separate or comma-separated words,
+// dropping duplicates
+func ParseTags(args []string) ([]string, error) {
+	tags := []string{}
+	for _, arg := range args {
+		for word := range strings.SplitSeq(arg, ",") {
+			if strings.TrimSpace(word) == "" {
+				continue
+			}
+			tag, err := NormalizeTag(word)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// ParseTagQuery parses a tag query such as "cat happy -sad": stickers need every
+// listed tag, and none of the ones prefixed with '-'
+func ParseTagQuery(query string) (TagQuery, error) {
+	var q TagQuery
+	for _, word := range strings.Fields(strings.ReplaceAll(query, ",", " ")) {
+		exclude := strings.HasPrefix(word, "-")
+		tag, err := NormalizeTag(strings.TrimPrefix(word, "-"))
+		if err != nil {
+			return q, err
+		}
+		if exclude {
+			q.Exclude = append(q.Exclude, tag)
+		} else {
+			q.Include = append(q.Include, tag)
+		}
+	}
+
+	if len(q.Include) == 0 {
+		return q, fmt.Errorf("tag query needs at least one tag to match")
+	}
+	return q, nil
+}
+
+// Matches reports whether a sticker's tags satisfy the query
+func (q TagQuery) Matches(sticker Sticker) bool {
+	for _, tag := range q.Include {
+		if !slices.Contains(sticker.Tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range q.Exclude {
+		if slices.Contains(sticker.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the query the way ParseTagQuery reads it
+func (q TagQuery) String() string {
+	words := slices.Clone(q.Include)
+	for _, tag := range q.Exclude {
+		words = append(words, "-"+tag)
+	}
+	return strings.Join(words, " ")
+}
+
+// TagSticker adds tags to a sticker, ignoring ones it already has
+func TagSticker(dataDir string, id string, tags []string) error {
+	return updateSticker(dataDir, id, func(sticker *Sticker) {
+		for _, tag := range tags {
+			if !slices.Contains(sticker.Tags, tag) {
+				sticker.Tags = append(sticker.Tags, tag)
+			}
+		}
+	})
+}
+
+// UntagSticker removes tags from a sticker
+func UntagSticker(dataDir string, id string, tags []string) error {
+	return updateSticker(dataDir, id, func(sticker *Sticker) {
+		sticker.Tags = slices.DeleteFunc(sticker.Tags, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+		if len(sticker.Tags) == 0 {
+			sticker.Tags = nil
+		}
+	})
+}
+
+// ListTags returns every tag in use with its sticker count, sorted by tag
+func ListTags(dataDir string) ([]TagCount, error) {
+	collection, err := LoadCollection(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, sticker := range collection.Stickers {
+		for _, tag := range sticker.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := []TagCount{}
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	slices.SortFunc(tags, func(a, b TagCount) int { return cmp.Compare(a.Tag, b.Tag) })
+
+	return tags, nil
+}
+
+// TaggedStickers returns the stickers matching a tag query, in collection order
+func TaggedStickers(dataDir string, query TagQuery) ([]Sticker, error) {
+	collection, err := LoadCollection(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection: %w", err)
+	}
+
+	tagged := []Sticker{}
+	for _, sticker := range collection.Stickers {
+		if query.Matches(sticker) {
+			tagged = append(tagged, sticker)
+		}
+	}
+
+	return tagged, nil
+}
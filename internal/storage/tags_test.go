@@ -0,0 +1,68 @@
+package storage
+
+import "testing"
+
+// TestNormalizeTag verifies tags are lowercased and checked
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"Cat", "cat", true},
+		{"#happy", "happy", true},
+		{"big_cat-2", "big_cat-2", true},
+		{"café", "café", true},
+		{"", "", false},
+		{"#", "", false},
+		{"two words", "", false},
+		{"tag:cat", "", false},
+		{"abcdefghijklmnopqrstuvwxyz1234567", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.input)
+		if tt.valid && (err != nil || got != tt.expected) {
+			t.Errorf("NormalizeTag(%q) = %q, %v; expected %q", tt.input, got, err, tt.expected)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("NormalizeTag(%q) expected error, got %q", tt.input, got)
+		}
+	}
+}
+
+// TestParseTags verifies comma-separated tags are split and deduplicated
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags([]string{"cat,Happy", "#cat", "wave,"})
+	if err != nil {
+		t.Fatalf("Failed to parse tags: %v", err)
+	}
+	if len(tags) != 3 || tags[0] != "cat" || tags[1] != "happy" || tags[2] != "wave" {
+		t.Errorf("Expected [cat happy wave], got %v", tags)
+	}
+
+	if _, err := ParseTags([]string{"cat", "no!"}); err == nil {
+		t.Error("Expected error for invalid tag")
+	}
+}
+
+// TestParseTagQuery verifies included and excluded tags
+func TestParseTagQuery(t *testing.T) {
+	q, err := ParseTagQuery("cat -sad, Happy")
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	if q.String() != "cat happy -sad" {
+		t.Errorf("Unexpected query: %s", q)
+	}
+
+	happyCat := Sticker{Tags: []string{"happy", "cat"}}
+	sadCat := Sticker{Tags: []string{"cat", "happy", "sad"}}
+	if !q.Matches(happyCat) || q.Matches(sadCat) || q.Matches(Sticker{}) {
+		t.Error("Unexpected matches for query")
+	}
+
+	if _, err := ParseTagQuery("-sad"); err == nil {
+		t.Error("Expected error for query with only exclusions")
+	}
+}
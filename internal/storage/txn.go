@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	collectionFile = "collection.json"
+	packsFile      = "packs.json"
+
+	// lockFile is flocked for the duration of every read and write
+	lockFile = ".lock"
+	// journalFile lists the pending files of a transaction that is being committed
+	journalFile = "commit.journal"
+	// pendingSuffix marks a fully written replacement waiting to be renamed into place
+	pendingSuffix = ".pending"
+)
+
+// Update runs fn with the collection and packs loaded under an exclusive lock on the
+// data directory. If fn returns nil, every file it changed is committed together; if
+// fn returns an error (or the process dies part-way through), neither file changes.
+func Update(dataDir string, fn func(collection *Collection, packsData *PacksData) error) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	unlock, err := lockDataDir(dataDir, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := recoverJournal(dataDir); err != nil {
+		return err
+	}
+
+	collection, collectionState, err := readCollection(dataDir)
+	if err != nil {
+		return err
+	}
+	packsData, packsState, err := readPacks(dataDir)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(collection, packsData); err != nil {
+		return err
+	}
+
+	// Only rewrite files whose contents actually changed
+	changes := make(map[string][]byte)
+	for _, file := range []struct {
+		name  string
+		state fileState
+		data  any
+	}{
+		{collectionFile, collectionState, collection},
+		{packsFile, packsState, packsData},
+	} {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", file.name, err)
+		}
+		if bytes.Equal(data, file.state.raw) {
+			continue
+		}
+
+		// Writing upgrades an old file, so keep the pre-migration copy first
+		if file.state.raw != nil && file.state.version < CurrentSchemaVersion {
+			if _, err := backupFile(dataDir, file.name, file.state); err != nil {
+				return err
+			}
+		}
+		changes[file.name] = data
+	}
+
+	return commitFiles(dataDir, changes)
+}
+
+// View runs fn with a consistent snapshot of the collection and packs, taken under a
+// shared lock so it never observes half of a transaction.
+func View(dataDir string, fn func(collection *Collection, packsData *PacksData) error) error {
+	unlock, err := lockForRead(dataDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	collection, _, err := readCollection(dataDir)
+	if err != nil {
+		return err
+	}
+	packsData, _, err := readPacks(dataDir)
+	if err != nil {
+		return err
+	}
+
+	return fn(collection, packsData)
+}
+
+// lockForRead takes a shared lock, first finishing any interrupted commit
+func lockForRead(dataDir string) (func(), error) {
+	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
+		// Nothing has been written yet, so there is nothing to lock
+		return func() {}, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dataDir, journalFile)); err == nil {
+		// Repairing needs the exclusive lock; take it briefly, then fall through
+		unlock, err := lockDataDir(dataDir, true)
+		if err != nil {
+			return nil, err
+		}
+		err = recoverJournal(dataDir)
+		unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lockDataDir(dataDir, false)
+}
+
+// commitFiles atomically replaces one or more files in dataDir.
+// Each replacement is written and synced as a pending file, then a journal listing
+// them is written; once the journal exists the commit is durable and recoverJournal
+// will finish the renames if we crash before doing so ourselves.
+func commitFiles(dataDir string, changes map[string][]byte) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Single-file changes don't need the journal, a rename is already atomic
+	if len(changes) == 1 {
+		for name, data := range changes {
+			return writeFileAtomic(filepath.Join(dataDir, name), data, 0644)
+		}
+	}
+
+	names := make([]string, 0, len(changes))
+	for name, data := range changes {
+		if err := writeFileSynced(filepath.Join(dataDir, name+pendingSuffix), data, 0644); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	journal, err := json.Marshal(names)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dataDir, journalFile), journal, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return recoverJournal(dataDir)
+}
+
+// recoverJournal completes a committed transaction and discards an uncommitted one.
+// Callers must hold the exclusive lock.
+func recoverJournal(dataDir string) error {
+	journalPath := filepath.Join(dataDir, journalFile)
+
+	data, err := os.ReadFile(journalPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	if err == nil {
+		// Journal present: the transaction committed, roll it forward
+		var names []string
+		if err := json.Unmarshal(data, &names); err != nil {
+			return fmt.Errorf("failed to parse journal: %w", err)
+		}
+		for _, name := range names {
+			pending := filepath.Join(dataDir, name+pendingSuffix)
+			if err := os.Rename(pending, filepath.Join(dataDir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to commit %s: %w", name, err)
+			}
+		}
+		if err := syncDir(dataDir); err != nil {
+			return err
+		}
+		if err := os.Remove(journalPath); err != nil {
+			return fmt.Errorf("failed to remove journal: %w", err)
+		}
+		if err := syncDir(dataDir); err != nil {
+			return err
+		}
+	}
+
+	// Any pending files left without a journal belong to an aborted transaction
+	for _, name := range []string{collectionFile, packsFile} {
+		if err := os.Remove(filepath.Join(dataDir, name+pendingSuffix)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to discard pending %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// writeFileAtomic replaces path with data via a synced temp file and rename,
+// so readers and crashes only ever see the old or the new contents
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Clean up the temp file unless the rename succeeds
+	committed := false
+	defer func() {
+		if !committed {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set permissions on %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	committed = true
+
+	return syncDir(dir)
+}
+
+// writeFileSynced writes data to path and syncs it to disk before returning
+func writeFileSynced(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
+
+// syncDir flushes directory entries so renames survive a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open data directory: %w", err)
+	}
+	defer func() { _ = d.Close() }()
+
+	// Some platforms and filesystems can't sync directories; the rename itself still happened
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return fmt.Errorf("failed to sync data directory: %w", err)
+	}
+	return nil
+}
+
+// readCollection reads collection.json without locking, upgrading it in memory to the
+// current schema version, and also returns what the file looked like on disk
+func readCollection(dataDir string) (*Collection, fileState, error) {
+	collection := &Collection{}
+	state, err := readDataFile(dataDir, collectionFile, collection)
+	if err != nil {
+		return nil, state, err
+	}
+	if collection.Stickers == nil {
+		collection.Stickers = []Sticker{}
+	}
+	collection.SchemaVersion = CurrentSchemaVersion
+	return collection, state, nil
+}
+
+// readPacks reads packs.json without locking, upgrading it in memory to the current
+// schema version, and also returns what the file looked like on disk
+func readPacks(dataDir string) (*PacksData, fileState, error) {
+	packsData := &PacksData{}
+	state, err := readDataFile(dataDir, packsFile, packsData)
+	if err != nil {
+		return nil, state, err
+	}
+	if packsData.Packs == nil {
+		packsData.Packs = []Pack{}
+	}
+	packsData.SchemaVersion = CurrentSchemaVersion
+	return packsData, state, nil
+}
+
+// readDataFile decodes a data file into v after running any pending migrations.
+// A missing file leaves v untouched, as if it were empty at the current version.
+func readDataFile(dataDir string, name string, v any) (fileState, error) {
+	raw, err := os.ReadFile(filepath.Join(dataDir, name))
+	if os.IsNotExist(err) {
+		return fileState{version: CurrentSchemaVersion}, nil
+	}
+	if err != nil {
+		return fileState{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	migrated, version, err := migrateJSON(name, raw)
+	state := fileState{raw: raw, version: version}
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(migrated, v); err != nil {
+		return state, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+
+	return state, nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestUpdate_RollsBackOnError verifies neither file changes when fn fails
+func TestUpdate_RollsBackOnError(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := AddSticker(tmpDir, testSticker("sha256:abc123")); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+
+	errBoom := errors.New("boom")
+	err := Update(tmpDir, func(collection *Collection, packsData *PacksData) error {
+		collection.Stickers = nil
+		packsData.Packs = append(packsData.Packs, Pack{Name: "half-done"})
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Expected fn error to be returned, got %v", err)
+	}
+
+	stickers, err := ListStickers(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to list stickers: %v", err)
+	}
+	if len(stickers) != 1 {
+		t.Errorf("Expected collection to be unchanged, got %d stickers", len(stickers))
+	}
+	if _, err := GetPack(tmpDir, "half-done"); err == nil {
+		t.Error("Expected pack from failed transaction not to exist")
+	}
+}
+
+// TestUpdate_ConcurrentWriters verifies concurrent writers don't lose each other's changes
+func TestUpdate_ConcurrentWriters(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- AddSticker(tmpDir, testSticker(fmt.Sprintf("sha256:sticker%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Concurrent add failed: %v", err)
+		}
+	}
+
+	stickers, err := ListStickers(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to list stickers: %v", err)
+	}
+	if len(stickers) != writers {
+		t.Errorf("Expected %d stickers, got %d", writers, len(stickers))
+	}
+}
+
+// TestRecoverJournal_RollsForward verifies a committed but unfinished transaction completes
+func TestRecoverJournal_RollsForward(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// Simulate a crash after the journal was written but before the renames
+	pendingCollection := `{"stickers": [{"id": "sha256:abc123", "in_packs": ["favourites"]}]}`
+	pendingPacks := `{"packs": [{"name": "favourites", "sticker_ids": ["sha256:abc123"]}]}`
+	writeTestFile(t, tmpDir, collectionFile+pendingSuffix, pendingCollection)
+	writeTestFile(t, tmpDir, packsFile+pendingSuffix, pendingPacks)
+	writeTestFile(t, tmpDir, journalFile, `["collection.json", "packs.json"]`)
+
+	pack, err := GetPack(tmpDir, "favourites")
+	if err != nil {
+		t.Fatalf("Expected committed pack to be recovered: %v", err)
+	}
+	if len(pack.StickerIDs) != 1 {
+		t.Errorf("Expected recovered pack to contain 1 sticker, got %d", len(pack.StickerIDs))
+	}
+
+	sticker, err := GetSticker(tmpDir, "sha256:abc123")
+	if err != nil {
+		t.Fatalf("Expected committed sticker to be recovered: %v", err)
+	}
+	if !containsString(sticker.InPacks, "favourites") {
+		t.Error("Expected recovered sticker to reference pack")
+	}
+
+	assertNotExists(t, filepath.Join(tmpDir, journalFile))
+	assertNotExists(t, filepath.Join(tmpDir, packsFile+pendingSuffix))
+}
+
+// TestRecoverJournal_DiscardsUncommitted verifies pending files without a journal are dropped
+func TestRecoverJournal_DiscardsUncommitted(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := AddSticker(tmpDir, testSticker("sha256:abc123")); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+
+	// Simulate a crash while pending files were still being written
+	writeTestFile(t, tmpDir, collectionFile+pendingSuffix, `{"stickers": []}`)
+
+	if err := AddSticker(tmpDir, testSticker("sha256:def456")); err != nil {
+		t.Fatalf("Failed to add sticker: %v", err)
+	}
+
+	stickers, err := ListStickers(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to list stickers: %v", err)
+	}
+	if len(stickers) != 2 {
+		t.Errorf("Expected aborted transaction to be ignored, got %d stickers", len(stickers))
+	}
+	assertNotExists(t, filepath.Join(tmpDir, collectionFile+pendingSuffix))
+}
+
+// TestSaveCollection_NoTempFilesLeft verifies atomic writes clean up after themselves
+func TestSaveCollection_NoTempFilesLeft(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	collection := &Collection{Stickers: []Sticker{testSticker("sha256:abc123")}}
+	if err := SaveCollection(tmpDir, collection); err != nil {
+		t.Fatalf("Failed to save collection: %v", err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to read data dir: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != collectionFile && entry.Name() != lockFile {
+			t.Errorf("Unexpected file left in data dir: %s", entry.Name())
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func assertNotExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", filepath.Base(path))
+	}
+}
@@ -7,6 +7,7 @@ type Sticker struct {
 	ID               string    `json:"id"`                 // SHA256 hash of image data (internal ID)
 	Name             string    `json:"name"`               // Shortcode name for emoji (defaults to ID)
 	CollectedAt      time.Time `json:"collected_at"`       // When sticker was collected
+	CollectedBy      string    `json:"collected_by"`       // Matrix ID of the user who collected it
 	SourceRoom       string    `json:"source_room"`        // Room ID where found
 	SourceEvent      string    `json:"source_event"`       // Event ID of original message
 	SourceMXC        string    `json:"source_mxc"`         // Original MXC URI
@@ -16,14 +17,64 @@ type Sticker struct {
 	Height           int       `json:"height"`             // Image height in pixels
 	SizeBytes        int64     `json:"size_bytes"`         // File size in bytes
 	OriginalBody     string    `json:"original_body"`      // Original description/alt-text
-	GeneratedAltText string    `json:"generated_alt_text"` // Claude-generated alt-text
+	GeneratedAltText string    `json:"generated_alt_text"` // Model-generated alt-text
 	InPacks          []string  `json:"in_packs"`           // Pack names containing this sticker
 	Usage            []string  `json:"usage,omitempty"`    // Usage types: "sticker", "emoticon", or both
+	Tags             []string  `json:"tags,omitempty"`     // Free-form lowercase tags, in the order they were added
+
+	// AltTexts holds model-generated alt-text by language code, for each language in
+	// alt_text.languages. GeneratedAltText is the first language's.
+	AltTexts     map[string]string `json:"alt_texts,omitempty"`
+	AltTextBy    string            `json:"alt_text_by,omitempty"`    // AltTextByHuman or AltTextByModel; empty for older, machine-written alt-text
+	AltTextModel string            `json:"alt_text_model,omitempty"` // Model that wrote machine-written alt-text
+
+	SuggestedName string `json:"suggested_name,omitempty"` // Shortcode suggested by the alt-text model, until a name is set
+}
+
+// Who wrote a sticker's alt-text
+const (
+	AltTextByHuman = "human" // Written or edited by hand, so never regenerated in bulk
+	AltTextByModel = "model" // Generated by the alt-text provider
+)
+
+// AltText is a sticker's alt-text and who wrote it, for UpdateAltText
+type AltText struct {
+	Text      string            // Main alt-text (Sticker.GeneratedAltText)
+	Languages map[string]string // Alt-text by language (Sticker.AltTexts)
+	By        string            // AltTextByHuman or AltTextByModel
+	Model     string            // Model that wrote it, for AltTextByModel
+}
+
+// HumanAltText reports whether the sticker's alt-text was written by hand
+func (s Sticker) HumanAltText() bool {
+	return s.AltTextBy == AltTextByHuman
+}
+
+// Shortcode returns the shortcode the sticker is published under: its name, or its
+// ID if it has none
+func (s Sticker) Shortcode() string {
+	if s.Name == "" {
+		return s.ID
+	}
+	return s.Name
+}
+
+// AltTextIn returns the sticker's alt-text in a language, falling back to the main
+// generated alt-text and then the original description
+func (s Sticker) AltTextIn(language string) string {
+	if altText := s.AltTexts[language]; altText != "" {
+		return altText
+	}
+	if s.GeneratedAltText != "" {
+		return s.GeneratedAltText
+	}
+	return s.OriginalBody
 }
 
 // Collection holds all collected stickers
 type Collection struct {
-	Stickers []Sticker `json:"stickers"`
+	SchemaVersion int       `json:"schema_version"` // Data format version, see CurrentSchemaVersion
+	Stickers      []Sticker `json:"stickers"`
 }
 
 // Pack represents a curated sticker pack
@@ -35,9 +86,12 @@ type Pack struct {
 	StickerIDs     []string          `json:"sticker_ids"`               // Sticker IDs in this pack
 	PublishedRooms map[string]string `json:"published_rooms,omitempty"` // Room ID -> state key mapping
 	Usage          []string          `json:"usage,omitempty"`           // Default usage for pack: "sticker", "emoticon", or both
+
+	PublishedPersonal bool `json:"published_personal,omitempty"` // Published as the user's personal emotes (im.ponies.user_emotes)
 }
 
 // PacksData holds all pack definitions
 type PacksData struct {
-	Packs []Pack `json:"packs"`
+	SchemaVersion int    `json:"schema_version"` // Data format version, see CurrentSchemaVersion
+	Packs         []Pack `json:"packs"`
 }
@@ -57,14 +57,17 @@ func FormatUsage(usage []string) string {
 	return strings.Join(usage, ", ")
 }
 
+// MaxShortcodeLength is the longest shortcode ValidateShortcode accepts
+const MaxShortcodeLength = 64
+
 // ValidateShortcode checks if a shortcode name is valid for emoji usage
-// Valid shortcodes: alphanumeric, underscores, hyphens, 1-64 chars
+// Valid shortcodes: alphanumeric, underscores, hyphens, 1-MaxShortcodeLength chars
 func ValidateShortcode(name string) error {
 	if len(name) == 0 {
 		return fmt.Errorf("shortcode cannot be empty")
 	}
-	if len(name) > 64 {
-		return fmt.Errorf("shortcode too long (max 64 characters)")
+	if len(name) > MaxShortcodeLength {
+		return fmt.Errorf("shortcode too long (max %d characters)", MaxShortcodeLength)
 	}
 
 	// Allow alphanumeric, underscore, hyphen